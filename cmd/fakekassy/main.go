@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"github.com/ekstyle/go_backend/fakekassy"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+func main() {
+	addr := flag.String("addr", ":8081", "Binding address")
+	secret := flag.String("secret", os.Getenv("API_SECRET_KEY"), "Secret key used to check sign")
+	fixturesPath := flag.String("fixtures", "", "JSON fixtures file (demo data if not set)")
+	flag.Parse()
+
+	fixtures := fakekassy.DemoFixtures()
+	if *fixturesPath != "" {
+		var err error
+		fixtures, err = fakekassy.LoadFixtures(*fixturesPath)
+		if err != nil {
+			slog.Error("Can`t load fixtures", "path", *fixturesPath, "error", err)
+			os.Exit(1)
+		}
+	}
+	slog.Info("Fake kassy api listening", "addr", *addr)
+	err := http.ListenAndServe(*addr, fakekassy.NewServer(*secret, fixtures))
+	slog.Error("Fake kassy api stopped", "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,80 @@
+package fakekassy
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ekstyle/go_backend/lib"
+	"strconv"
+	"time"
+)
+
+const DEMO_BUILDING_ID = 603
+const DEMO_HALL_ID = 1
+const DEMO_SHOW_ID = 1
+const DEMO_EVENT_ID = 1
+const DEMO_TICKETS = 600
+
+// Barcode used by go_backend_test.go
+const DEMO_BARCODE = "3749797650507"
+
+const demoPageEventList = `{
+	"db": "ekb",
+	"module": "page_event_list",
+	"content": {
+		"subdivision": [{"id": "1", "db": "ekb", "city": "Demo", "title": "Demo", "tz": "Asia/Yekaterinburg", "state": "1"}],
+		"building": [{"id": "%[1]d", "title": "Demo building", "address": "Demo street, 1", "hall_count": "1", "state": "1"}],
+		"hall": [{"id": "%[2]d", "building_id": "%[1]d", "title": "Demo hall", "state": "1"}],
+		"show": [{"id": "%[3]d", "title": "Demo show", "state": "1"}],
+		"event": [{"id": "%[4]d", "show_id": "%[3]d", "hall_id": "%[2]d", "date": "%[5]d", "is_sale": "1", "event_state": "1", "state": "1"}]
+	}
+}`
+
+// One building with one event starting in 30 minutes, so it is open for entry right away
+func DemoFixtures() Fixtures {
+	eventDt := time.Now().Add(time.Minute * 30).Unix()
+
+	var page lib.PageEventList
+	json.Unmarshal([]byte(fmt.Sprintf(demoPageEventList, DEMO_BUILDING_ID, DEMO_HALL_ID, DEMO_SHOW_ID, DEMO_EVENT_ID, eventDt)), &page)
+
+	var acs lib.ACSExportEvent
+	acs.Module = MODULE_ACS_EXPORT_EVENT
+	event := &acs.Content.Data.Event
+	event.EventID = DEMO_EVENT_ID
+	event.ShowID = DEMO_SHOW_ID
+	event.ShowTitle = "Demo show"
+	event.EventDt = int(eventDt)
+	event.VenueID = DEMO_BUILDING_ID
+	event.VenueTitle = "Demo building"
+	event.HallID = DEMO_HALL_ID
+	event.HallTitle = "Demo hall"
+	for i := 1; i <= DEMO_TICKETS; i++ {
+		barcode := fmt.Sprintf("%012d", i)
+		if i == 1 {
+			barcode = DEMO_BARCODE
+		}
+		event.Tickets = append(event.Tickets, lib.TicketExport{
+			TicketID:      i,
+			TicketBarcode: barcode,
+			TicketSector:  "Parterre",
+			TicketTitle:   "Row " + strconv.Itoa((i-1)/20+1) + ", seat " + strconv.Itoa((i-1)%20+1),
+			TicketPrice:   strconv.Itoa(500 + (i-1)/200*250),
+			TicketDt:      int(time.Now().Add(-time.Hour * 24).Unix()),
+			PlaceID:       i,
+			OrderID:       i,
+			CashboxID:     1,
+			CashboxTitle:  "Demo cashbox",
+			OperatorTitle: "Demo operator",
+		})
+	}
+
+	return Fixtures{
+		Buildings: []lib.Building{{
+			ID:        strconv.Itoa(DEMO_BUILDING_ID),
+			Title:     "Demo building",
+			Address:   "Demo street, 1",
+			HallCount: "1",
+		}},
+		EventLists: map[string]lib.PageEventList{strconv.Itoa(DEMO_BUILDING_ID): page},
+		Events:     map[string]lib.ACSExportEvent{strconv.Itoa(DEMO_EVENT_ID): acs},
+	}
+}
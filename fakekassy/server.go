@@ -0,0 +1,260 @@
+package fakekassy
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/ekstyle/go_backend/lib"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const MODULE_ACS_EXPORT_EVENT = "acs_export_event"
+const MODULE_PAGE_EVENT_LIST = "page_event_list"
+const MODULE_TABLE_BUILDING = "table_building"
+
+// Use "*" to inject fault for every module
+const ALL_MODULES = "*"
+
+const RESULT_CODE_OK = 0
+const RESULT_CODE_BAD_REQUEST = 1
+const RESULT_CODE_BAD_SIGN = 2
+const RESULT_CODE_UNKNOWN_MODULE = 3
+const RESULT_CODE_NOT_FOUND = 4
+
+// Api answers, keyed the same way api is asked: buildings by nothing, event lists by building id, events by event id
+type Fixtures struct {
+	Buildings  []lib.Building                `json:"buildings"`
+	EventLists map[string]lib.PageEventList  `json:"event_lists"`
+	Events     map[string]lib.ACSExportEvent `json:"events"`
+}
+
+// Fault injected into module responses
+type Fault struct {
+	Delay  time.Duration `json:"delay"`
+	Code   int           `json:"code"`
+	Status int           `json:"status"`
+	Empty  bool          `json:"empty"`
+}
+
+type RequestXML struct {
+	Db     string `xml:"db,attr"`
+	Module string `xml:"module,attr"`
+	Format string `xml:"format,attr"`
+	Param  struct {
+		EventID int64 `xml:"event_id,attr"`
+	} `xml:"param"`
+	Filter struct {
+		BuildingID string `xml:"building_id,attr"`
+		DateFrom   string `xml:"date_from,attr"`
+		DateTo     string `xml:"date_to,attr"`
+	} `xml:"filter"`
+	Auth struct {
+		ID string `xml:"id,attr"`
+	} `xml:"auth"`
+}
+
+type Result struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+type Response struct {
+	Db      string      `json:"db"`
+	Module  string      `json:"module"`
+	Result  Result      `json:"result"`
+	Errors  interface{} `json:"errors"`
+	Content interface{} `json:"content"`
+}
+
+// Fake kassy api server, speaks the same xml+sign form protocol as API_URL
+type Server struct {
+	SecretKey string
+	mutex     sync.RWMutex
+	fixtures  Fixtures
+	faults    map[string]Fault
+}
+
+func NewServer(secretKey string, fixtures Fixtures) *Server {
+	return &Server{SecretKey: secretKey, fixtures: fixtures, faults: map[string]Fault{}}
+}
+
+func LoadFixtures(path string) (Fixtures, error) {
+	var fixtures Fixtures
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fixtures, err
+	}
+	err = json.Unmarshal(data, &fixtures)
+	return fixtures, err
+}
+
+func (s *Server) SetFixtures(fixtures Fixtures) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fixtures = fixtures
+}
+func (s *Server) SetFault(module string, fault Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults[module] = fault
+}
+func (s *Server) ResetFaults() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = map[string]Fault{}
+}
+func (s *Server) fault(module string) (Fault, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if fault, ok := s.faults[module]; ok {
+		return fault, true
+	}
+	fault, ok := s.faults[ALL_MODULES]
+	return fault, ok
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/_faults":
+		s.FaultsHandler(w, r)
+	case "/_fixtures":
+		s.FixturesHandler(w, r)
+	default:
+		s.ApiHandler(w, r)
+	}
+}
+
+func respondWithJson(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	w.Write(response)
+}
+
+func (s *Server) ApiHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusOK, Response{Result: Result{RESULT_CODE_BAD_REQUEST, err.Error()}})
+		return
+	}
+	xmlString := r.PostForm.Get("xml")
+	var request RequestXML
+	errXml := xml.Unmarshal([]byte(xmlString), &request)
+	if errXml != nil {
+		respondWithJson(w, http.StatusOK, Response{Result: Result{RESULT_CODE_BAD_REQUEST, errXml.Error()}})
+		return
+	}
+	response := Response{Db: request.Db, Module: request.Module}
+	if !lib.CheckSign(s.SecretKey, xmlString, r.PostForm.Get("sign")) {
+		slog.Warn("Fake kassy bad sign", "module", request.Module)
+		response.Result = Result{RESULT_CODE_BAD_SIGN, "Invalid sign"}
+		respondWithJson(w, http.StatusOK, response)
+		return
+	}
+
+	status := http.StatusOK
+	fault, faulty := s.fault(request.Module)
+	if faulty {
+		time.Sleep(fault.Delay)
+		if fault.Status != 0 {
+			status = fault.Status
+		}
+	}
+
+	s.mutex.RLock()
+	switch request.Module {
+	case MODULE_ACS_EXPORT_EVENT:
+		response.Result, response.Content = s.acsExportEvent(request)
+	case MODULE_PAGE_EVENT_LIST:
+		response.Result, response.Content = s.pageEventList(request)
+	case MODULE_TABLE_BUILDING:
+		response.Result, response.Content = Result{RESULT_CODE_OK, ""}, s.fixtures.Buildings
+	default:
+		response.Result = Result{RESULT_CODE_UNKNOWN_MODULE, "Unknown module " + request.Module}
+	}
+	s.mutex.RUnlock()
+
+	if faulty {
+		if fault.Code != 0 {
+			response.Result = Result{fault.Code, "Injected fault"}
+			response.Errors = []string{"Injected fault"}
+		}
+		if fault.Empty {
+			response.Content = nil
+		}
+	}
+	respondWithJson(w, status, response)
+}
+func (s *Server) acsExportEvent(request RequestXML) (Result, interface{}) {
+	event, ok := s.fixtures.Events[strconv.FormatInt(request.Param.EventID, 10)]
+	if !ok {
+		return Result{RESULT_CODE_NOT_FOUND, fmt.Sprintf("Event %d not found", request.Param.EventID)}, nil
+	}
+	return Result{RESULT_CODE_OK, ""}, event.Content
+}
+func (s *Server) pageEventList(request RequestXML) (Result, interface{}) {
+	page, ok := s.fixtures.EventLists[request.Filter.BuildingID]
+	if !ok {
+		return Result{RESULT_CODE_NOT_FOUND, "Building " + request.Filter.BuildingID + " not found"}, nil
+	}
+	dateFrom, _ := strconv.ParseInt(request.Filter.DateFrom, 10, 64)
+	dateTo, _ := strconv.ParseInt(request.Filter.DateTo, 10, 64)
+	events := page.Content.Event[:0:0]
+	for _, event := range page.Content.Event {
+		dt, _ := strconv.ParseInt(event.Date, 10, 64)
+		if (dateFrom != 0 && dt < dateFrom) || (dateTo != 0 && dt > dateTo) {
+			continue
+		}
+		events = append(events, event)
+	}
+	page.Content.Event = events
+	return Result{RESULT_CODE_OK, ""}, page.Content
+}
+
+// POST module, delay (ms), code, status, empty to inject fault; DELETE to reset all
+func (s *Server) FaultsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		s.ResetFaults()
+		respondWithJson(w, http.StatusOK, lib.Response{Result: lib.OK_RESPONSE, Code: lib.OK_CODE_RESPONSE})
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+		respondWithJson(w, http.StatusOK, s.faults)
+		return
+	}
+	r.ParseForm()
+	module := r.PostForm.Get("module")
+	if module == "" {
+		module = ALL_MODULES
+	}
+	delay, _ := strconv.Atoi(r.PostForm.Get("delay"))
+	code, _ := strconv.Atoi(r.PostForm.Get("code"))
+	status, _ := strconv.Atoi(r.PostForm.Get("status"))
+	empty, _ := strconv.ParseBool(r.PostForm.Get("empty"))
+	s.SetFault(module, Fault{time.Duration(delay) * time.Millisecond, code, status, empty})
+	respondWithJson(w, http.StatusOK, lib.Response{Result: lib.OK_RESPONSE, Code: lib.OK_CODE_RESPONSE})
+}
+
+// GET current fixtures, POST json body to replace them
+func (s *Server) FixturesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+		respondWithJson(w, http.StatusOK, s.fixtures)
+		return
+	}
+	var fixtures Fixtures
+	err := json.NewDecoder(r.Body).Decode(&fixtures)
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, lib.Exception{Message: lib.PARSE_PARAMS_EXEPTION, Error: err.Error()})
+		return
+	}
+	s.SetFixtures(fixtures)
+	respondWithJson(w, http.StatusOK, lib.Response{Result: lib.OK_RESPONSE, Code: lib.OK_CODE_RESPONSE})
+}
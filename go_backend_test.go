@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/ekstyle/go_backend/fakekassy"
 	"github.com/ekstyle/go_backend/lib"
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
+	neturl "net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -17,6 +22,10 @@ const BARCODEFORTEST = "3749797650507"
 const SECRETKEY = "5d9f2f8931434f346faf8a17be68f0d1"
 const MASTERKEY = "VjKnRbkhDQJIisIq01"
 
+// User of tested server, made with /init
+const TESTLOGIN = "tester"
+const TESTPASSWORD = "Gate-Check-2024"
+
 const MODULEVALIDATION = "validation"
 const MODULEREGISTRATION = "registration"
 const FAILSKDRESPONSE = -100
@@ -41,16 +50,49 @@ func RandomStr(len int) string {
 	return result.String()
 }
 
+var testToken string
+var testLogin sync.Once
+
+// Access token is valid only with server-side session, so test user logs in once per run
+func TesterToken() (string, error) {
+	var errLogin error
+	testLogin.Do(func() {
+		var login *http.Response
+		login, errLogin = http.PostForm(fmt.Sprintf("http://localhost%s/login", GetPort()), neturl.Values{"login": {TESTLOGIN}, "password": {TESTPASSWORD}})
+		if errLogin != nil {
+			return
+		}
+		login.Body.Close()
+		for _, cookie := range login.Cookies() {
+			if cookie.Name == "token" {
+				testToken = cookie.Value
+			}
+		}
+	})
+	if errLogin == nil && testToken == "" {
+		errLogin = fmt.Errorf("Login as %s failed", TESTLOGIN)
+	}
+	return testToken, errLogin
+}
+
 func TesterGET(urltest string) ([]byte, error) {
 	var buf []byte
 	url := fmt.Sprintf("http://localhost%s/%s", GetPort(), urltest)
-	resp, err := http.Get(url)
-	defer resp.Body.Close()
-
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return buf, err
+	}
+	token, err := TesterToken()
+	if err != nil {
+		return buf, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 
 		return buf, err
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return buf, fmt.Errorf("Error GET %s: %s", url, resp.Status)
 	}
@@ -119,3 +161,512 @@ func TestValidationRegistration(t *testing.T) {
 		}
 	}
 }
+
+func TestFakeKassyApi(t *testing.T) {
+	fake := fakekassy.NewServer(SECRETKEY, fakekassy.DemoFixtures())
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	api := lib.Api{Url: server.URL, Db: "ekb", SecretKey: SECRETKEY}
+
+	if buildings, err := api.GetBuildings(); err != nil || len(buildings) != 1 {
+		t.Errorf("Buildings: expected 1, actual %d (%v)", len(buildings), err)
+	}
+	now := time.Now()
+	page, err := api.PageEventList(fakekassy.DEMO_BUILDING_ID, now.Add(-time.Hour).Unix(), now.Add(time.Hour).Unix())
+	if err != nil {
+		t.Errorf("Event list: %v", err)
+	}
+	events := page.ToEvents()
+	if len(events.Events) != 1 || events.Events[0].Id != fakekassy.DEMO_EVENT_ID {
+		t.Errorf("Event list: expected event #%d, actual %v", fakekassy.DEMO_EVENT_ID, events.Events)
+	}
+	if rez, err := api.GetEventACS(fakekassy.DEMO_EVENT_ID); err != nil || len(rez.Content.Data.Event.Tickets) != fakekassy.DEMO_TICKETS {
+		t.Errorf("Tickets: expected %d, actual %d (%v)", fakekassy.DEMO_TICKETS, len(rez.Content.Data.Event.Tickets), err)
+	}
+	//Bad sign
+	badApi := lib.Api{Url: server.URL, Db: "ekb", SecretKey: RandomStr(32)}
+	if rez, _ := badApi.GetEventACS(fakekassy.DEMO_EVENT_ID); rez.Result.Code != fakekassy.RESULT_CODE_BAD_SIGN {
+		t.Errorf("Bad sign: expected code %d, actual %d", fakekassy.RESULT_CODE_BAD_SIGN, rez.Result.Code)
+	}
+	//Injected faults
+	fake.SetFault(fakekassy.MODULE_ACS_EXPORT_EVENT, fakekassy.Fault{Code: 500, Empty: true})
+	if rez, _ := api.GetEventACS(fakekassy.DEMO_EVENT_ID); rez.Result.Code != 500 || len(rez.Content.Data.Event.Tickets) != 0 {
+		t.Errorf("Fault: expected code 500 without tickets, actual %d with %d tickets", rez.Result.Code, len(rez.Content.Data.Event.Tickets))
+	}
+	fake.ResetFaults()
+	//Unreachable api is an error, not a panic
+	downApi := lib.Api{Url: "http://127.0.0.1:0", Db: "ekb", SecretKey: SECRETKEY}
+	if _, err := downApi.GetEventACS(fakekassy.DEMO_EVENT_ID); err == nil {
+		t.Errorf("Unreachable api: expected error")
+	}
+}
+
+func TestVenueTimezone(t *testing.T) {
+	var tzTests = []struct {
+		tz       string
+		expected string
+	}{
+		{"Asia/Yekaterinburg", "2019-03-01T21:00:00+05:00"},
+		{"5", "2019-03-01T21:00:00+05:00"},
+		{"+03:00", "2019-03-01T19:00:00+03:00"},
+		{"-2", "2019-03-01T14:00:00-02:00"},
+	}
+	for idx, tt := range tzTests {
+		actual := lib.IsoTime(1551456000, lib.LoadLocation(tt.tz))
+		if actual != tt.expected {
+			t.Errorf("(#%d) Timezone %s: expected %s, actual %s", idx+1, tt.tz, tt.expected, actual)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	var moneyTests = []struct {
+		price    string
+		expected string
+	}{
+		{"1500", "1500.00"},
+		{"1500.5", "1500.50"},
+		{"1500,05", "1500.05"},
+		{"0.999", "1.00"},
+		{"-20.10", "-20.10"},
+		{"", "0.00"},
+	}
+	for idx, tt := range moneyTests {
+		money, err := lib.ParseMoney(tt.price)
+		if err != nil || money.String() != tt.expected {
+			t.Errorf("(#%d) Money %s: expected %s, actual %s (%v)", idx+1, tt.price, tt.expected, money, err)
+		}
+	}
+	if money, _ := lib.ParseMoney("1500.50"); money.Mul(3).String() != "4501.50" {
+		t.Errorf("Money mul: expected 4501.50, actual %s", money.Mul(3))
+	}
+	if _, err := lib.ParseMoney("12a"); err == nil {
+		t.Error("Money 12a: expected error")
+	}
+}
+
+func TestSyncRunFromTickets(t *testing.T) {
+	ticket := func(barcode string, price string) lib.TicketExport {
+		return lib.TicketExport{TicketID: 1, TicketBarcode: barcode, TicketPrice: price}
+	}
+	var syncTests = []struct {
+		cached  []lib.TicketExport
+		fresh   []lib.TicketExport
+		added   string
+		removed string
+		changed string
+	}{
+		{nil, nil, "[]", "[]", "[]"},
+		{nil, []lib.TicketExport{ticket("1", "100"), ticket("2", "100")}, "[1 2]", "[]", "[]"},
+		{[]lib.TicketExport{ticket("1", "100"), ticket("2", "100")}, nil, "[]", "[1 2]", "[]"},
+		{[]lib.TicketExport{ticket("1", "100")}, []lib.TicketExport{ticket("1", "100")}, "[]", "[]", "[]"},
+		{[]lib.TicketExport{ticket("1", "100")}, []lib.TicketExport{ticket("1", "200")}, "[]", "[]", "[1]"},
+		{[]lib.TicketExport{ticket("1", "100"), ticket("2", "100")}, []lib.TicketExport{ticket("2", "150"), ticket("3", "100")}, "[3]", "[1]", "[2]"},
+	}
+	for idx, tt := range syncTests {
+		var run lib.SyncRun
+		run.FromTickets(tt.cached, tt.fresh)
+		if fmt.Sprint(run.Added) != tt.added || fmt.Sprint(run.Removed) != tt.removed || fmt.Sprint(run.Changed) != tt.changed {
+			t.Errorf("#%d: expected %s %s %s, actual %v %v %v", idx+1, tt.added, tt.removed, tt.changed, run.Added, run.Removed, run.Changed)
+		}
+		if run.Tickets != len(tt.fresh) {
+			t.Errorf("#%d: expected %d tickets, actual %d", idx+1, len(tt.fresh), run.Tickets)
+		}
+	}
+}
+
+func TestSyncDiffFromRuns(t *testing.T) {
+	run := func(added []string, removed []string, changed []string) lib.SyncRun {
+		return lib.SyncRun{Added: added, Removed: removed, Changed: changed}
+	}
+	var diffTests = []struct {
+		runs    []lib.SyncRun
+		added   string
+		removed string
+		changed string
+	}{
+		{nil, "[]", "[]", "[]"},
+		{[]lib.SyncRun{run([]string{"1"}, []string{"2"}, []string{"3"})}, "[1]", "[2]", "[3]"},
+		//added then removed within range is no change
+		{[]lib.SyncRun{run([]string{"1"}, nil, nil), run(nil, []string{"1"}, nil)}, "[]", "[]", "[]"},
+		//removed then added back is a change
+		{[]lib.SyncRun{run(nil, []string{"1"}, nil), run([]string{"1"}, nil, nil)}, "[]", "[]", "[1]"},
+		//added then changed is still added
+		{[]lib.SyncRun{run([]string{"1"}, nil, nil), run(nil, nil, []string{"1"})}, "[1]", "[]", "[]"},
+		//changed then removed is removed
+		{[]lib.SyncRun{run(nil, nil, []string{"1"}), run(nil, []string{"1"}, nil)}, "[]", "[1]", "[]"},
+	}
+	for idx, tt := range diffTests {
+		var diff lib.SyncDiff
+		diff.FromRuns(tt.runs)
+		if fmt.Sprint(diff.Added) != tt.added || fmt.Sprint(diff.Removed) != tt.removed || fmt.Sprint(diff.Changed) != tt.changed {
+			t.Errorf("#%d: expected %s %s %s, actual %v %v %v", idx+1, tt.added, tt.removed, tt.changed, diff.Added, diff.Removed, diff.Changed)
+		}
+	}
+}
+
+func TestTicketsFromRows(t *testing.T) {
+	var importTests = []struct {
+		rows    [][]string
+		tickets string
+		errors  string
+	}{
+		{nil, "", "[{0  empty file}]"},
+		{[][]string{{"sector", "price"}}, "", "[{1  barcode column not found in header}]"},
+		//header aliases, case and spaces
+		{[][]string{{" Code ", "TICKET_SECTOR", "place", "ticket_price", "holder_name"}, {"111", "A", "1-1", "1500", "Ivanov"}},
+			"111/A/1-1/1500.00/Ivanov ", "[]"},
+		{[][]string{{"barcode", "price"}, {"111", "100"}, {"222", ""}, {"111", "200"}},
+			"111///100.00/ 222//// ", "[{4 111 duplicate barcode, first seen in row 2}]"},
+		{[][]string{{"barcode", "price"}, {"111", "12a"}, {"222", "10,5"}},
+			"222///10.50/ ", "[{2 111 price is not a number}]"},
+		{[][]string{{"barcode", "sector"}, {"", ""}, {"", "A"}, {"111"}},
+			"111//// ", "[{3  empty barcode}]"},
+	}
+	for idx, tt := range importTests {
+		tickets, _, errors := lib.TicketsFromRows(tt.rows, 1, lib.ImportSource(""))
+		actual := ""
+		for _, ticket := range tickets {
+			actual += ticket.TicketBarcode + "/" + ticket.TicketSector + "/" + ticket.TicketTitle + "/" + ticket.TicketPrice + "/" + ticket.CustomerTitle + " "
+		}
+		if actual != tt.tickets || fmt.Sprint(errors) != tt.errors {
+			t.Errorf("#%d: expected %q %s, actual %q %v", idx+1, tt.tickets, tt.errors, actual, errors)
+		}
+	}
+}
+
+func TestEventApplyLifecycle(t *testing.T) {
+	var lifecycleTests = []struct {
+		cached   lib.Event
+		synced   lib.Event
+		status   string
+		expected string
+	}{
+		//new event takes status from api
+		{lib.Event{}, lib.Event{Id: 1, EventDT: 100}, "", "active/100/0/false"},
+		{lib.Event{}, lib.Event{Id: 1, EventDT: 100}, lib.EVENT_STATUS_CANCELLED, "cancelled/100/0/false"},
+		//manual status wins, rescheduled keeps its date
+		{lib.Event{Id: 1, EventDT: 100, Status: lib.EVENT_STATUS_POSTPONED, StatusManual: true}, lib.Event{Id: 1, EventDT: 200}, lib.EVENT_STATUS_ACTIVE, "postponed/200/0/true"},
+		{lib.Event{Id: 1, EventDT: 300, OriginalDT: 100, Status: lib.EVENT_STATUS_RESCHEDULED, StatusManual: true}, lib.Event{Id: 1, EventDT: 100}, lib.EVENT_STATUS_ACTIVE, "rescheduled/300/100/true"},
+		//date change sets original date, active event becomes rescheduled
+		{lib.Event{Id: 1, EventDT: 100, Status: lib.EVENT_STATUS_ACTIVE}, lib.Event{Id: 1, EventDT: 200}, lib.EVENT_STATUS_ACTIVE, "rescheduled/200/100/false"},
+		{lib.Event{Id: 1, EventDT: 200, OriginalDT: 100, Status: lib.EVENT_STATUS_RESCHEDULED}, lib.Event{Id: 1, EventDT: 300}, lib.EVENT_STATUS_ACTIVE, "rescheduled/300/100/false"},
+		{lib.Event{Id: 1, EventDT: 100, Status: lib.EVENT_STATUS_ACTIVE}, lib.Event{Id: 1, EventDT: 200}, lib.EVENT_STATUS_CANCELLED, "cancelled/200/100/false"},
+		//back to original date
+		{lib.Event{Id: 1, EventDT: 200, OriginalDT: 100, Status: lib.EVENT_STATUS_RESCHEDULED}, lib.Event{Id: 1, EventDT: 100}, lib.EVENT_STATUS_ACTIVE, "active/100/0/false"},
+		{lib.Event{Id: 1, EventDT: 100, Status: lib.EVENT_STATUS_ACTIVE}, lib.Event{Id: 1, EventDT: 100}, "", "active/100/0/false"},
+	}
+	for idx, tt := range lifecycleTests {
+		event := tt.synced
+		event.ApplyLifecycle(tt.cached, tt.status)
+		actual := fmt.Sprintf("%s/%d/%d/%t", event.Status, event.EventDT, event.OriginalDT, event.StatusManual)
+		if actual != tt.expected {
+			t.Errorf("#%d: expected %s, actual %s", idx+1, tt.expected, actual)
+		}
+	}
+}
+
+func TestEventRejectCode(t *testing.T) {
+	var rejectTests = []struct {
+		status   string
+		code     int64
+		rejected bool
+	}{
+		{lib.EVENT_STATUS_ACTIVE, 0, false},
+		{lib.EVENT_STATUS_RESCHEDULED, 0, false},
+		{lib.EVENT_STATUS_CANCELLED, lib.ENTRY_RESULT_CODE_CANCELLED, true},
+		{lib.EVENT_STATUS_POSTPONED, lib.ENTRY_RESULT_CODE_POSTPONED, true},
+		{"", 0, false},
+	}
+	for _, tt := range rejectTests {
+		event := lib.Event{Status: tt.status}
+		code, rejected := event.RejectCode()
+		if code != tt.code || rejected != tt.rejected {
+			t.Errorf("%q: expected %d %t, actual %d %t", tt.status, tt.code, tt.rejected, code, rejected)
+		}
+	}
+}
+
+func anomaliesString(anomalies []lib.Anomaly) string {
+	result := ""
+	for _, anomaly := range anomalies {
+		result += fmt.Sprintf("%s/%d/%v/%v/%d ", anomaly.Type, anomaly.Dt, anomaly.TerminalIds, anomaly.Barcodes, anomaly.Count)
+	}
+	return result
+}
+
+func TestDuplicateScans(t *testing.T) {
+	var duplicateTests = []struct {
+		entries  []lib.Entry
+		expected string
+	}{
+		{nil, ""},
+		//other gate within window, same gate, other barcode
+		{[]lib.Entry{{TicketBarcode: "111", TerminalId: 1, OperationDt: 100}, {TicketBarcode: "111", TerminalId: 2, OperationDt: 130}},
+			"duplicate_scan/130/[1 2]/[111]/2 "},
+		{[]lib.Entry{{TicketBarcode: "111", TerminalId: 1, OperationDt: 100}, {TicketBarcode: "111", TerminalId: 2, OperationDt: 131}}, ""},
+		{[]lib.Entry{{TicketBarcode: "111", TerminalId: 1, OperationDt: 100}, {TicketBarcode: "111", TerminalId: 1, OperationDt: 110}}, ""},
+		{[]lib.Entry{{TicketBarcode: "111", TerminalId: 1, OperationDt: 100}, {TicketBarcode: "222", TerminalId: 2, OperationDt: 110}}, ""},
+		{[]lib.Entry{{TicketBarcode: "111", TerminalId: 1, OperationDt: 100}, {TicketBarcode: "111", TerminalId: 2, OperationDt: 110}, {TicketBarcode: "111", TerminalId: 3, OperationDt: 120}},
+			"duplicate_scan/110/[1 2]/[111]/2 duplicate_scan/120/[2 3]/[111]/2 "},
+	}
+	for idx, tt := range duplicateTests {
+		actual := anomaliesString(lib.DuplicateScans(tt.entries))
+		if actual != tt.expected {
+			t.Errorf("#%d: expected %q, actual %q", idx+1, tt.expected, actual)
+		}
+	}
+}
+
+func TestNotFoundBursts(t *testing.T) {
+	scans := func(terminalId int64, dts ...int64) []lib.NotFoundScan {
+		result := []lib.NotFoundScan{}
+		for _, dt := range dts {
+			result = append(result, lib.NotFoundScan{TerminalId: terminalId, Dt: dt, Barcode: fmt.Sprint(dt)})
+		}
+		return result
+	}
+	var burstTests = []struct {
+		scans    []lib.NotFoundScan
+		expected string
+	}{
+		{nil, ""},
+		//one scan less than a burst, exactly a burst, burst outside window
+		{scans(1, 0, 10, 20, 30), ""},
+		{scans(1, 0, 10, 20, 30, 40), "notfound_burst/0/[1]/[0 10 20 30 40]/5 "},
+		{scans(1, 0, 20, 40, 60, 61), ""},
+		//burst grows while window holds enough scans, then closes
+		{scans(1, 0, 10, 20, 30, 40, 50, 200), "notfound_burst/0/[1]/[0 10 20 30 40 50]/6 "},
+		//burst closes at terminal boundary, scans of next terminal are not counted in it
+		{append(scans(1, 0, 10, 20, 30, 40), scans(2, 45, 50)...), "notfound_burst/0/[1]/[0 10 20 30 40]/5 "},
+		{append(scans(2, 0, 1, 2, 3, 4), scans(1, 5, 6, 7, 8, 9)...),
+			"notfound_burst/5/[1]/[5 6 7 8 9]/5 notfound_burst/0/[2]/[0 1 2 3 4]/5 "},
+		//scans of different terminals do not make a burst together
+		{append(scans(1, 0, 10, 20), scans(2, 5, 15)...), ""},
+	}
+	for idx, tt := range burstTests {
+		actual := anomaliesString(lib.NotFoundBursts(tt.scans))
+		if actual != tt.expected {
+			t.Errorf("#%d: expected %q, actual %q", idx+1, tt.expected, actual)
+		}
+	}
+}
+
+func TestSharedPlaces(t *testing.T) {
+	var placeTests = []struct {
+		tickets  []lib.TicketExport
+		expected string
+	}{
+		{nil, ""},
+		//no place, one barcode per place
+		{[]lib.TicketExport{{TicketBarcode: "111"}, {TicketBarcode: "222"}}, ""},
+		{[]lib.TicketExport{{TicketBarcode: "111", PlaceID: 1}, {TicketBarcode: "222", PlaceID: 2}}, ""},
+		{[]lib.TicketExport{{TicketBarcode: "333", PlaceID: 2}, {TicketBarcode: "111", PlaceID: 1}, {TicketBarcode: "444", PlaceID: 2}, {TicketBarcode: "222", PlaceID: 1}, {TicketBarcode: "555", PlaceID: 2}},
+			"shared_place/0/[]/[111 222]/2 shared_place/0/[]/[333 444 555]/3 "},
+	}
+	for idx, tt := range placeTests {
+		actual := anomaliesString(lib.SharedPlaces(tt.tickets))
+		if actual != tt.expected {
+			t.Errorf("#%d: expected %q, actual %q", idx+1, tt.expected, actual)
+		}
+	}
+}
+
+func TestAnomalyDetectorNotFound(t *testing.T) {
+	detector := lib.NewAnomalyDetector()
+	var detectorTests = []struct {
+		terminalId int64
+		dt         int64
+		count      int
+		burst      bool
+	}{
+		{1, 0, 1, false},
+		{1, 10, 2, false},
+		{2, 10, 1, false},
+		{1, 20, 3, false},
+		{1, 30, 4, false},
+		//alert once, when window gets exactly enough scans
+		{1, 40, 5, true},
+		{1, 50, 6, false},
+		//scans older than window are dropped
+		{1, 100, 3, false},
+		{1, 300, 1, false},
+	}
+	for idx, tt := range detectorTests {
+		count, burst := detector.NotFound(tt.terminalId, tt.dt)
+		if count != tt.count || burst != tt.burst {
+			t.Errorf("#%d: expected %d %t, actual %d %t", idx+1, tt.count, tt.burst, count, burst)
+		}
+	}
+}
+
+func TestReportSchedule(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	//13 march 2024 is wednesday
+	at := func(day int, hour int, min int) time.Time {
+		return time.Date(2024, time.March, day, hour, min, 0, 0, loc)
+	}
+	daily := lib.ReportSchedule{Period: lib.REPORT_PERIOD_DAILY, Hour: 8}
+	weekly := lib.ReportSchedule{Period: lib.REPORT_PERIOD_WEEKLY, Hour: 8, Weekday: int(time.Wednesday)}
+	with := func(schedule lib.ReportSchedule, lastRun time.Time, lastAttempt time.Time) lib.ReportSchedule {
+		schedule.LastRun, schedule.LastAttempt = lastRun.Unix(), lastAttempt.Unix()
+		return schedule
+	}
+	var dueTests = []struct {
+		schedule lib.ReportSchedule
+		now      time.Time
+		due      bool
+	}{
+		{daily, at(13, 7, 59), false},
+		{daily, at(13, 8, 0), true},
+		{daily, at(13, 23, 0), true},
+		//delivered today, delivered yesterday
+		{with(daily, at(13, 8, 1), at(13, 8, 1)), at(13, 9, 0), false},
+		{with(daily, at(12, 8, 1), at(12, 8, 1)), at(13, 9, 0), true},
+		//failed attempt is retried after delay
+		{with(daily, at(12, 8, 1), at(13, 8, 1)), at(13, 8, 10), false},
+		{with(daily, at(12, 8, 1), at(13, 8, 1)), at(13, 8, 11), true},
+		//weekly only on its weekday
+		{weekly, at(13, 8, 0), true},
+		{weekly, at(14, 8, 0), false},
+		{weekly, at(12, 23, 59), false},
+		{with(weekly, at(6, 8, 0), at(6, 8, 0)), at(13, 8, 0), true},
+	}
+	for idx, tt := range dueTests {
+		if due := tt.schedule.IsDue(tt.now); due != tt.due {
+			t.Errorf("#%d: expected %t, actual %t", idx+1, tt.due, due)
+		}
+	}
+	if dueAt, ok := weekly.DueAt(at(13, 15, 30)); !ok || !dueAt.Equal(at(13, 8, 0)) {
+		t.Errorf("weekly due at: expected %v true, actual %v %t", at(13, 8, 0), dueAt, ok)
+	}
+	if _, ok := weekly.DueAt(at(14, 15, 30)); ok {
+		t.Errorf("weekly due at: expected other weekday not to be due")
+	}
+	var rangeTests = []struct {
+		schedule lib.ReportSchedule
+		from     time.Time
+		to       time.Time
+	}{
+		{daily, at(12, 0, 0), at(12, 23, 59).Add(59 * time.Second)},
+		{weekly, at(6, 0, 0), at(12, 23, 59).Add(59 * time.Second)},
+	}
+	for _, tt := range rangeTests {
+		from, to := tt.schedule.ReportRange(at(13, 8, 0))
+		if !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("%s: expected %v - %v, actual %v - %v", tt.schedule.Period, tt.from, tt.to, from, to)
+		}
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	var roleTests = []struct {
+		role       string
+		permission string
+		expected   bool
+	}{
+		{lib.ROLE_VIEWER, lib.PERMISSION_STATS_READ, true},
+		{lib.ROLE_VIEWER, lib.PERMISSION_TICKETS_CHECK, false},
+		{lib.ROLE_OPERATOR, lib.PERMISSION_TICKETS_CHECK, true},
+		{lib.ROLE_OPERATOR, lib.PERMISSION_EVENTS_WRITE, false},
+		{lib.ROLE_MANAGER, lib.PERMISSION_STATS_READ, true},
+		{lib.ROLE_MANAGER, lib.PERMISSION_USERS_WRITE, false},
+		{lib.ROLE_ADMIN, lib.PERMISSION_SYSTEM, true},
+		{lib.ROLE_ADMIN, "", false},
+		{lib.ROLE_ADMIN, "unknown", false},
+		{"", lib.PERMISSION_AUTHENTICATED, false},
+		{"root", lib.PERMISSION_STATS_READ, false},
+	}
+	for idx, tt := range roleTests {
+		if actual := lib.RoleAllows(tt.role, tt.permission); actual != tt.expected {
+			t.Errorf("#%d %s %s: expected %v, actual %v", idx+1, tt.role, tt.permission, tt.expected, actual)
+		}
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := lib.HashPassword("Gate-Check-2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := lib.LegacyHashPassword("demo")
+	var passwordTests = []struct {
+		hash     string
+		password string
+		valid    bool
+		legacy   bool
+	}{
+		{hash, "Gate-Check-2024", true, false},
+		{hash, "gate-check-2024", false, false},
+		{hash, "", false, false},
+		{legacy, "demo", true, true},
+		{legacy, "Demo", false, true},
+		{"", "", false, false},
+		{"", "demo", false, false},
+	}
+	for idx, tt := range passwordTests {
+		valid, isLegacy := lib.VerifyPassword(tt.hash, tt.password)
+		if valid != tt.valid || isLegacy != tt.legacy {
+			t.Errorf("#%d %q: expected %v %v, actual %v %v", idx+1, tt.password, tt.valid, tt.legacy, valid, isLegacy)
+		}
+	}
+}
+
+func TestCheckPasswordStrength(t *testing.T) {
+	var strengthTests = []struct {
+		password string
+		login    string
+		strong   bool
+	}{
+		{"Gate-Check-2024", "tester", true},
+		{"Пароль-ворот-7", "tester", true},
+		{"demo", "demo", false},
+		{"Short-1", "tester", false},
+		{"alllowercase1", "tester", false},
+		{"NoDigitsOrOther", "tester", false},
+		{"MyTester-2024", "tester", false},
+		{"Aa1-" + strings.Repeat("x", 70), "tester", false},
+	}
+	for idx, tt := range strengthTests {
+		if ex := lib.CheckPasswordStrength(tt.password, tt.login); (ex == nil) != tt.strong {
+			t.Errorf("#%d %q: expected strong %v, actual %v", idx+1, tt.password, tt.strong, ex)
+		}
+	}
+}
+
+func TestSessionActive(t *testing.T) {
+	now := time.Now()
+	var sessionTests = []struct {
+		session  lib.Session
+		expected bool
+	}{
+		{lib.Session{Id: "a", Expires: now.Add(time.Hour)}, true},
+		{lib.Session{Id: "a", Expires: now.Add(-time.Second)}, false},
+		{lib.Session{Id: "a", Expires: now.Add(time.Hour), Revoked: now.Unix()}, false},
+		{lib.Session{Expires: now.Add(time.Hour)}, false},
+	}
+	for idx, tt := range sessionTests {
+		if actual := tt.session.Active(now); actual != tt.expected {
+			t.Errorf("#%d: expected %v, actual %v", idx+1, tt.expected, actual)
+		}
+	}
+}
+
+func TestWebhookFresh(t *testing.T) {
+	now := time.Now()
+	var hookTests = []struct {
+		dt       int64
+		expected bool
+	}{
+		{now.Unix(), true},
+		{now.Unix() - lib.WEBHOOK_MAX_AGE, true},
+		{now.Unix() - lib.WEBHOOK_MAX_AGE - 1, false},
+		{now.Unix() + lib.WEBHOOK_MAX_AGE + 1, false},
+		{0, false},
+	}
+	for idx, tt := range hookTests {
+		if actual := (lib.TicketWebhook{Dt: tt.dt}).Fresh(now); actual != tt.expected {
+			t.Errorf("#%d %d: expected %v, actual %v", idx+1, tt.dt, tt.expected, actual)
+		}
+	}
+}
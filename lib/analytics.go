@@ -0,0 +1,180 @@
+package lib
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const TIMELINE_DEFAULT_INTERVAL = 5
+const TIMELINE_MAX_INTERVAL = 180
+
+// Row of entry aggregation grouped by bucket, terminal and direction
+type timelineRow struct {
+	Id struct {
+		Bucket     int64  `bson:"bucket"`
+		TerminalId int64  `bson:"terminal_id"`
+		Direction  string `bson:"direction"`
+	} `bson:"_id"`
+	Count int64 `bson:"count"`
+}
+
+type timelineCounter struct {
+	series  TimelineSeries
+	buckets map[int64]*TimelineBucket
+}
+
+func newTimelineCounter(id int64, name string) *timelineCounter {
+	return &timelineCounter{TimelineSeries{Id: id, Name: name}, map[int64]*TimelineBucket{}}
+}
+func (r *timelineCounter) add(bucket int64, direction string, count int64) {
+	b, ok := r.buckets[bucket]
+	if !ok {
+		b = &TimelineBucket{Dt: bucket}
+		r.buckets[bucket] = b
+	}
+	if direction == "exit" {
+		b.Exit += count
+		r.series.Exit += count
+	} else {
+		b.Entry += count
+		r.series.Entry += count
+	}
+}
+
+// Series with empty buckets filled from first to last, so graphs share x axis
+func (r *timelineCounter) fill(first, last, interval int64, loc *time.Location) TimelineSeries {
+	series := r.series
+	series.Buckets = []TimelineBucket{}
+	for dt := first; dt <= last && interval > 0; dt += interval {
+		bucket := TimelineBucket{Dt: dt}
+		if b, ok := r.buckets[dt]; ok {
+			bucket = *b
+		}
+		bucket.DtISO = IsoTime(dt, loc)
+		series.Buckets = append(series.Buckets, bucket)
+	}
+	return series
+}
+
+func (r *EventTimeline) fromRows(rows []timelineRow, terminals []Terminal, groups []Group, loc *time.Location) {
+	total := newTimelineCounter(r.EventId, "Total")
+	byTerminal := map[int64]*timelineCounter{}
+	byGroup := map[int64]*timelineCounter{}
+	terminalById := map[int64]Terminal{}
+	for _, terminal := range terminals {
+		terminalById[terminal.Id] = terminal
+	}
+	groupById := map[int64]Group{}
+	for _, group := range groups {
+		groupById[group.Id] = group
+	}
+	var first, last int64
+	for i, row := range rows {
+		bucket := row.Id.Bucket
+		if i == 0 || bucket < first {
+			first = bucket
+		}
+		if bucket > last {
+			last = bucket
+		}
+		total.add(bucket, row.Id.Direction, row.Count)
+		terminal := terminalById[row.Id.TerminalId]
+		if _, ok := byTerminal[row.Id.TerminalId]; !ok {
+			byTerminal[row.Id.TerminalId] = newTimelineCounter(row.Id.TerminalId, terminal.Name)
+		}
+		byTerminal[row.Id.TerminalId].add(bucket, row.Id.Direction, row.Count)
+		for _, groupId := range terminal.Groups {
+			if _, ok := byGroup[groupId]; !ok {
+				byGroup[groupId] = newTimelineCounter(groupId, groupById[groupId].Name)
+			}
+			byGroup[groupId].add(bucket, row.Id.Direction, row.Count)
+		}
+	}
+	r.Total = total.fill(first, last, r.Interval, loc)
+	r.Terminals = []TimelineSeries{}
+	for _, counter := range byTerminal {
+		r.Terminals = append(r.Terminals, counter.fill(first, last, r.Interval, loc))
+	}
+	sort.Slice(r.Terminals, func(i, j int) bool { return r.Terminals[i].Id < r.Terminals[j].Id })
+	r.Groups = []TimelineSeries{}
+	for _, counter := range byGroup {
+		r.Groups = append(r.Groups, counter.fill(first, last, r.Interval, loc))
+	}
+	sort.Slice(r.Groups, func(i, j int) bool { return r.Groups[i].Id < r.Groups[j].Id })
+}
+
+// Median of first entry times counted from doors open, negative if came before
+func (r *EventTimeline) fromFirstEntries(firstEntries []int64) {
+	r.FirstEntries = int64(len(firstEntries))
+	if len(firstEntries) == 0 {
+		return
+	}
+	sort.Slice(firstEntries, func(i, j int) bool { return firstEntries[i] < firstEntries[j] })
+	middle := len(firstEntries) / 2
+	median := firstEntries[middle]
+	if len(firstEntries)%2 == 0 {
+		median = (firstEntries[middle-1] + firstEntries[middle]) / 2
+	}
+	r.MedianFromDoorsOpen = median - r.DoorsOpen
+}
+
+func comparisonKey(event Event, by string) (string, string) {
+	switch by {
+	case COMPARE_BY_HALL:
+		return strconv.FormatInt(event.VenueId, 10) + ":" + strconv.FormatInt(event.HallId, 10), event.VenueTitle + " / " + event.Hall
+	case COMPARE_BY_SHOW:
+		//touring show has own show id in each building, title is the same
+		return strings.ToLower(strings.TrimSpace(event.Title)), event.Title
+	}
+	return strconv.FormatInt(event.VenueId, 10), event.VenueTitle
+}
+
+func rate(part int64, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part*10000/whole) / 10000
+}
+
+// Lines with best sell-through first
+func compareEvents(events []Event, rollups map[int64]EventRollup, by string) []ComparisonLine {
+	lines := map[string]*ComparisonLine{}
+	keys := []string{}
+	for _, event := range events {
+		key, title := comparisonKey(event, by)
+		line, ok := lines[key]
+		if !ok {
+			line = &ComparisonLine{Key: key, Title: title}
+			lines[key] = line
+			keys = append(keys, key)
+		}
+		rollup := rollups[event.Id]
+		line.Events++
+		line.Sell += rollup.Sell
+		line.Entry += rollup.Entry
+		line.Total += rollup.Total
+		if event.Vacancies != nil {
+			line.Capacity += rollup.Sell + *event.Vacancies
+			line.capacitySell += rollup.Sell
+		}
+	}
+	result := []ComparisonLine{}
+	for _, key := range keys {
+		line := lines[key]
+		line.SellThrough = rate(line.capacitySell, line.Capacity)
+		line.Attendance = rate(line.Entry, line.Sell)
+		result = append(result, *line)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].SellThrough != result[j].SellThrough {
+			return result[i].SellThrough > result[j].SellThrough
+		}
+		if result[i].Attendance != result[j].Attendance {
+			return result[i].Attendance > result[j].Attendance
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
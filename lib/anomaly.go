@@ -0,0 +1,243 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const ANOMALY_DUPLICATE_SCAN = "duplicate_scan"
+const ANOMALY_NOTFOUND_BURST = "notfound_burst"
+const ANOMALY_SHARED_PLACE = "shared_place"
+
+// Same barcode at another gate within this many seconds
+const ANOMALY_DUPLICATE_WINDOW = 30
+
+// That many not found scans on one terminal within window seconds
+const ANOMALY_BURST_SCANS = 5
+const ANOMALY_BURST_WINDOW = 60
+
+// Code of anomaly records in logs
+const ANOMALY_LOG_CODE = 1000
+
+// Url to POST real time alerts to as json, alerts go only to logs and streams if not set
+const ANOMALY_ALERT_URL_ENV = "ANOMALY_ALERT_URL"
+
+var gateInLog = regexp.MustCompile(`^Result for \w+ from gate #(\d+)`)
+var gateInMessage = regexp.MustCompile(`gate #(\d+)`)
+
+type Anomaly struct {
+	Type        string   `json:"type"`
+	EventId     int64    `json:"event_id,omitempty"`
+	Dt          int64    `json:"dt"`
+	DtISO       string   `json:"dt_iso,omitempty"`
+	TerminalIds []int64  `json:"terminal_ids,omitempty"`
+	Barcodes    []string `json:"barcodes,omitempty"`
+	PlaceId     int      `json:"place_id,omitempty"`
+	Count       int      `json:"count"`
+	Message     string   `json:"message"`
+}
+type EventAnomalies struct {
+	EventId   int64          `json:"event_id"`
+	Counts    map[string]int `json:"counts"`
+	Anomalies []Anomaly      `json:"anomalies"`
+}
+
+// Not found scan parsed from logs
+type NotFoundScan struct {
+	TerminalId int64
+	Dt         int64
+	Barcode    string
+}
+
+func notFoundFromLogs(logs []Log, terminals map[int64]bool) []NotFoundScan {
+	scans := []NotFoundScan{}
+	for _, record := range logs {
+		match := gateInLog.FindStringSubmatch(record.Message)
+		if match == nil {
+			continue
+		}
+		terminalId, _ := strconv.ParseInt(match[1], 10, 64)
+		if terminals[terminalId] {
+			scans = append(scans, NotFoundScan{terminalId, record.Dt, record.Data})
+		}
+	}
+	return scans
+}
+
+// Entries must be sorted by barcode and time
+func DuplicateScans(entries []Entry) []Anomaly {
+	anomalies := []Anomaly{}
+	for i := 1; i < len(entries); i++ {
+		prev, entry := entries[i-1], entries[i]
+		if prev.TicketBarcode != entry.TicketBarcode || prev.TerminalId == entry.TerminalId {
+			continue
+		}
+		if entry.OperationDt-prev.OperationDt <= ANOMALY_DUPLICATE_WINDOW {
+			anomalies = append(anomalies, duplicateScan(prev, entry))
+		}
+	}
+	return anomalies
+}
+
+func duplicateScan(prev Entry, entry Entry) Anomaly {
+	return Anomaly{
+		Type:        ANOMALY_DUPLICATE_SCAN,
+		EventId:     entry.EventId,
+		Dt:          entry.OperationDt,
+		TerminalIds: []int64{prev.TerminalId, entry.TerminalId},
+		Barcodes:    []string{entry.TicketBarcode},
+		Count:       2,
+		Message: fmt.Sprintf("Barcode %s scanned at gates #%d and #%d within %d seconds",
+			entry.TicketBarcode, prev.TerminalId, entry.TerminalId, entry.OperationDt-prev.OperationDt),
+	}
+}
+
+// One anomaly per burst, a burst lasts while window holds enough scans
+func NotFoundBursts(scans []NotFoundScan) []Anomaly {
+	sort.Slice(scans, func(i, j int) bool {
+		if scans[i].TerminalId != scans[j].TerminalId {
+			return scans[i].TerminalId < scans[j].TerminalId
+		}
+		return scans[i].Dt < scans[j].Dt
+	})
+	anomalies := []Anomaly{}
+	burstStart := -1
+	closeBurst := func(last int) {
+		burst := scans[burstStart : last+1]
+		barcodes := []string{}
+		for _, scan := range burst {
+			barcodes = append(barcodes, scan.Barcode)
+		}
+		anomalies = append(anomalies, Anomaly{
+			Type:        ANOMALY_NOTFOUND_BURST,
+			Dt:          burst[0].Dt,
+			TerminalIds: []int64{burst[0].TerminalId},
+			Barcodes:    barcodes,
+			Count:       len(burst),
+			Message: fmt.Sprintf("%d not found scans at gate #%d in %d seconds",
+				len(burst), burst[0].TerminalId, burst[len(burst)-1].Dt-burst[0].Dt),
+		})
+		burstStart = -1
+	}
+	start := 0
+	for i := range scans {
+		if i > 0 && scans[i].TerminalId != scans[i-1].TerminalId {
+			if burstStart >= 0 {
+				closeBurst(i - 1)
+			}
+			start = i
+		}
+		for scans[i].Dt-scans[start].Dt > ANOMALY_BURST_WINDOW {
+			start++
+		}
+		if i-start+1 >= ANOMALY_BURST_SCANS {
+			if burstStart < 0 {
+				burstStart = start
+			}
+		} else if burstStart >= 0 {
+			closeBurst(i - 1)
+		}
+	}
+	if burstStart >= 0 {
+		closeBurst(len(scans) - 1)
+	}
+	return anomalies
+}
+
+func SharedPlaces(tickets []TicketExport) []Anomaly {
+	barcodes := map[int][]string{}
+	places := []int{}
+	for _, ticket := range tickets {
+		if ticket.PlaceID == 0 {
+			continue
+		}
+		if _, ok := barcodes[ticket.PlaceID]; !ok {
+			places = append(places, ticket.PlaceID)
+		}
+		barcodes[ticket.PlaceID] = append(barcodes[ticket.PlaceID], ticket.TicketBarcode)
+	}
+	sort.Ints(places)
+	anomalies := []Anomaly{}
+	for _, place := range places {
+		if len(barcodes[place]) < 2 {
+			continue
+		}
+		anomalies = append(anomalies, Anomaly{
+			Type:     ANOMALY_SHARED_PLACE,
+			PlaceId:  place,
+			Barcodes: barcodes[place],
+			Count:    len(barcodes[place]),
+			Message:  fmt.Sprintf("Place %d has %d valid barcodes", place, len(barcodes[place])),
+		})
+	}
+	return anomalies
+}
+
+func (r *EventAnomalies) add(anomalies []Anomaly, loc *time.Location) {
+	for _, anomaly := range anomalies {
+		anomaly.EventId = r.EventId
+		if anomaly.Dt != 0 {
+			anomaly.DtISO = IsoTime(anomaly.Dt, loc)
+		}
+		r.Anomalies = append(r.Anomalies, anomaly)
+		r.Counts[anomaly.Type]++
+	}
+}
+
+// Counts not found scans per terminal to alert on burst while it happens
+type AnomalyDetector struct {
+	mutex    sync.Mutex
+	notFound map[int64][]int64
+}
+
+var anomalyDetector = NewAnomalyDetector()
+
+func NewAnomalyDetector() *AnomalyDetector {
+	return &AnomalyDetector{notFound: map[int64][]int64{}}
+}
+
+// Returns true once per burst, when window gets enough scans
+func (d *AnomalyDetector) NotFound(terminalId int64, dt int64) (int, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	scans := d.notFound[terminalId]
+	for len(scans) > 0 && dt-scans[0] > ANOMALY_BURST_WINDOW {
+		scans = scans[1:]
+	}
+	scans = append(scans, dt)
+	d.notFound[terminalId] = scans
+	return len(scans), len(scans) == ANOMALY_BURST_SCANS
+}
+
+// Log anomaly, push it to dashboards of events and to alert url
+func raiseAnomaly(r *Repository, log *slog.Logger, anomaly Anomaly, events []Event) {
+	log.Warn("Scan anomaly", "type", anomaly.Type, "terminal_ids", anomaly.TerminalIds, "barcodes", anomaly.Barcodes,
+		"count", anomaly.Count, "message", anomaly.Message)
+	r.Log(Log{0, anomaly.Type, anomaly.Message, ANOMALY_LOG_CODE})
+	for _, event := range events {
+		streamHub.PublishAnomaly(anomaly, event)
+	}
+	url := os.Getenv(ANOMALY_ALERT_URL_ENV)
+	if url == "" {
+		return
+	}
+	go func() {
+		data, _ := json.Marshal(anomaly)
+		client := http.Client{Timeout: 10 * time.Second}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+		if err != nil {
+			log.Error("Can`t send anomaly alert", "url", url, "error", err)
+			return
+		}
+		resp.Body.Close()
+	}()
+}
@@ -7,10 +7,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
+	"time"
 )
 
 const xmlPattern_acs_export_event = `<?xml version="1.0" encoding="utf-8"?>
@@ -31,11 +33,20 @@ const xmlPattern_page_event_list = `<?xml version="1.0" encoding="utf-8"?>
 const ENTRY_RESULT_CODE_ACCEPT = 1
 const ENTRY_RESULT_CODE_REENTRY = -1
 const ENTRY_RESULT_CODE_NOTFOUND = 0
+const ENTRY_RESULT_CODE_CANCELLED = -2
+const ENTRY_RESULT_CODE_POSTPONED = -3
+
+// Kassy event states
+const KASSY_STATE_DELETED = "0"
+const KASSY_EVENT_STATE_CANCELLED = "2"
+const KASSY_EVENT_STATE_POSTPONED = "3"
 
 type Api struct {
 	Url       string
 	Db        string
 	SecretKey string
+	requestId string
+	log       *slog.Logger
 }
 type Building struct {
 	ID      string `json:"id"`
@@ -217,6 +228,26 @@ func (pg *PageEventList) HallTitleById(hallid string) string {
 	return ""
 }
 
+func kassyEventStatus(state string, eventState string) string {
+	if state == KASSY_STATE_DELETED || eventState == KASSY_EVENT_STATE_CANCELLED {
+		return EVENT_STATUS_CANCELLED
+	}
+	if eventState == KASSY_EVENT_STATE_POSTPONED {
+		return EVENT_STATUS_POSTPONED
+	}
+	return EVENT_STATUS_ACTIVE
+}
+
+// Timezone of subdivision the list was asked from
+func (pg *PageEventList) Timezone() string {
+	for i := range pg.Content.Subdivision {
+		if pg.Content.Subdivision[i].Tz != "" {
+			return pg.Content.Subdivision[i].Tz
+		}
+	}
+	return ""
+}
+
 func (pg *PageEventList) ToEvents() Events {
 
 	events := Events{}
@@ -229,6 +260,12 @@ func (pg *PageEventList) ToEvents() Events {
 		event.VenueTitle = pg.Content.Building[0].Title
 		event.Hall = pg.HallTitleById(pg.Content.Event[i].HallID)
 		event.HallId, _ = strconv.ParseInt(pg.Content.Event[i].HallID, 10, 32)
+		event.ShowId, _ = strconv.ParseInt(pg.Content.Event[i].ShowID, 10, 32)
+		event.Status = kassyEventStatus(pg.Content.Event[i].State, pg.Content.Event[i].EventState)
+		event.Timezone = pg.Timezone()
+		if vacancies, err := strconv.ParseInt(pg.Content.Event[i].Vacancies, 10, 64); err == nil {
+			event.Vacancies = &vacancies
+		}
 		events.Events = append(events.Events, event)
 	}
 	return events
@@ -260,62 +297,64 @@ func (api *Api) Source() string {
 func (api *Api) Sync() {
 
 }
-func (api *Api) GetEventACS(eventid int64) ACSExportEvent {
-	xml := fmt.Sprintf(xmlPattern_acs_export_event, api.Db, eventid)
+
+// Signed request to api, request id goes in header to match api logs
+// Raw api answer, error if api can`t be reached
+func (api *Api) post(module string, xml string) ([]byte, error) {
+	start := time.Now()
+	log := api.logger().With("api_module", module)
 	form := url.Values{
 		"xml":  {xml},
 		"sign": {GetMD5Hash(xml + api.SecretKey)},
 	}
-	body := bytes.NewBufferString(form.Encode())
-	rsp, err := http.Post(api.Url, "application/x-www-form-urlencoded", body)
+	req, err := http.NewRequest("POST", api.Url, bytes.NewBufferString(form.Encode()))
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if api.requestId != "" {
+		req.Header.Set(REQUEST_ID_HEADER, api.requestId)
+	}
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Error("Api request failed", "error", err)
+		return nil, err
 	}
 	defer rsp.Body.Close()
 	body_byte, err := ioutil.ReadAll(rsp.Body)
 	if err != nil {
-		panic(err)
+		log.Error("Api response read failed", "status", rsp.StatusCode, "error", err)
+		return nil, err
 	}
+	log.Debug("Api request", "status", rsp.StatusCode, "bytes", len(body_byte), "duration", time.Since(start).Seconds())
+	return body_byte, nil
+}
+func (api *Api) GetEventACS(eventid int64) (ACSExportEvent, error) {
+	xml := fmt.Sprintf(xmlPattern_acs_export_event, api.Db, eventid)
 	var acsExportEvent ACSExportEvent
+	body_byte, err := api.post("acs_export_event", xml)
+	if err != nil {
+		return acsExportEvent, err
+	}
 	json.Unmarshal(body_byte, &acsExportEvent)
-	return acsExportEvent
+	return acsExportEvent, nil
 }
-func (api *Api) PageEventList(buildingId int64, dtFrom int64, dtTo int64) PageEventList {
+func (api *Api) PageEventList(buildingId int64, dtFrom int64, dtTo int64) (PageEventList, error) {
 	xml := fmt.Sprintf(xmlPattern_page_event_list, api.Db, buildingId, dtFrom, dtTo)
-	form := url.Values{
-		"xml":  {xml},
-		"sign": {GetMD5Hash(xml + api.SecretKey)},
-	}
-	body := bytes.NewBufferString(form.Encode())
-	rsp, err := http.Post(api.Url, "application/x-www-form-urlencoded", body)
-	if err != nil {
-		panic(err)
-	}
-	defer rsp.Body.Close()
-	body_byte, err := ioutil.ReadAll(rsp.Body)
+	var page PageEventList
+	body_byte, err := api.post("page_event_list", xml)
 	if err != nil {
-		panic(err)
+		return page, err
 	}
-	var page PageEventList
 	json.Unmarshal(body_byte, &page)
-	return page
+	return page, nil
 }
-func (api *Api) GetBuildings() []Building {
-	form := url.Values{
-		"xml":  {xmlPattern_table_buildings},
-		"sign": {GetMD5Hash(xmlPattern_table_buildings + api.SecretKey)},
-	}
-	body := bytes.NewBufferString(form.Encode())
-	rsp, err := http.Post(api.Url, "application/x-www-form-urlencoded", body)
-	if err != nil {
-		panic(err)
-	}
-	defer rsp.Body.Close()
-	body_byte, err := ioutil.ReadAll(rsp.Body)
+func (api *Api) GetBuildings() ([]Building, error) {
+	var tableBuildings TableBuildings
+	body_byte, err := api.post("table_building", xmlPattern_table_buildings)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	var tableBuildings TableBuildings
 	json.Unmarshal(body_byte, &tableBuildings)
-	return tableBuildings.Content
+	return tableBuildings.Content, nil
 }
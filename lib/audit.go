@@ -0,0 +1,215 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/context"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const AUDIT_DEFAULT_LIMIT = 100
+const AUDIT_MAX_LIMIT = 1000
+
+// Actions are named as routes
+const AUDIT_ADD_USER = "AddUser"
+const AUDIT_SET_USER = "SetUser"
+const AUDIT_REMOVE_USER = "RemoveUser"
+const AUDIT_SET_USER_PASSWORD = "SetUserPassword"
+const AUDIT_CHANGE_PASSWORD = "ChangePassword"
+const AUDIT_REVOKE_SESSION = "RevokeSession"
+const AUDIT_REVOKE_SESSIONS = "RevokeSessions"
+const AUDIT_ADD_TERMINAL = "AddTerminal"
+const AUDIT_SET_TERMINAL = "TerminalSet"
+const AUDIT_ADD_GROUP = "AddGroup"
+const AUDIT_SET_GROUP = "SetGroup"
+const AUDIT_REMOVE_GROUP = "RemoveGroup"
+const AUDIT_ADD_MASTERKEY = "AddMasterKey"
+const AUDIT_SQL = "SQL"
+const AUDIT_ADD_REPORT = "AddReport"
+const AUDIT_SET_REPORT = "SetReport"
+const AUDIT_REMOVE_REPORT = "RemoveReport"
+const AUDIT_SET_EVENT_STATUS = "SetEventStatus"
+const AUDIT_IMPORT_TICKETS = "ImportTickets"
+
+var passwordInConString = regexp.MustCompile(`(?i)(password=)[^\s;&]*`)
+var passwordInUrl = regexp.MustCompile(`(://[^:/@]*:)[^/]*@`)
+
+var errNoToken = errors.New("Not Authorized")
+
+// Token from cookie or bearer header
+func parseRequestToken(req *http.Request) (*jwt.Token, error) {
+	var clientToken string
+	JWTCookie, cookieErr := req.Cookie("token")
+	if cookieErr != nil {
+		bearerToken := strings.Split(req.Header.Get("authorization"), " ")
+		if len(bearerToken) != 2 {
+			return nil, errNoToken
+		}
+		clientToken = bearerToken[1]
+	} else {
+		clientToken = JWTCookie.Value
+	}
+	return jwt.Parse(clientToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("There was an error")
+		}
+		return []byte(GetSecretKey()), nil
+	})
+}
+
+// Username from checked token, from request token for routes without auth middleware
+func requestUser(req *http.Request) string {
+	claims, ok := context.Get(req, "decoded").(jwt.MapClaims)
+	if !ok {
+		token, err := parseRequestToken(req)
+		if err != nil || !token.Valid {
+			return ""
+		}
+		claims, _ = token.Claims.(jwt.MapClaims)
+	}
+	username, _ := claims["username"].(string)
+	return username
+}
+
+// Proxies whose X-Forwarded-For and X-Real-IP are believed, comma separated ips or cidrs
+const TRUSTED_PROXIES_ENV = "TRUSTED_PROXIES"
+
+var trustedProxies = parseNetworks(os.Getenv(TRUSTED_PROXIES_ENV))
+
+func parseNetworks(list string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			if ip := net.ParseIP(item); ip != nil && ip.To4() != nil {
+				item += "/32"
+			} else {
+				item += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			logger.Warn("Bad trusted proxy", "proxy", item, "error", err)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+func isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	for _, network := range trustedProxies {
+		if ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remote address, forwarded headers only when it is a trusted proxy
+func clientIp(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	if !isTrustedProxy(host) {
+		return host
+	}
+	//last address not added by own proxies, earlier ones are set by client
+	if forwarded := req.Header.Get("X-Forwarded-For"); forwarded != "" {
+		chain := strings.Split(forwarded, ",")
+		for i := len(chain) - 1; i >= 0; i-- {
+			if ip := strings.TrimSpace(chain[i]); !isTrustedProxy(ip) || i == 0 {
+				return ip
+			}
+		}
+	}
+	if realIp := req.Header.Get("X-Real-IP"); realIp != "" {
+		return realIp
+	}
+	return host
+}
+
+func maskConString(conString string) string {
+	conString = passwordInConString.ReplaceAllString(conString, "${1}***")
+	return passwordInUrl.ReplaceAllString(conString, "${1}***@")
+}
+
+// Master key opens every gate, only its end is kept
+func maskBarcode(barcode string) string {
+	runes := []rune(barcode)
+	if len(runes) <= 4 {
+		return "***"
+	}
+	return "***" + string(runes[len(runes)-4:])
+}
+
+// Terminal without secret, nil if there is no such terminal
+func auditTerminal(terminal Terminal) interface{} {
+	if terminal.Id == 0 {
+		return nil
+	}
+	terminal.Secret = ""
+	return terminal
+}
+
+// User without password
+func auditUser(user User) interface{} {
+	if user.Login == "" {
+		return nil
+	}
+	return map[string]interface{}{"login": user.Login, "role": user.Role, "active": user.Active}
+}
+func auditGroup(group *Group) interface{} {
+	if group == nil {
+		return nil
+	}
+	return *group
+}
+
+// Report schedule, nil if there is no such schedule
+func auditReport(schedule ReportSchedule) interface{} {
+	if schedule.Id == 0 {
+		return nil
+	}
+	return schedule
+}
+
+// Status fields of event, nil if there is no such event
+func auditEventStatus(event Event) interface{} {
+	if event.Id == 0 {
+		return nil
+	}
+	return map[string]interface{}{"status": event.Status, "status_manual": event.StatusManual, "dt": event.EventDT, "original_dt": event.OriginalDT}
+}
+
+func audit(req *http.Request, action string, target string, before interface{}, after interface{}, ex *Exception) {
+	record := AuditRecord{Username: requestUser(req), Ip: clientIp(req), Action: action, Target: target, Before: before, After: after,
+		RequestId: requestId(req)}
+	if ex != nil {
+		record.Error = strings.TrimSpace(ex.Message + " " + ex.Error)
+	}
+	repository.For(req).AddAudit(record)
+}
+
+func terminalTarget(id int64) string {
+	return "terminal:" + strconv.FormatInt(id, 10)
+}
+
+func reportTarget(id int64) string {
+	return "report:" + strconv.FormatInt(id, 10)
+}
+
+func eventTarget(id int64) string {
+	return "event:" + strconv.FormatInt(id, 10)
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -10,7 +11,6 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/gorilla/schema"
 	"github.com/skip2/go-qrcode"
-	"log"
 	"net/http"
 	"os"
 	"regexp"
@@ -20,6 +20,12 @@ import (
 )
 
 const MAINTANCERUN = 30
+const STATS_DEFAULT_LIMIT = 100
+const STATS_MAX_LIMIT = 5000
+const LOGS_DEFAULT_LIMIT = 100
+const LOGS_MAX_LIMIT = 1000
+const ENTRIES_DEFAULT_LIMIT = 100
+const ENTRIES_MAX_LIMIT = 1000
 
 func Bod(t time.Time) time.Time {
 	year, month, day := t.Date()
@@ -34,7 +40,7 @@ func writeImagePng(w http.ResponseWriter, buffer []byte) {
 	w.Header().Set("Content-Type", "image/png")
 	w.Header().Set("Content-Length", strconv.Itoa(len(buffer)))
 	if _, err := w.Write(buffer); err != nil {
-		log.Println("Unable to write image.")
+		logger.Error("Unable to write image", "error", err)
 	}
 }
 func getHostnameFromUrl(url string) string {
@@ -55,7 +61,8 @@ var repository = Repository{}
 var decoder = schema.NewDecoder()
 var api = NewApi()
 
-func init() {
+// Connect to database and start maintenance loop
+func Init() {
 	repository.Connect()
 
 	ticker := time.NewTicker(MAINTANCERUN * time.Second)
@@ -71,13 +78,25 @@ func init() {
 			}
 		}
 	}()
+	go streamHub.Run(STREAM_STATS_INTERVAL)
+	//slow jobs on own tickers, so they do not hold event sync
+	go runEvery(MAINTANCERUN*time.Second, "reports", repository.RunDueReports)
+	go runEvery(MAINTANCERUN*time.Second, "retention", repository.RunRetention)
 
 }
+
+// Job ticks are skipped while previous run is not finished
+func runEvery(interval time.Duration, job string, run func() *Exception) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		maintenanceJob(job, run)
+	}
+}
 func GetSecretKey() string {
 	key := os.Getenv("SECRET_KEY")
 	if key == "" {
 		key = "secretKEYmustBEset"
-		log.Println("Please set ENV: SECRET_KEY. API not secured!!!")
+		logger.Warn("Please set ENV: SECRET_KEY. API not secured!!!")
 	}
 	return key
 }
@@ -103,6 +122,7 @@ func (с *Controller) SqlHandler(w http.ResponseWriter, r *http.Request) {
 		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
 		return
 	}
+	audit(r, AUDIT_SQL, "sql", nil, map[string]string{"constring": maskConString(sqlQuery.ConString), "query": sqlQuery.Query}, nil)
 	respondWithJson(w, http.StatusOK, RunMe(sqlQuery.ConString, sqlQuery.Query))
 }
 func (c *Controller) LoginHandler(w http.ResponseWriter, r *http.Request) {
@@ -126,40 +146,133 @@ func (c *Controller) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	//Login information correct
 	if isValid == true {
 		//GEN token
-		expires := time.Now().Add(time.Second * 60 * 60 * 24)
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-			"username": userLogin.Login,
-			"admin":    false,
-			"exp":      expires.Unix(),
-		})
-		tokenString, errorToken := token.SignedString([]byte(GetSecretKey()))
-		if errorToken != nil {
-			fmt.Println(errorToken)
-		}
-		cookie := http.Cookie{Name: "token", Value: tokenString, HttpOnly: true, Expires: expires}
-		http.SetCookie(w, &cookie)
-		json.NewEncoder(w).Encode(JwtToken{Token: tokenString, Expires: expires.Unix()})
-
+		startSession(w, r, repository.GetUser(userLogin.Login))
 		return
 	}
 	//UnAuthorized
 	respondWithJson(w, http.StatusUnauthorized, Exception{UNAUTHORIZED, ""})
 }
+
+// Request id for every request and a log line when it is served
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Do stuff here
-		log.Println(r.RequestURI)
-		// Call the next handler, which can be another middleware in the chain, or the final handler.
-		next.ServeHTTP(w, r)
+		r = withRequestId(r)
+		w.Header().Set(REQUEST_ID_HEADER, requestId(r))
+		start := time.Now()
+		writer := &statusWriter{w, http.StatusOK}
+		next.ServeHTTP(writer, r)
+		route := ""
+		if current := mux.CurrentRoute(r); current != nil {
+			route = current.GetName()
+		}
+		requestLogger(r).Info("Request", "route", route, "method", r.Method, "path", r.URL.Path, "status", writer.status,
+			"duration", time.Since(start).Seconds(), "ip", clientIp(r))
 	})
 }
 func (c *Controller) LogoutHandler(w http.ResponseWriter, r *http.Request) {
-
-	cookie := http.Cookie{Name: "token", HttpOnly: true, Expires: time.Now()}
-	http.SetCookie(w, &cookie)
+	//Token copied before logout must not work after it
+	if ex := repository.RevokeSession(requestSession(r).Id); ex != nil {
+		respondWithJson(w, http.StatusInternalServerError, ex)
+		return
+	}
+	clearTokenCookies(w, r)
 	json.NewEncoder(w).Encode(Response{OK_RESPONSE, OK_CODE_RESPONSE})
 	return
 }
+
+// New access token for refresh token, refresh token is rotated
+func (c *Controller) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	refreshToken := requestRefreshToken(r)
+	if refreshToken == "" {
+		respondWithJson(w, http.StatusUnauthorized, Exception{REFRESH_TOKEN_EXEPTION, ""})
+		return
+	}
+	session, newToken, ex := repository.For(r).RefreshSession(refreshToken)
+	if ex != nil {
+		clearTokenCookies(w, r)
+		respondWithJson(w, http.StatusUnauthorized, ex)
+		return
+	}
+	user := repository.GetUser(session.Login)
+	if user.Login == "" || !user.Active {
+		repository.RevokeSession(session.Id)
+		clearTokenCookies(w, r)
+		respondWithJson(w, http.StatusUnauthorized, Exception{REFRESH_TOKEN_EXEPTION, "user is not active"})
+		return
+	}
+	respondWithToken(w, r, user, session, newToken)
+}
+
+// Own sessions, sessions of other user with users:read
+func (c *Controller) SessionsHandler(w http.ResponseWriter, r *http.Request) {
+	current := requestSession(r)
+	login := r.URL.Query().Get("login")
+	if login == "" {
+		login = current.Login
+	}
+	if login != current.Login && !RoleAllows(requestRole(r), PERMISSION_USERS_READ) {
+		respondWithJson(w, http.StatusForbidden, Exception{FORBIDDEN_EXEPTION, PERMISSION_USERS_READ})
+		return
+	}
+	sessions := repository.Sessions(login)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id == current.Id
+	}
+	respondWithJson(w, http.StatusOK, sessions)
+}
+
+// Own session, session of other user with users:write
+func (c *Controller) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	session := repository.GetSession(r.PostForm.Get("id"))
+	if session.Id == "" {
+		respondWithJson(w, http.StatusNotFound, Exception{SESSION_NOT_FOUND_EXEPTION, r.PostForm.Get("id")})
+		return
+	}
+	if session.Login != requestSession(r).Login && !RoleAllows(requestRole(r), PERMISSION_USERS_WRITE) {
+		respondWithJson(w, http.StatusForbidden, Exception{FORBIDDEN_EXEPTION, PERMISSION_USERS_WRITE})
+		return
+	}
+	ex := repository.RevokeSession(session.Id)
+	audit(r, AUDIT_REVOKE_SESSION, "user:"+session.Login, map[string]string{"session": session.Id}, nil, ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusInternalServerError, ex)
+		return
+	}
+	respondWithJson(w, http.StatusOK, Response{OK_RESPONSE, OK_CODE_RESPONSE})
+}
+
+// All sessions of own or other user, current session too
+func (c *Controller) RevokeSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	current := requestSession(r)
+	login := r.PostForm.Get("login")
+	if login == "" {
+		login = current.Login
+	}
+	if login != current.Login && !RoleAllows(requestRole(r), PERMISSION_USERS_WRITE) {
+		respondWithJson(w, http.StatusForbidden, Exception{FORBIDDEN_EXEPTION, PERMISSION_USERS_WRITE})
+		return
+	}
+	revoked, ex := repository.RevokeSessions(login)
+	audit(r, AUDIT_REVOKE_SESSIONS, "user:"+login, nil, map[string]int{"revoked": revoked}, ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusInternalServerError, ex)
+		return
+	}
+	if login == current.Login {
+		clearTokenCookies(w, r)
+	}
+	respondWithJson(w, http.StatusOK, Response{OK_RESPONSE, OK_CODE_RESPONSE})
+}
 func (c *Controller) StatsHandler(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
@@ -172,23 +285,84 @@ func (c *Controller) StatsHandler(w http.ResponseWriter, r *http.Request) {
 		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
 		return
 	}
-	from, _ := time.Parse("2006-01-02", timeRange.From)
-	to, _ := time.Parse("2006-01-02", timeRange.To)
+	//Dates are days in venue timezone
+	timezone := timeRange.Timezone
+	if timezone == "" && timeRange.GroupId != 0 {
+		timezone = repository.GetGroupById(timeRange.GroupId).Timezone
+	}
+	loc := LoadLocation(timezone)
+	from, _ := time.ParseInLocation("2006-01-02", timeRange.From, loc)
+	to, _ := time.ParseInLocation("2006-01-02", timeRange.To, loc)
 
-	eventInfo := repository.GetEventsByDt(Bod(from).Unix(), Eod(to).Unix())
+	if timeRange.Page <= 0 {
+		timeRange.Page = 1
+	}
 	var events []EventStats
-	var ii int64
-	ii = 1
-	for _, event := range eventInfo.Events {
-		info := repository.GetEventInfo(event.Id)
-		events = append(events, EventStats{ii, event.Id, event.EventDT, event.Title, info.Tickets(), info.Entrys(), info.Total(), info.Info})
-		ii = ii + 1
+	var count int
+	var ex *Exception
+	//Files are not paged unless asked to
+	if timeRange.Limit <= 0 && exportFormat(r) != EXPORT_JSON {
+		events, ex = repository.AllStats(Bod(from).Unix(), Eod(to).Unix(), timeRange.GroupId)
+		count, timeRange.Page, timeRange.Limit = len(events), 1, len(events)
+	} else {
+		if timeRange.Limit <= 0 {
+			timeRange.Limit = STATS_DEFAULT_LIMIT
+		}
+		if timeRange.Limit > STATS_MAX_LIMIT {
+			timeRange.Limit = STATS_MAX_LIMIT
+		}
+		events, count, ex = repository.GetStats(Bod(from).Unix(), Eod(to).Unix(), timeRange.GroupId, (timeRange.Page-1)*timeRange.Limit, timeRange.Limit)
 	}
-	respondWithJson(w, OK_CODE_RESPONSE, events)
+	if ex != nil {
+		respondWithJson(w, http.StatusInternalServerError, ex)
+		return
+	}
+	for i := range events {
+		if timezone != "" {
+			events[i].DtISO = IsoTime(events[i].Dt, loc)
+		}
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(count))
+	w.Header().Set("X-Page", strconv.Itoa(timeRange.Page))
+	w.Header().Set("X-Per-Page", strconv.Itoa(timeRange.Limit))
+	respondWithExport(w, r, "stats_"+timeRange.From+"_"+timeRange.To, events, func() Table {
+		return statsTable("Stats "+timeRange.From+" - "+timeRange.To, events)
+	})
 }
 func (c *Controller) Terminals(w http.ResponseWriter, r *http.Request) {
 	respondWithJson(w, http.StatusOK, repository.Terminals())
 }
+func (c *Controller) CompareHandler(w http.ResponseWriter, r *http.Request) {
+	var form CompareForm
+	errDecode := decoder.Decode(&form, r.URL.Query())
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
+		return
+	}
+	if form.By == "" {
+		form.By = COMPARE_BY_BUILDING
+	}
+	timezone := form.Timezone
+	if timezone == "" && form.GroupId != 0 {
+		timezone = repository.GetGroupById(form.GroupId).Timezone
+	}
+	loc := LoadLocation(timezone)
+	from, errFrom := time.ParseInLocation("2006-01-02", form.From, loc)
+	to, errTo := time.ParseInLocation("2006-01-02", form.To, loc)
+	if errFrom != nil || errTo != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, "from and to must be YYYY-MM-DD"})
+		return
+	}
+	lines, ex := repository.GetComparison(Bod(from).Unix(), Eod(to).Unix(), form.GroupId, form.By)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	comparison := Comparison{form.By, form.From, form.To, lines}
+	respondWithExport(w, r, "compare_"+form.By+"_"+form.From+"_"+form.To, comparison, func() Table {
+		return comparisonTable("Comparison by "+form.By+" "+form.From+" - "+form.To, comparison)
+	})
+}
 func (c *Controller) CheckTicketHandler(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
@@ -207,9 +381,39 @@ func (c *Controller) CheckTicketHandler(w http.ResponseWriter, r *http.Request)
 }
 
 func (c *Controller) InitInstance(w http.ResponseWriter, r *http.Request) {
-	user := User{"demo", "demo", true}
-	repository.AddUser(user)
-	respondWithJson(w, http.StatusOK, nil)
+	token := os.Getenv(INIT_TOKEN_ENV)
+	if token == "" {
+		respondWithJson(w, http.StatusForbidden, Exception{FORBIDDEN_EXEPTION, INIT_TOKEN_ENV + " is not set"})
+		return
+	}
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	var form InitForm
+	errDecode := decoder.Decode(&form, r.PostForm)
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(form.Token), []byte(token)) != 1 {
+		respondWithJson(w, http.StatusUnauthorized, Exception{UNAUTHORIZED, ""})
+		return
+	}
+	//Only first user, as admin
+	if repository.UsersCount() > 0 {
+		respondWithJson(w, http.StatusForbidden, Exception{USER_EXIST_EXEPTION, ""})
+		return
+	}
+	user := User{form.Login, form.Password, true, ROLE_ADMIN, 0}
+	ex := repository.AddUser(user)
+	audit(r, AUDIT_ADD_USER, "user:"+user.Login, nil, map[string]string{"login": user.Login, "role": user.Role}, ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	respondWithJson(w, http.StatusOK, Response{OK_RESPONSE, OK_CODE_RESPONSE})
 }
 func (c *Controller) Request(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
@@ -232,23 +436,25 @@ func (c *Controller) Request(w http.ResponseWriter, r *http.Request) {
 	}
 	termId, _ := strconv.ParseInt(requestXml.Terminal.ID, 10, 64)
 
-	term := repository.GetTerminalById(termId)
+	repo := repository.For(r)
+	term := repo.GetTerminalById(termId)
 	if term.Secret != "" && CheckSign(term.Secret, reqest.Xml, reqest.Sign) {
 		//Correct sign
-		resp, _ := repository.ValidateTicket(requestXml.Ticket.Code, term)
-		rez, _ := repository.ValidateRegistrateTicket(requestXml.Ticket.Code, term, "entry")
+		resp, _ := repo.ValidateTicket(requestXml.Ticket.Code, term)
+		rez, _ := repo.ValidateRegistrateTicket(requestXml.Ticket.Code, term, "entry")
 		resp.Result.Code = rez.Result.Code
 		oldresp := SKDOLDResponse{}
 		oldresp.fromResponse(resp)
-		repository.Log(Log{0, requestXml.Ticket.Code, "Result for entry from gate #" + requestXml.Terminal.ID + ". MANUAL SCAN! ", resp.Result.Code})
+		repo.Log(Log{0, requestXml.Ticket.Code, "Result for entry from gate #" + requestXml.Terminal.ID + ". MANUAL SCAN! ", resp.Result.Code})
+		observeScan(resp.Result.Code, requestXml.Terminal.ID, "entry")
 		if resp.Result.Code == 1 {
-			repository.RegistrateTicket(requestXml.Ticket.Code, term, "entry")
+			repo.RegistrateTicket(requestXml.Ticket.Code, term, "entry")
 		}
 		respondWithJson(w, OK_CODE_RESPONSE, oldresp)
 		return
 	}
 	// Bad sign or gateId
-	log.Println("Bad sign")
+	scanLogger(repo.logger(), Terminal{Id: termId}, requestXml.Ticket.Code, "entry").Warn("Bad sign")
 	respondWithJson(w, http.StatusBadRequest, Exception{UNAUTHORIZED, errDecode.Error()})
 	return
 
@@ -262,7 +468,13 @@ func (c *Controller) TerminalSet(w http.ResponseWriter, r *http.Request) {
 		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, err.Error()})
 		return
 	}
-	repository.SetTerminal(terminal)
+	before := auditTerminal(repository.GetTerminalById(terminal.Id))
+	ex := repository.SetTerminal(terminal)
+	audit(r, AUDIT_SET_TERMINAL, terminalTarget(terminal.Id), before, auditTerminal(repository.GetTerminalById(terminal.Id)), ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
 	json.NewEncoder(w).Encode(Response{OK_RESPONSE, OK_CODE_RESPONSE})
 }
 func (c *Controller) TerimalAuthPng(w http.ResponseWriter, r *http.Request) {
@@ -277,11 +489,10 @@ func (c *Controller) TerimalAuthPng(w http.ResponseWriter, r *http.Request) {
 	terminalAuth.Auth.URL = getHostnameFromUrl(r.Referer()) + "/request"
 	jsonAuth, errjson := json.Marshal(terminalAuth)
 	if err != nil {
-		fmt.Printf("Error: %s", errjson)
+		requestLogger(r).Error("Can`t encode terminal auth", "terminal_id", id, "error", errjson)
 		return
 	}
 	png, err := qrcode.Encode(string(jsonAuth), qrcode.Low, 200)
-	log.Println(string(jsonAuth))
 	if err == nil {
 		writeImagePng(w, png)
 	}
@@ -296,11 +507,13 @@ func (c *Controller) AddTerminalHandler(w http.ResponseWriter, r *http.Request)
 	//Check login information
 	errDecode := decoder.Decode(&terminal, r.PostForm)
 	if errDecode != nil {
-		log.Println(errDecode)
+		requestLogger(r).Warn("Can`t decode form", "error", errDecode)
 		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
 		return
 	}
-	repository.AddTerminal(terminal)
+	ex := repository.AddTerminal(terminal)
+	added := repository.GetTerminalByName(terminal.Name)
+	audit(r, AUDIT_ADD_TERMINAL, terminalTarget(added.Id), nil, auditTerminal(added), ex)
 	/*	ex := repository.AddGroup(group)
 		if ex != nil {
 			respondWithJson(w, http.StatusBadRequest, ex)
@@ -308,7 +521,12 @@ func (c *Controller) AddTerminalHandler(w http.ResponseWriter, r *http.Request)
 
 }
 func (c *Controller) GetBuildings(w http.ResponseWriter, r *http.Request) {
-	respondWithJson(w, OK_CODE_RESPONSE, api.GetBuildings())
+	buildings, err := repository.For(r).api().GetBuildings()
+	if err != nil {
+		respondWithJson(w, http.StatusBadGateway, Exception{API_EXEPTION, err.Error()})
+		return
+	}
+	respondWithJson(w, OK_CODE_RESPONSE, buildings)
 
 }
 func (c *Controller) EventInfo(w http.ResponseWriter, r *http.Request) {
@@ -316,7 +534,11 @@ func (c *Controller) EventInfo(w http.ResponseWriter, r *http.Request) {
 	idin := vars["id"]
 	id, _ := strconv.Atoi(idin)
 	eventInfo := repository.GetEventInfo(int64(id))
-	respondWithJson(w, OK_CODE_RESPONSE, eventInfo)
+	respondWithExport(w, r, "event_"+idin, eventInfo, func() Table {
+		event := repository.GetEventById(int64(id))
+		event.localize()
+		return eventInfoTable(event.Title+" "+event.DtISO, eventInfo)
+	})
 
 }
 func (c *Controller) Validation(w http.ResponseWriter, r *http.Request) {
@@ -328,15 +550,17 @@ func (c *Controller) Validation(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		respondWithJson(w, http.StatusBadRequest, err)
 	}
-	term := repository.GetTerminalById(int64(gateId))
+	repo := repository.For(r)
+	term := repo.GetTerminalById(int64(gateId))
 	if term.Secret != "" && CheckSign(term.Secret, ticket, sign) {
 		//Correct sign
-		resp, _ := repository.ValidateTicket(ticket, term)
+		resp, _ := repo.ValidateTicket(ticket, term)
+		observeScan(resp.Result.Code, gate, SCAN_DIRECTION_CHECK)
 		respondWithJson(w, OK_CODE_RESPONSE, resp)
 		return
 	}
 	// Bad sign or gateId
-
+	scanLogger(repo.logger(), Terminal{Id: int64(gateId)}, ticket, "").Warn("Bad sign")
 	respondWithJson(w, http.StatusUnauthorized, Exception{Message: "Unauthorized"})
 
 }
@@ -351,19 +575,20 @@ func (c *Controller) ValidationRegistration(w http.ResponseWriter, r *http.Reque
 	if err != nil {
 		respondWithJson(w, http.StatusBadRequest, err)
 	}
-	term := repository.GetTerminalById(int64(gateId))
+	repo := repository.For(r)
+	term := repo.GetTerminalById(int64(gateId))
 
 	if term.Secret != "" && CheckSign(term.Secret, ticket, sign) {
 		//Correct sign
-		log.Println("1")
-		resp, _ := repository.ValidateRegistrateTicket(ticket, term, direction)
-		log.Println("2")
-		repository.Log(Log{0, ticket, "Result for " + direction + " from gate #" + gate, resp.Result.Code})
+		resp, _ := repo.ValidateRegistrateTicket(ticket, term, direction)
+		repo.Log(Log{0, ticket, "Result for " + direction + " from gate #" + gate, resp.Result.Code})
+		observeScan(resp.Result.Code, gate, direction)
 		respondWithJson(w, OK_CODE_RESPONSE, resp)
 		return
 	}
 	// Bad sign or gateId
-	repository.Log(Log{0, ticket, "Bad sign request from gate #" + gate + " sign - " + sign, http.StatusUnauthorized})
+	scanLogger(repo.logger(), Terminal{Id: int64(gateId)}, ticket, direction).Warn("Bad sign")
+	repo.Log(Log{0, ticket, "Bad sign request from gate #" + gate + " sign - " + sign, http.StatusUnauthorized})
 	respondWithJson(w, http.StatusUnauthorized, Exception{Message: "Unauthorized"})
 
 }
@@ -378,22 +603,88 @@ func (c *Controller) Registration(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		respondWithJson(w, http.StatusBadRequest, err)
 	}
-	term := repository.GetTerminalById(int64(gateId))
+	repo := repository.For(r)
+	term := repo.GetTerminalById(int64(gateId))
 	if term.Secret != "" && CheckSign(term.Secret, ticket, sign) {
 		//Correct sign
-		resp, _ := repository.RegistrateTicket(ticket, term, direction)
+		resp, _ := repo.RegistrateTicket(ticket, term, direction)
+		observeScan(resp.Code, gate, direction)
 		respondWithJson(w, OK_CODE_RESPONSE, resp)
 		return
 	}
 	// Bad sign or gateId
+	scanLogger(repo.logger(), Terminal{Id: int64(gateId)}, ticket, direction).Warn("Bad sign")
 	respondWithJson(w, http.StatusUnauthorized, Exception{Message: "Unauthorized"})
 
 }
 func (c *Controller) Groups(w http.ResponseWriter, r *http.Request) {
 	respondWithJson(w, http.StatusOK, repository.Groups())
 }
+func (c *Controller) RetentionHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJson(w, http.StatusOK, repository.Retention())
+}
 func (c *Controller) LogsHandler(w http.ResponseWriter, r *http.Request) {
-	respondWithJson(w, http.StatusOK, repository.Logs())
+	var query LogQuery
+	errDecode := decoder.Decode(&query, r.URL.Query())
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, errDecode.Error()})
+		return
+	}
+	if query.Limit <= 0 {
+		query.Limit = LOGS_DEFAULT_LIMIT
+	}
+	if query.Limit > LOGS_MAX_LIMIT {
+		query.Limit = LOGS_MAX_LIMIT
+	}
+	logs, next, ex := repository.Logs(query)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	w.Header().Set("X-Next-Cursor", next)
+	respondWithJson(w, http.StatusOK, logs)
+}
+func (c *Controller) EntriesHandler(w http.ResponseWriter, r *http.Request) {
+	var query EntryQuery
+	errDecode := decoder.Decode(&query, r.URL.Query())
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, errDecode.Error()})
+		return
+	}
+	if query.Limit <= 0 {
+		query.Limit = ENTRIES_DEFAULT_LIMIT
+	}
+	if query.Limit > ENTRIES_MAX_LIMIT {
+		query.Limit = ENTRIES_MAX_LIMIT
+	}
+	entries, next, ex := repository.Entries(query)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	w.Header().Set("X-Next-Cursor", next)
+	respondWithJson(w, http.StatusOK, entries)
+}
+func (c *Controller) AuditHandler(w http.ResponseWriter, r *http.Request) {
+	var query AuditQuery
+	errDecode := decoder.Decode(&query, r.URL.Query())
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, errDecode.Error()})
+		return
+	}
+	if query.Limit <= 0 {
+		query.Limit = AUDIT_DEFAULT_LIMIT
+	}
+	if query.Limit > AUDIT_MAX_LIMIT {
+		query.Limit = AUDIT_MAX_LIMIT
+	}
+	records, next, ex := repository.Audit(query)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	w.Header().Set("X-Next-Cursor", next)
+	respondWithJson(w, http.StatusOK, records)
 }
 func (c *Controller) AddUserHandler(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
@@ -408,10 +699,111 @@ func (c *Controller) AddUserHandler(w http.ResponseWriter, r *http.Request) {
 		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
 		return
 	}
+	if user.Role == "" {
+		user.Role = ROLE_VIEWER
+	}
+	if !IsRole(user.Role) {
+		respondWithJson(w, http.StatusBadRequest, Exception{ROLE_EXEPTION, user.Role})
+		return
+	}
 	ex := repository.AddUser(user)
+	audit(r, AUDIT_ADD_USER, "user:"+user.Login, nil, map[string]string{"login": user.Login, "role": user.Role}, ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+	}
+}
+func (c *Controller) UsersHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJson(w, http.StatusOK, repository.Users())
+}
+func (c *Controller) SetUserHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	var form UserForm
+	errDecode := decoder.Decode(&form, r.PostForm)
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
+		return
+	}
+	if form.Role != "" && !IsRole(form.Role) {
+		respondWithJson(w, http.StatusBadRequest, Exception{ROLE_EXEPTION, form.Role})
+		return
+	}
+	before := auditUser(repository.GetUser(form.Login))
+	ex := repository.SetUser(form)
+	audit(r, AUDIT_SET_USER, "user:"+form.Login, before, auditUser(repository.GetUser(form.Login)), ex)
 	if ex != nil {
 		respondWithJson(w, http.StatusBadRequest, ex)
+		return
 	}
+	respondWithJson(w, http.StatusOK, Response{OK_RESPONSE, OK_CODE_RESPONSE})
+}
+func (c *Controller) RemoveUserHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	login := r.PostForm.Get("login")
+	before := auditUser(repository.GetUser(login))
+	ex := repository.RemoveUser(login)
+	audit(r, AUDIT_REMOVE_USER, "user:"+login, before, nil, ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	respondWithJson(w, http.StatusOK, Response{OK_RESPONSE, OK_CODE_RESPONSE})
+}
+
+// Password reset by admin
+func (c *Controller) SetUserPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	var form PasswordForm
+	errDecode := decoder.Decode(&form, r.PostForm)
+	if errDecode != nil || form.Login == "" {
+		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, "login and password are required"})
+		return
+	}
+	ex := repository.SetUserPassword(form.Login, form.Password)
+	audit(r, AUDIT_SET_USER_PASSWORD, "user:"+form.Login, nil, nil, ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	respondWithJson(w, http.StatusOK, Response{OK_RESPONSE, OK_CODE_RESPONSE})
+}
+
+// Own password change, other sessions are revoked and new token is returned
+func (c *Controller) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	var form PasswordForm
+	errDecode := decoder.Decode(&form, r.PostForm)
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
+		return
+	}
+	user, _ := context.Get(r, "user").(User)
+	if valid, _ := VerifyPassword(user.Password, form.OldPassword); !valid {
+		respondWithJson(w, http.StatusBadRequest, Exception{WRONG_PASSWORD_EXEPTION, ""})
+		return
+	}
+	ex := repository.SetUserPassword(user.Login, form.Password)
+	audit(r, AUDIT_CHANGE_PASSWORD, "user:"+user.Login, nil, nil, ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	startSession(w, r, repository.GetUser(user.Login))
 }
 func (c *Controller) AddGroupHandler(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
@@ -427,6 +819,7 @@ func (c *Controller) AddGroupHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	ex := repository.AddGroup(group)
+	audit(r, AUDIT_ADD_GROUP, "group:"+group.Name, nil, auditGroup(repository.GetGroupByName(group.Name)), ex)
 	if ex != nil {
 		respondWithJson(w, http.StatusBadRequest, ex)
 	}
@@ -445,6 +838,8 @@ func (c *Controller) AddMasterKeyHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 	ex := repository.AddMasterKey(masterKey)
+	masked := maskBarcode(masterKey.Barcode)
+	audit(r, AUDIT_ADD_MASTERKEY, "masterkey:"+masked, nil, MasterKey{masked}, ex)
 	if ex != nil {
 		respondWithJson(w, http.StatusBadRequest, ex)
 	}
@@ -453,18 +848,273 @@ func (c *Controller) EventsByGroupHandler(w http.ResponseWriter, r *http.Request
 	vars := mux.Vars(r)
 	idin := vars["id"]
 	id, _ := strconv.Atoi(idin)
-	log.Println("Read event for " + idin)
-	events := repository.GetEventsByGroup(int64(id))
+	requestLogger(r).Debug("Read events for group", "group_id", id)
+	//Optional from/to days in venue timezone
+	var dtf, dtt int64
+	query := r.URL.Query()
+	loc := LoadLocation(repository.GetGroupById(int64(id)).Timezone)
+	if from, errFrom := time.ParseInLocation("2006-01-02", query.Get("from"), loc); errFrom == nil {
+		dtf = Bod(from).Unix()
+	}
+	if to, errTo := time.ParseInLocation("2006-01-02", query.Get("to"), loc); errTo == nil {
+		dtt = Eod(to).Unix()
+	}
+	events := repository.GetEventsByGroup(int64(id), dtf, dtt)
 	respondWithJson(w, OK_CODE_RESPONSE, events)
 }
 func (c *Controller) EventSync(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idin := vars["id"]
 	id, _ := strconv.Atoi(idin)
-	event, _ := repository.SyncEvent(int64(id))
-	repository.Log(Log{0, strconv.FormatInt(event.Id, 10), "Event synced. " + strconv.Itoa(event.TicketsCached) + " tickets cached.", OK_CODE_RESPONSE})
+	event, ex := repository.For(r).SyncEvent(int64(id))
+	if ex != nil {
+		status := http.StatusInternalServerError
+		if ex.Message == API_EXEPTION {
+			status = http.StatusBadGateway
+		}
+		respondWithJson(w, status, ex)
+		return
+	}
+	message := "Event synced. " + strconv.Itoa(event.TicketsCached) + " tickets cached."
+	if event.LastSync != nil {
+		message += fmt.Sprintf(" Added %d, removed %d, changed %d.", event.LastSync.Added, event.LastSync.Removed, event.LastSync.Changed)
+	}
+	repository.For(r).Log(Log{0, strconv.FormatInt(event.Id, 10), message, OK_CODE_RESPONSE})
+	event.localize()
+	respondWithJson(w, OK_CODE_RESPONSE, event)
+}
+func (c *Controller) EventSyncHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idin := vars["id"]
+	id, _ := strconv.Atoi(idin)
+	query := r.URL.Query()
+	//Diff between two runs
+	if query.Get("from") != "" || query.Get("to") != "" {
+		from, errFrom := strconv.ParseInt(query.Get("from"), 10, 64)
+		to, errTo := strconv.ParseInt(query.Get("to"), 10, 64)
+		if errFrom != nil || errTo != nil {
+			respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, "from and to must be sync run ids"})
+			return
+		}
+		diff, ex := repository.SyncDiff(int64(id), from, to)
+		if ex != nil {
+			respondWithJson(w, http.StatusInternalServerError, ex)
+			return
+		}
+		respondWithJson(w, OK_CODE_RESPONSE, diff)
+		return
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = SYNC_HISTORY_LIMIT
+	}
+	respondWithJson(w, OK_CODE_RESPONSE, repository.SyncHistory(int64(id), limit))
+}
+// Own key only, webhook is closed if not set
+func GetWebhookSecretKey() string {
+	return os.Getenv(WEBHOOK_SECRET_KEY_ENV)
+}
+func (c *Controller) EventBreakdownHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idin := vars["id"]
+	id, _ := strconv.Atoi(idin)
+	//comma separated dimensions, all by default
+	dimensions := []string{BREAKDOWN_PRICE, BREAKDOWN_SECTOR, BREAKDOWN_CASHBOX, BREAKDOWN_OPERATOR, BREAKDOWN_ETICKET}
+	if by := r.URL.Query().Get("by"); by != "" {
+		dimensions = strings.Split(by, ",")
+	}
+	breakdown, ex := repository.GetEventBreakdown(int64(id), dimensions)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	respondWithExport(w, r, "event_"+idin+"_breakdown", breakdown, func() Table {
+		return breakdownTable("Event #"+idin+" sales breakdown", breakdown)
+	})
+}
+func (c *Controller) EventNoShowHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idin := vars["id"]
+	id, _ := strconv.Atoi(idin)
+	var filter NoShowFilter
+	errDecode := decoder.Decode(&filter, r.URL.Query())
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, errDecode.Error()})
+		return
+	}
+	noShows, ex := repository.GetEventNoShows(int64(id), filter)
+	if ex != nil {
+		respondWithJson(w, http.StatusInternalServerError, ex)
+		return
+	}
+	respondWithExport(w, r, "event_"+idin+"_noshow", noShows, func() Table {
+		return noShowTable("Event #"+idin+" no-shows", noShows)
+	})
+}
+func (c *Controller) EventAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idin := vars["id"]
+	id, _ := strconv.Atoi(idin)
+	anomalies, ex := repository.GetEventAnomalies(int64(id))
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	respondWithExport(w, r, "event_"+idin+"_anomalies", anomalies, func() Table {
+		return anomaliesTable("Event #"+idin+" anomalies", anomalies)
+	})
+}
+func (c *Controller) EventTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idin := vars["id"]
+	id, _ := strconv.Atoi(idin)
+	//interval in minutes
+	interval, err := strconv.Atoi(r.URL.Query().Get("interval"))
+	if err != nil || interval <= 0 {
+		interval = TIMELINE_DEFAULT_INTERVAL
+	}
+	if interval > TIMELINE_MAX_INTERVAL {
+		interval = TIMELINE_MAX_INTERVAL
+	}
+	respondWithJson(w, OK_CODE_RESPONSE, repository.GetEventTimeline(int64(id), int64(interval)*60))
+}
+func (c *Controller) EventStream(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idin := vars["id"]
+	id, _ := strconv.Atoi(idin)
+	serveStream(w, r, &streamSubscriber{eventId: int64(id)}, []EventLiveStats{repository.GetEventLiveStats(int64(id))})
+}
+func (c *Controller) GroupStream(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idin := vars["id"]
+	id, _ := strconv.Atoi(idin)
+	group := repository.GetGroupById(int64(id))
+	initial := []EventLiveStats{}
+	activeEvents := repository.GetActiveEventsByGroups(Groups{[]Group{group}})
+	for _, event := range activeEvents.Events {
+		initial = append(initial, repository.GetEventLiveStats(event.Id))
+	}
+	serveStream(w, r, &streamSubscriber{group: &group}, initial)
+}
+func (c *Controller) EventStatusHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idin := vars["id"]
+	id, _ := strconv.Atoi(idin)
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	var statusForm EventStatusForm
+	errDecode := decoder.Decode(&statusForm, r.PostForm)
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
+		return
+	}
+	before := auditEventStatus(repository.GetEventById(int64(id)))
+	ex := repository.SetEventStatus(int64(id), statusForm)
+	audit(r, AUDIT_SET_EVENT_STATUS, eventTarget(int64(id)), before, auditEventStatus(repository.GetEventById(int64(id))), ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	repository.For(r).Log(Log{0, idin, "Event status set to " + statusForm.Status, OK_CODE_RESPONSE})
+	event := repository.GetEventById(int64(id))
+	event.localize()
 	respondWithJson(w, OK_CODE_RESPONSE, event)
 }
+func (c *Controller) TicketWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	var request TicketWebhookRequest
+	errDecode := decoder.Decode(&request, r.PostForm)
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
+		return
+	}
+	if GetWebhookSecretKey() == "" {
+		respondWithJson(w, http.StatusForbidden, Exception{FORBIDDEN_EXEPTION, WEBHOOK_SECRET_KEY_ENV + " is not set"})
+		return
+	}
+	if !CheckSign(GetWebhookSecretKey(), request.Data, request.Sign) {
+		repository.For(r).Log(Log{0, request.Data, "Bad sign webhook request, sign - " + request.Sign, http.StatusUnauthorized})
+		respondWithJson(w, http.StatusUnauthorized, Exception{UNAUTHORIZED, ""})
+		return
+	}
+	var hook TicketWebhook
+	errJson := json.Unmarshal([]byte(request.Data), &hook)
+	if errJson != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, errJson.Error()})
+		return
+	}
+	if !hook.Fresh(time.Now()) {
+		repository.For(r).Log(Log{0, request.Data, "Expired webhook request, dt - " + strconv.FormatInt(hook.Dt, 10), http.StatusUnauthorized})
+		respondWithJson(w, http.StatusUnauthorized, Exception{UNAUTHORIZED, "webhook dt is missing or too old"})
+		return
+	}
+	repo := repository.For(r)
+	if ex := repo.RecordWebhook(hook, request); ex != nil {
+		repo.Log(Log{0, request.Data, "Replayed webhook request, " + ex.Error, http.StatusConflict})
+		respondWithJson(w, http.StatusConflict, ex)
+		return
+	}
+	run, ex := repo.ApplyTicketWebhook(hook)
+	if ex != nil {
+		repo.ForgetWebhook(request)
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	repository.For(r).Log(Log{0, hook.Ticket.TicketBarcode, fmt.Sprintf("Webhook %s for event #%d. Added %d, removed %d, changed %d.", hook.Action, hook.EventId, len(run.Added), len(run.Removed), len(run.Changed)), OK_CODE_RESPONSE})
+	respondWithJson(w, OK_CODE_RESPONSE, Response{OK_RESPONSE, OK_CODE_RESPONSE})
+}
+func (c *Controller) ImportTicketsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idin := vars["id"]
+	id, _ := strconv.Atoi(idin)
+	err := r.ParseMultipartForm(IMPORT_MAX_SIZE)
+	if err != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, err.Error()})
+		return
+	}
+	var importForm TicketImportForm
+	errDecode := decoder.Decode(&importForm, r.MultipartForm.Value)
+	if errDecode != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
+		return
+	}
+	file, header, errFile := r.FormFile("file")
+	if errFile != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errFile.Error()})
+		return
+	}
+	defer file.Close()
+	rows, errRead := readImportRows(file, header.Filename, importForm.Format)
+	if errRead != nil {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, errRead.Error()})
+		return
+	}
+	report, ex := repository.For(r).ImportTickets(int64(id), importForm, rows)
+	//dry run changes nothing
+	if !report.DryRun {
+		audit(r, AUDIT_IMPORT_TICKETS, eventTarget(int64(id)), nil, map[string]interface{}{"file": header.Filename, "source": report.Source,
+			"rows": report.Rows, "imported": report.Imported}, ex)
+	}
+	if ex != nil {
+		if ex.Message == IMPORT_EXEPTION {
+			respondWithJson(w, http.StatusBadRequest, report)
+			return
+		}
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	if !report.DryRun {
+		repository.For(r).Log(Log{0, strconv.Itoa(id), "Tickets imported from " + header.Filename + " as " + report.Source + ". " + strconv.Itoa(report.Imported) + " tickets.", OK_CODE_RESPONSE})
+	}
+	respondWithJson(w, OK_CODE_RESPONSE, report)
+}
 func (c *Controller) SetGroupHandler(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
@@ -475,19 +1125,95 @@ func (c *Controller) SetGroupHandler(w http.ResponseWriter, r *http.Request) {
 	//Check login information
 	errDecode := decoder.Decode(&group, r.PostForm)
 	if errDecode != nil {
-		log.Println(errDecode)
+		requestLogger(r).Warn("Can`t decode form", "error", errDecode)
 		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
 		return
 	}
+	before := auditGroup(repository.GetGroupByName(group.Name))
 	ex := repository.SetGroup(group)
+	audit(r, AUDIT_SET_GROUP, "group:"+group.Name, before, auditGroup(repository.GetGroupByName(group.Name)), ex)
 	if ex != nil {
 		respondWithJson(w, http.StatusBadRequest, ex)
 	}
 	if group.BuildingId != 0 {
-		repository.SyncEventsList(group.BuildingId)
+		repository.For(r).SyncEventsList(group.BuildingId)
 	}
 
 }
+func (c *Controller) ReportsHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithJson(w, http.StatusOK, repository.ReportSchedules())
+}
+func decodeReportSchedule(r *http.Request) (ReportSchedule, *Exception) {
+	var schedule ReportSchedule
+	err := r.ParseForm()
+	if err != nil {
+		return schedule, &Exception{PARSE_PARAMS_EXEPTION, err.Error()}
+	}
+	errDecode := decoder.Decode(&schedule, r.PostForm)
+	if errDecode != nil {
+		return schedule, &Exception{NOT_ENOUGH_PARAMS, errDecode.Error()}
+	}
+	return schedule, schedule.normalize(r.PostForm)
+}
+func (c *Controller) AddReportHandler(w http.ResponseWriter, r *http.Request) {
+	schedule, ex := decodeReportSchedule(r)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	schedule, ex = repository.AddReportSchedule(schedule)
+	audit(r, AUDIT_ADD_REPORT, reportTarget(schedule.Id), nil, auditReport(schedule), ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	respondWithJson(w, OK_CODE_RESPONSE, schedule)
+}
+func (c *Controller) SetReportHandler(w http.ResponseWriter, r *http.Request) {
+	schedule, ex := decodeReportSchedule(r)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	current, _ := repository.GetReportSchedule(schedule.Id)
+	schedule, ex = repository.SetReportSchedule(schedule)
+	after, _ := repository.GetReportSchedule(schedule.Id)
+	audit(r, AUDIT_SET_REPORT, reportTarget(schedule.Id), auditReport(current), auditReport(after), ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	respondWithJson(w, OK_CODE_RESPONSE, schedule)
+}
+func (c *Controller) RemoveReportHandler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	id, _ := strconv.ParseInt(r.PostForm.Get("id"), 10, 64)
+	current, _ := repository.GetReportSchedule(id)
+	ex := repository.RemoveReportSchedule(id)
+	audit(r, AUDIT_REMOVE_REPORT, reportTarget(id), auditReport(current), nil, ex)
+	if ex != nil {
+		respondWithJson(w, http.StatusBadRequest, ex)
+		return
+	}
+	respondWithJson(w, OK_CODE_RESPONSE, Response{Result: OK_RESPONSE, Code: OK_CODE_RESPONSE})
+}
+
+// Build and deliver report now, for yesterday (or last week)
+func (c *Controller) RunReportHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, _ := strconv.ParseInt(vars["id"], 10, 64)
+	schedule, ex := repository.GetReportSchedule(id)
+	if ex != nil {
+		respondWithJson(w, http.StatusNotFound, ex)
+		return
+	}
+	schedule, ex = repository.RunReport(schedule, time.Now())
+	if ex != nil {
+		respondWithJson(w, http.StatusBadGateway, ex)
+		return
+	}
+	respondWithJson(w, OK_CODE_RESPONSE, schedule)
+}
 func (c *Controller) RemoveGroupHandler(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
@@ -501,7 +1227,9 @@ func (c *Controller) RemoveGroupHandler(w http.ResponseWriter, r *http.Request)
 		respondWithJson(w, http.StatusBadRequest, Exception{NOT_ENOUGH_PARAMS, errDecode.Error()})
 		return
 	}
+	before := auditGroup(repository.GetGroupByName(group.Name))
 	ex := repository.RemoveGroup(group)
+	audit(r, AUDIT_REMOVE_GROUP, "group:"+group.Name, before, auditGroup(repository.GetGroupByName(group.Name)), ex)
 	if ex != nil {
 		respondWithJson(w, http.StatusBadRequest, ex)
 	}
@@ -510,33 +1238,26 @@ func (c *Controller) RemoveGroupHandler(w http.ResponseWriter, r *http.Request)
 //Middleware
 func AuthenticationMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		var clientToken string
-		JWTCookie, cookieErr := req.Cookie("token")
-		if cookieErr != nil {
-			authorizationHeader := req.Header.Get("authorization")
-			bearerToken := strings.Split(authorizationHeader, " ")
-			if len(bearerToken) != 2 {
-				//http.Redirect(w, req, "/get-token", 301)
-				respondWithJson(w, http.StatusUnauthorized, Exception{Message: "Not Authorized"})
-				return
-			}
-			clientToken = bearerToken[1]
-		} else {
-			clientToken = JWTCookie.Value
+		token, error := parseRequestToken(req)
+		if error == errNoToken {
+			//http.Redirect(w, req, "/get-token", 301)
+			respondWithJson(w, http.StatusUnauthorized, Exception{Message: "Not Authorized"})
+			return
 		}
-		token, error := jwt.Parse(clientToken, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("There was an error")
-			}
-			return []byte(GetSecretKey()), nil
-		})
 		if error != nil {
 			json.NewEncoder(w).Encode(Exception{Message: error.Error()})
 			return
 		}
 		if token.Valid {
-			log.Println("TOKEN WAS VALID")
+			user, session, active := tokenUser(token.Claims.(jwt.MapClaims))
+			if !active {
+				respondWithJson(w, http.StatusUnauthorized, Exception{Message: "Not Authorized, session revoked"})
+				return
+			}
+			requestLogger(req).Debug("Token valid", "username", user.Login)
 			context.Set(req, "decoded", token.Claims)
+			context.Set(req, "user", user)
+			context.Set(req, "session", session)
 			next(w, req)
 		} else {
 			respondWithJson(w, http.StatusUnauthorized, Exception{Message: "Not Authorized, token not valid"})
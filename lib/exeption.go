@@ -14,3 +14,19 @@ const TERMINAL_EXIST_EXEPTION = "Can`t add, terminal already exists"
 const PARSE_PARAMS_EXEPTION = "Can`t parse params"
 const NOT_ENOUGH_PARAMS = "Not enouth params"
 const UNAUTHORIZED = "Unauthorized access "
+const FORBIDDEN_EXEPTION = "Forbidden, role has no permission"
+const USER_NOT_FOUND_EXEPTION = "User not found"
+const LAST_ADMIN_EXEPTION = "Can`t remove role of last active admin"
+const WRONG_PASSWORD_EXEPTION = "Wrong password"
+const WEAK_PASSWORD_EXEPTION = "Password is too weak"
+const SESSION_NOT_FOUND_EXEPTION = "Session not found"
+const REFRESH_TOKEN_EXEPTION = "Refresh token not valid, login again"
+const ROLE_EXEPTION = "Unknown role, use admin, manager, operator or viewer"
+const EVENT_NOT_FOUND_EXEPTION = "Event not found"
+const API_EXEPTION = "Can`t get data from api"
+const WEBHOOK_REPLAY_EXEPTION = "Webhook already applied or older than last one for ticket"
+const IMPORT_EXEPTION = "Can`t import tickets, check errors"
+const EXPORT_EXEPTION = "Can`t build report"
+const REPORT_NOT_FOUND_EXEPTION = "Report schedule not found"
+const REPORT_EXEPTION = "Can`t deliver report"
+const RETENTION_EXEPTION = "Can`t apply retention"
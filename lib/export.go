@@ -0,0 +1,270 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/jung-kurt/gofpdf"
+	"github.com/tealeg/xlsx"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const EXPORT_JSON = "json"
+const EXPORT_CSV = "csv"
+const EXPORT_XLSX = "xlsx"
+const EXPORT_PDF = "pdf"
+
+// TTF font with cyrillic for pdf, core Helvetica if not set
+const PDF_FONT_ENV = "PDF_FONT"
+
+var exportContentTypes = map[string]string{
+	EXPORT_CSV:  "text/csv; charset=utf-8",
+	EXPORT_XLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	EXPORT_PDF:  "application/pdf",
+}
+
+// Order formats are matched against Accept header
+var exportFormats = []string{EXPORT_CSV, EXPORT_XLSX, EXPORT_PDF}
+
+// Report as plain table, cells are string, int64, float64 or Money
+type Table struct {
+	Title  string
+	Header []string
+	Rows   [][]interface{}
+}
+
+func (t *Table) addRow(cells ...interface{}) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// format param wins over Accept header, json by default
+func exportFormat(r *http.Request) string {
+	format := strings.ToLower(r.FormValue("format"))
+	if format != "" {
+		return format
+	}
+	accept := r.Header.Get("Accept")
+	for _, format := range exportFormats {
+		if strings.Contains(accept, strings.Split(exportContentTypes[format], ";")[0]) {
+			return format
+		}
+	}
+	return EXPORT_JSON
+}
+
+func cellString(cell interface{}) string {
+	switch v := cell.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(cell)
+}
+
+// Text starting like a formula is kept as text by spreadsheet apps, numbers are left as is
+func spreadsheetString(cell interface{}) string {
+	text, ok := cell.(string)
+	if !ok {
+		return cellString(cell)
+	}
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func (t *Table) csv() ([]byte, error) {
+	var buffer bytes.Buffer
+	//BOM for Excel to detect utf-8
+	buffer.WriteString("\uFEFF")
+	writer := csv.NewWriter(&buffer)
+	writer.Write(t.Header)
+	for _, row := range t.Rows {
+		record := []string{}
+		for _, cell := range row {
+			record = append(record, spreadsheetString(cell))
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+func (t *Table) xlsx() ([]byte, error) {
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet("Report")
+	if err != nil {
+		return nil, err
+	}
+	header := sheet.AddRow()
+	for _, name := range t.Header {
+		header.AddCell().SetString(name)
+	}
+	for _, row := range t.Rows {
+		sheetRow := sheet.AddRow()
+		for _, cell := range row {
+			switch v := cell.(type) {
+			case int64:
+				sheetRow.AddCell().SetInt64(v)
+			case int:
+				sheetRow.AddCell().SetInt(v)
+			case float64:
+				sheetRow.AddCell().SetFloat(v)
+			case Money:
+				sheetRow.AddCell().SetFloatWithFormat(v.Float(), "0.00")
+			default:
+				sheetRow.AddCell().SetString(spreadsheetString(cell))
+			}
+		}
+	}
+	var buffer bytes.Buffer
+	err = file.Write(&buffer)
+	return buffer.Bytes(), err
+}
+
+func (t *Table) pdf() ([]byte, error) {
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	font := "Helvetica"
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+	if path := os.Getenv(PDF_FONT_ENV); path != "" {
+		font = "Report"
+		//gofpdf joins file to font location, absolute path would be cut
+		pdf.SetFontLocation(filepath.Dir(path))
+		pdf.AddUTF8Font(font, "", filepath.Base(path))
+		pdf.AddUTF8Font(font, "B", filepath.Base(path))
+		//Helvetica would garble cyrillic titles, so no silent fallback
+		if err := pdf.Error(); err != nil {
+			return nil, fmt.Errorf("can`t load %s font %s: %v", PDF_FONT_ENV, path, err)
+		}
+		translate = func(s string) string { return s }
+	}
+	pdf.AddPage()
+	pdf.SetFont(font, "B", 14)
+	pdf.CellFormat(0, 10, translate(t.Title), "", 1, "L", false, 0, "")
+	pdf.SetFont(font, "", 8)
+	pdf.CellFormat(0, 6, translate("Generated "+time.Now().Format("2006-01-02 15:04")), "", 1, "L", false, 0, "")
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	width := (pageWidth - left - right) / float64(len(t.Header))
+	pdf.SetFont(font, "B", 9)
+	for _, name := range t.Header {
+		pdf.CellFormat(width, 7, translate(name), "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont(font, "", 9)
+	for _, row := range t.Rows {
+		for _, cell := range row {
+			align := "L"
+			if _, ok := cell.(string); !ok {
+				align = "R"
+			}
+			runes := []rune(cellString(cell))
+			text := translate(string(runes))
+			//cut long titles to column
+			for len(runes) > 1 && pdf.GetStringWidth(text) > width-2 {
+				runes = runes[:len(runes)-1]
+				text = translate(string(runes))
+			}
+			pdf.CellFormat(width, 6, text, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	var buffer bytes.Buffer
+	err := pdf.Output(&buffer)
+	return buffer.Bytes(), err
+}
+
+// Respond with payload as json or with table in requested format
+func respondWithExport(w http.ResponseWriter, r *http.Request, filename string, payload interface{}, table func() Table) {
+	format := exportFormat(r)
+	if format == EXPORT_JSON {
+		respondWithJson(w, OK_CODE_RESPONSE, payload)
+		return
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		respondWithJson(w, http.StatusBadRequest, Exception{PARSE_PARAMS_EXEPTION, "unknown format " + format})
+		return
+	}
+	report := table()
+	var data []byte
+	var err error
+	switch format {
+	case EXPORT_CSV:
+		data, err = report.csv()
+	case EXPORT_XLSX:
+		data, err = report.xlsx()
+	case EXPORT_PDF:
+		data, err = report.pdf()
+	}
+	if err != nil {
+		respondWithJson(w, http.StatusInternalServerError, Exception{EXPORT_EXEPTION, err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filename+"."+format+"\"")
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(OK_CODE_RESPONSE)
+	w.Write(data)
+}
+
+func statsTable(title string, events []EventStats) Table {
+	table := Table{Title: title, Header: []string{"#", "Event", "Date", "Title", "Sold", "Entered", "Total"}}
+	var sell, entry int64
+	var total Money
+	for _, event := range events {
+		table.addRow(event.Id, event.EventId, event.DtISO, event.Title, event.Sell, event.Entry, event.Total)
+		sell, entry, total = sell+event.Sell, entry+event.Entry, total+event.Total
+	}
+	table.addRow("", "", "", "Total", sell, entry, total)
+	return table
+}
+
+func breakdownTable(title string, breakdown EventBreakdown) Table {
+	table := Table{Title: title, Header: []string{"Dimension", "Key", "Sold", "Sold total", "Entered", "Entered total"}}
+	for _, b := range breakdown.Breakdowns {
+		for _, line := range b.Lines {
+			table.addRow(b.Dimension, line.Key, line.Sell, line.SellTotal, line.Entry, line.EntryTotal)
+		}
+	}
+	return table
+}
+
+func eventInfoTable(title string, info EventInfo) Table {
+	table := Table{Title: title, Header: []string{"Price", "Sold", "Entered", "Total"}}
+	for _, line := range info.Info {
+		table.addRow(line.Price, line.Sell, line.Entry, line.Total)
+	}
+	table.addRow("Total", info.Tickets(), info.Entrys(), info.Total())
+	return table
+}
+
+func noShowTable(title string, noShows EventNoShows) Table {
+	table := Table{Title: title, Header: []string{"Barcode", "Sector", "Place", "Price", "Order", "Customer", "Channel"}}
+	for _, ticket := range noShows.Tickets {
+		table.addRow(ticket.Barcode, ticket.Sector, ticket.Title, ticket.Price, ticket.OrderId, ticket.Customer, ticket.Channel)
+	}
+	table.addRow("Total "+strconv.Itoa(noShows.Count)+" of "+strconv.Itoa(noShows.Sell), "", "", noShows.Total, "", "", "")
+	return table
+}
+
+func anomaliesTable(title string, anomalies EventAnomalies) Table {
+	table := Table{Title: title, Header: []string{"Type", "Date", "Count", "Description"}}
+	for _, anomaly := range anomalies.Anomalies {
+		table.addRow(anomaly.Type, anomaly.DtISO, anomaly.Count, anomaly.Message)
+	}
+	return table
+}
+
+func comparisonTable(title string, comparison Comparison) Table {
+	table := Table{Title: title, Header: []string{"Key", "Title", "Events", "Sold", "Entered", "Total", "Capacity", "Sell-through", "Attendance"}}
+	for _, line := range comparison.Lines {
+		table.addRow(line.Key, line.Title, line.Events, line.Sell, line.Entry, line.Total, line.Capacity, line.SellThrough, line.Attendance)
+	}
+	return table
+}
@@ -0,0 +1,147 @@
+package lib
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/tealeg/xlsx"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const IMPORT_SOURCE_PREFIX = "import:"
+const IMPORT_DEFAULT_SOURCE = "guests"
+const IMPORT_MAX_SIZE = 16 << 20
+
+// Header names accepted for each column, first row of file must be a header
+var importColumns = map[string][]string{
+	"barcode": {"barcode", "ticket_barcode", "code"},
+	"sector":  {"sector", "ticket_sector"},
+	"title":   {"title", "ticket_title", "place"},
+	"price":   {"price", "ticket_price"},
+	"holder":  {"holder", "holder_name", "name", "customer_title"},
+}
+
+func ImportSource(name string) string {
+	if name == "" {
+		name = IMPORT_DEFAULT_SOURCE
+	}
+	return IMPORT_SOURCE_PREFIX + name
+}
+
+func readImportRows(file io.Reader, filename string, format string) ([][]string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+	switch format {
+	case "csv":
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case "xlsx":
+		data, err := ioutil.ReadAll(io.LimitReader(file, IMPORT_MAX_SIZE))
+		if err != nil {
+			return nil, err
+		}
+		book, err := xlsx.OpenBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(book.Sheets) == 0 {
+			return nil, fmt.Errorf("no sheets in file")
+		}
+		rows := [][]string{}
+		for _, row := range book.Sheets[0].Rows {
+			if row == nil {
+				continue
+			}
+			values := []string{}
+			for _, cell := range row.Cells {
+				values = append(values, cell.Value)
+			}
+			rows = append(rows, values)
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q, use csv or xlsx", format)
+}
+
+// Map header to column indexes, -1 for missing columns
+func importHeader(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for column := range importColumns {
+		columns[column] = -1
+	}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for column, names := range importColumns {
+			for _, n := range names {
+				if name == n && columns[column] == -1 {
+					columns[column] = i
+				}
+			}
+		}
+	}
+	if columns["barcode"] == -1 {
+		return columns, fmt.Errorf("barcode column not found in header")
+	}
+	return columns, nil
+}
+
+// Returns tickets with row number for each barcode, rows are numbered from 1 including header
+func TicketsFromRows(rows [][]string, eventId int64, source string) ([]TicketExport, map[string]int, []TicketImportError) {
+	tickets := []TicketExport{}
+	errors := []TicketImportError{}
+	rowByBarcode := map[string]int{}
+	if len(rows) == 0 {
+		return tickets, rowByBarcode, append(errors, TicketImportError{0, "", "empty file"})
+	}
+	columns, err := importHeader(rows[0])
+	if err != nil {
+		return tickets, rowByBarcode, append(errors, TicketImportError{1, "", err.Error()})
+	}
+	value := func(row []string, column string) string {
+		i := columns[column]
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	for i, row := range rows[1:] {
+		line := i + 2
+		barcode := value(row, "barcode")
+		if barcode == "" {
+			if strings.TrimSpace(strings.Join(row, "")) != "" {
+				errors = append(errors, TicketImportError{line, "", "empty barcode"})
+			}
+			continue
+		}
+		if first, ok := rowByBarcode[barcode]; ok {
+			errors = append(errors, TicketImportError{line, barcode, "duplicate barcode, first seen in row " + strconv.Itoa(first)})
+			continue
+		}
+		rowByBarcode[barcode] = line
+		price := value(row, "price")
+		if price != "" {
+			money, errPrice := ParseMoney(price)
+			if errPrice != nil {
+				errors = append(errors, TicketImportError{line, barcode, "price is not a number"})
+				continue
+			}
+			price = money.String()
+		}
+		tickets = append(tickets, TicketExport{
+			EventID:       int(eventId),
+			TicketBarcode: barcode,
+			TicketSector:  value(row, "sector"),
+			TicketTitle:   value(row, "title"),
+			TicketPrice:   price,
+			CustomerTitle: value(row, "holder"),
+			Source:        source,
+		})
+	}
+	return tickets, rowByBarcode, errors
+}
@@ -0,0 +1,97 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+// debug, info (default), warn or error
+const LOG_LEVEL_ENV = "LOG_LEVEL"
+
+// Request id is taken from this header if client sent one, returned and passed to api in it
+const REQUEST_ID_HEADER = "X-Request-ID"
+
+type requestIdKey struct{}
+
+var logger = newLogger(os.Stdout)
+
+func init() {
+	//standard log goes as json too
+	slog.SetDefault(logger)
+}
+
+func newLogger(w io.Writer) *slog.Logger {
+	level := slog.LevelInfo
+	if name := os.Getenv(LOG_LEVEL_ENV); name != "" {
+		level.UnmarshalText([]byte(name))
+	}
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+func newRequestId() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func requestId(req *http.Request) string {
+	id, _ := req.Context().Value(requestIdKey{}).(string)
+	return id
+}
+
+func requestLogger(req *http.Request) *slog.Logger {
+	if id := requestId(req); id != "" {
+		return logger.With("request_id", id)
+	}
+	return logger
+}
+
+// Repository logging with request id and passing it to api
+func (r *Repository) For(req *http.Request) *Repository {
+	scoped := *r
+	scoped.requestId = requestId(req)
+	scoped.log = requestLogger(req)
+	return &scoped
+}
+
+func (r *Repository) logger() *slog.Logger {
+	if r.log == nil {
+		return logger
+	}
+	return r.log
+}
+
+func (r *Repository) api() *Api {
+	return api.With(r.logger(), r.requestId)
+}
+
+func (api Api) With(log *slog.Logger, requestId string) *Api {
+	api.log = log
+	api.requestId = requestId
+	return &api
+}
+
+func (api *Api) logger() *slog.Logger {
+	if api.log == nil {
+		return logger
+	}
+	return api.log
+}
+
+// Scan log lines always carry terminal and barcode
+func scanLogger(log *slog.Logger, term Terminal, barcode string, direction string) *slog.Logger {
+	return log.With("terminal_id", term.Id, "terminal", term.Name, "barcode", barcode, "direction", direction)
+}
+
+func withRequestId(req *http.Request) *http.Request {
+	id := req.Header.Get(REQUEST_ID_HEADER)
+	if id == "" || len(id) > 64 {
+		id = newRequestId()
+	}
+	return req.WithContext(context.WithValue(req.Context(), requestIdKey{}, id))
+}
@@ -0,0 +1,151 @@
+package lib
+
+import (
+	"crypto/subtle"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const METRICS_NAMESPACE = "go_backend"
+
+// Bearer token for /metrics, metrics are closed if not set
+const METRICS_TOKEN_ENV = "METRICS_TOKEN"
+
+const MAINTENANCE_RESULT_OK = "ok"
+const MAINTENANCE_RESULT_ERROR = "error"
+const MAINTENANCE_RESULT_PANIC = "panic"
+
+// Direction label of scans which only check ticket
+const SCAN_DIRECTION_CHECK = "check"
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE, Name: "http_requests_total",
+		Help: "HTTP requests by route name, method and status code.",
+	}, []string{"route", "method", "code"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE, Name: "http_request_duration_seconds",
+		Help: "HTTP request latency by route name.",
+	}, []string{"route"})
+	repositoryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE, Name: "repository_duration_seconds",
+		Help:    "Repository call latency by method.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"method"})
+	syncDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE, Name: "sync_duration_seconds",
+		Help:    "Event sync with api duration by result.",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"result"})
+	syncTickets = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE, Name: "sync_tickets",
+		Help:    "Tickets received from api per event sync.",
+		Buckets: prometheus.ExponentialBuckets(10, 4, 7),
+	})
+	syncChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE, Name: "sync_ticket_changes_total",
+		Help: "Tickets added, removed and changed by syncs.",
+	}, []string{"change"})
+	maintenanceRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE, Name: "maintenance_runs_total",
+		Help: "Maintenance job runs by job and result.",
+	}, []string{"job", "result"})
+	maintenanceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE, Name: "maintenance_duration_seconds",
+		Help:    "Maintenance job duration.",
+		Buckets: []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300},
+	}, []string{"job"})
+	scans = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE, Name: "scans_total",
+		Help: "Ticket scans by result code, terminal and direction.",
+	}, []string{"code", "terminal", "direction"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequests, httpDuration, repositoryDuration, syncDuration, syncTickets, syncChanges,
+		maintenanceRuns, maintenanceDuration, scans)
+}
+
+// Usage: defer observeRepository("Method", time.Now())
+func observeRepository(method string, start time.Time) {
+	repositoryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func observeSync(start time.Time, result string, run *SyncRun, tickets int) {
+	syncDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	if run == nil {
+		return
+	}
+	syncTickets.Observe(float64(tickets))
+	syncChanges.WithLabelValues("added").Add(float64(len(run.Added)))
+	syncChanges.WithLabelValues("removed").Add(float64(len(run.Removed)))
+	syncChanges.WithLabelValues("changed").Add(float64(len(run.Changed)))
+}
+
+func observeScan(code int64, terminal string, direction string) {
+	scans.WithLabelValues(strconv.FormatInt(code, 10), terminal, direction).Inc()
+}
+
+// Run maintenance job, panic is logged and counted instead of killing the server
+func maintenanceJob(job string, run func() *Exception) {
+	start := time.Now()
+	result := MAINTENANCE_RESULT_OK
+	defer func() {
+		if err := recover(); err != nil {
+			logger.Error("Maintenance job panic", "job", job, "panic", err)
+			result = MAINTENANCE_RESULT_PANIC
+		}
+		maintenanceRuns.WithLabelValues(job, result).Inc()
+		maintenanceDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	}()
+	if ex := run(); ex != nil {
+		logger.Error("Maintenance job failed", "job", job, "message", ex.Message, "error", ex.Error)
+		result = MAINTENANCE_RESULT_ERROR
+	}
+}
+
+// Keeps status code for metrics, streams still need Flush
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func instrumentRoute(route Route, next http.Handler) http.Handler {
+	method := strings.ToUpper(route.Method)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		writer := &statusWriter{w, http.StatusOK}
+		next.ServeHTTP(writer, r)
+		httpRequests.WithLabelValues(route.Name, method, strconv.Itoa(writer.status)).Inc()
+		httpDuration.WithLabelValues(route.Name).Observe(time.Since(start).Seconds())
+	})
+}
+
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	//closed until token is configured, as every route without permission
+	token := os.Getenv(METRICS_TOKEN_ENV)
+	if token == "" {
+		respondWithJson(w, http.StatusForbidden, Exception{FORBIDDEN_EXEPTION, METRICS_TOKEN_ENV + " is not set"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+		respondWithJson(w, http.StatusUnauthorized, Exception{Message: "Not Authorized"})
+		return
+	}
+	promhttp.Handler().ServeHTTP(w, r)
+}
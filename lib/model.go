@@ -1,9 +1,13 @@
 package lib
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"gopkg.in/mgo.v2/bson"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -37,9 +41,29 @@ func (r *MasterKeys) add(barode string) {
 }
 
 type User struct {
-	Login    string `bson:"login" schema:"login,required"`
-	Password string `bson:"password" schema:"password,required"`
-	Active   bool   `bson:"active" schema:"-"`
+	Login    string `json:"login" bson:"login" schema:"login,required"`
+	Password string `json:"-" bson:"password" schema:"password,required"`
+	Active   bool   `json:"active" bson:"active" schema:"-"`
+	Role     string `json:"role" bson:"role" schema:"role"`
+	// Tokens with other version are revoked
+	TokenVersion int `json:"-" bson:"token_version" schema:"-"`
+}
+
+// Change of role or activity, empty fields are kept
+type UserForm struct {
+	Login  string `schema:"login,required"`
+	Role   string `schema:"role"`
+	Active *bool  `schema:"active"`
+}
+type InitForm struct {
+	Token    string `schema:"token,required"`
+	Login    string `schema:"login,required"`
+	Password string `schema:"password,required"`
+}
+type PasswordForm struct {
+	Login       string `schema:"login"`
+	OldPassword string `schema:"old_password"`
+	Password    string `schema:"password,required"`
 }
 type AuthStruct struct {
 	Auth struct {
@@ -81,8 +105,13 @@ type Request struct {
 	Sign string `schema:"sign,required"`
 }
 type TimeRange struct {
-	From string `schema:"from,required"`
-	To   string `schema:"to,required"`
+	From     string `schema:"from,required"`
+	To       string `schema:"to,required"`
+	Timezone string `schema:"tz"`
+	GroupId  int64  `schema:"group"`
+	Format   string `schema:"format"`
+	Page     int    `schema:"page"`
+	Limit    int    `schema:"limit"`
 }
 type CheckTiket struct {
 	Barcode string `json:"barcode" bson:"barcode"`
@@ -100,8 +129,25 @@ type SqlQuery struct {
 	Query     string `schema:"query,required"`
 }
 type JwtToken struct {
-	Token   string `json:"-"`
-	Expires int64  `json:"exp"`
+	Token          string `json:"-"`
+	Expires        int64  `json:"exp"`
+	RefreshExpires int64  `json:"refresh_exp,omitempty"`
+}
+
+// Login session, access tokens carry its id and are refused once it is revoked or expired
+type Session struct {
+	Id          string    `json:"id" bson:"_id"`
+	Login       string    `json:"login" bson:"login"`
+	Created     int64     `json:"created" bson:"created"`
+	LastRefresh int64     `json:"last_refresh" bson:"last_refresh"`
+	Expires     time.Time `json:"expires" bson:"expires"`
+	Ip          string    `json:"ip" bson:"ip"`
+	UserAgent   string    `json:"user_agent" bson:"user_agent"`
+	Revoked     int64     `json:"revoked,omitempty" bson:"revoked,omitempty"`
+	Current     bool      `json:"current,omitempty" bson:"-"`
+	//sha256 of current and previous refresh token, previous one used again means token was stolen
+	RefreshHash  string `json:"-" bson:"refresh_hash"`
+	PreviousHash string `json:"-" bson:"previous_hash,omitempty"`
 }
 type Terminal struct {
 	Name   string  `json:"name" bson:"name" form:"name"`
@@ -121,31 +167,104 @@ type Ticket struct {
 	TicketDt      int64  `json:"dt,omitempty" bson:"ticket_dt"`
 }
 type Event struct {
-	Id            int64  `json:"id,omitempty" bson:"event_id"`
-	Title         string `json:"title,omitempty" bson:"show_title"`
-	EventDT       int64  `json:"dt,omitempty" bson:"event_dt"`
-	VenueId       int64  `json:"venue_id,omitempty" bson:"venue_id"`
-	VenueTitle    string `json:"venue_title,omitempty" bson:"venue_title"`
-	HallId        int64  `json:"hall_id,omitempty" bson:"hall_id"`
-	Hall          string `json:"hall,omitempty" bson:"hall_title"`
-	LastUpdate    int64  `json:"last_update" bson:"last_update"`
-	TicketsCached int    `json:"tickets_cached" bson:"-"`
+	Id            int64         `json:"id,omitempty" bson:"event_id"`
+	Title         string        `json:"title,omitempty" bson:"show_title"`
+	EventDT       int64         `json:"dt,omitempty" bson:"event_dt"`
+	VenueId       int64         `json:"venue_id,omitempty" bson:"venue_id"`
+	VenueTitle    string        `json:"venue_title,omitempty" bson:"venue_title"`
+	HallId        int64         `json:"hall_id,omitempty" bson:"hall_id"`
+	Hall          string        `json:"hall,omitempty" bson:"hall_title"`
+	ShowId        int64         `json:"show_id,omitempty" bson:"show_id"`
+	Status        string        `json:"status,omitempty" bson:"status"`
+	StatusManual  bool          `json:"status_manual,omitempty" bson:"status_manual"`
+	OriginalDT    int64         `json:"original_dt,omitempty" bson:"original_dt"`
+	Timezone      string        `json:"timezone,omitempty" bson:"timezone"`
+	Vacancies     *int64        `json:"vacancies,omitempty" bson:"vacancies,omitempty"`
+	DtISO         string        `json:"dt_iso,omitempty" bson:"-"`
+	DoorsOpenISO  string        `json:"doors_open_iso,omitempty" bson:"-"`
+	LastUpdate    int64         `json:"last_update" bson:"last_update"`
+	TicketsCached int           `json:"tickets_cached" bson:"-"`
+	LastSync      *SyncRunStats `json:"last_sync,omitempty" bson:"-"`
 }
+
+const EVENT_STATUS_ACTIVE = "active"
+const EVENT_STATUS_CANCELLED = "cancelled"
+const EVENT_STATUS_POSTPONED = "postponed"
+const EVENT_STATUS_RESCHEDULED = "rescheduled"
+
+// Reset manual status, next sync takes status from api
+const EVENT_STATUS_AUTO = "auto"
+
+// Merge status reported by api with cached event: manual status wins, date change means reschedule
+func (r *Event) ApplyLifecycle(cached Event, status string) {
+	if status == "" {
+		status = EVENT_STATUS_ACTIVE
+	}
+	if cached.Id == 0 {
+		r.Status = status
+		return
+	}
+	r.OriginalDT = cached.OriginalDT
+	if cached.StatusManual {
+		r.Status, r.StatusManual = cached.Status, true
+		if cached.Status == EVENT_STATUS_RESCHEDULED {
+			r.EventDT = cached.EventDT
+		}
+		return
+	}
+	if r.OriginalDT == 0 && cached.EventDT != 0 && cached.EventDT != r.EventDT {
+		r.OriginalDT = cached.EventDT
+	}
+	if r.OriginalDT == r.EventDT {
+		r.OriginalDT = 0
+	}
+	r.Status = status
+	if status == EVENT_STATUS_ACTIVE && r.OriginalDT != 0 {
+		r.Status = EVENT_STATUS_RESCHEDULED
+	}
+}
+
+// Fill local time fields in venue timezone
+func (r *Event) localize() {
+	loc := LoadLocation(r.Timezone)
+	r.DtISO = IsoTime(r.EventDT, loc)
+	if r.EventDT != 0 {
+		r.DoorsOpenISO = IsoTime(r.EventDT-OPENBEFORE, loc)
+	}
+}
+
+// Result code for tickets of event which can`t be admitted at all
+func (r *Event) RejectCode() (int64, bool) {
+	switch r.Status {
+	case EVENT_STATUS_CANCELLED:
+		return ENTRY_RESULT_CODE_CANCELLED, true
+	case EVENT_STATUS_POSTPONED:
+		return ENTRY_RESULT_CODE_POSTPONED, true
+	}
+	return 0, false
+}
+
+type EventStatusForm struct {
+	Status string `schema:"status,required"`
+	Dt     int64  `schema:"dt"`
+}
+
 type EventStats struct {
 	Id      int64       `json:"id,omitempty"`
 	EventId int64       `json:"event_id,omitempty"`
 	Dt      int64       `json:"dt,omitempty"`
+	DtISO   string      `json:"dt_iso,omitempty"`
 	Title   string      `json:"title,omitempty"`
 	Sell    int64       `json:"sell,omitempty"`
 	Entry   int64       `json:"entry,omitempty"`
-	Total   int64       `json:"total,omitempty"`
+	Total   Money       `json:"total,omitempty"`
 	Info    []PriceLine `json:"info"`
 }
 type PriceLine struct {
-	Price string `json:"id"`
-	Sell  int64  `json:"sell"`
-	Total int64  `json:"total"`
-	Entry int64  `json:"entry"`
+	Price string `json:"id" bson:"price"`
+	Sell  int64  `json:"sell" bson:"sell"`
+	Total Money  `json:"total" bson:"total"`
+	Entry int64  `json:"entry" bson:"entry"`
 }
 
 type EventInfo struct {
@@ -160,8 +279,8 @@ func (r *EventInfo) fromPriceMap(tickets []bson.M, entrys []bson.M) {
 		var priceLine PriceLine
 		priceLine.Price = fmt.Sprintf("%v", ticket["_id"])
 		priceLine.Sell = int64(ticket["count"].(int))
-		price, _ := strconv.ParseFloat(priceLine.Price, 64)
-		priceLine.Total = int64(price) * priceLine.Sell
+		price, _ := ParseMoney(priceLine.Price)
+		priceLine.Total = price.Mul(priceLine.Sell)
 		priceLine.Entry = 0
 		for _, entry := range entrys {
 			if entry["_id"] == ticket["_id"] {
@@ -187,8 +306,8 @@ func (r *EventInfo) Entrys() int64 {
 	}
 	return c
 }
-func (r *EventInfo) Total() int64 {
-	var c int64
+func (r *EventInfo) Total() Money {
+	var c Money
 	c = 0
 	for _, line := range r.Info {
 		c += line.Total
@@ -196,6 +315,258 @@ func (r *EventInfo) Total() int64 {
 	return c
 }
 
+type EventLiveStats struct {
+	EventId   int64       `json:"event_id"`
+	Dt        int64       `json:"dt"`
+	Sell      int64       `json:"sell"`
+	Entry     int64       `json:"entry"`
+	Inside    int64       `json:"inside"`
+	Occupancy float64     `json:"occupancy"`
+	Info      []PriceLine `json:"info"`
+}
+type ScanResult struct {
+	EventId      int64  `json:"event_id"`
+	Barcode      string `json:"barcode"`
+	TerminalId   int64  `json:"terminal_id"`
+	TerminalName string `json:"terminal_name"`
+	Direction    string `json:"direction"`
+	Code         int64  `json:"code"`
+	Dt           int64  `json:"dt"`
+	Sector       string `json:"sector"`
+	Title        string `json:"title"`
+	Price        string `json:"price"`
+}
+
+type TimelineBucket struct {
+	Dt    int64  `json:"dt"`
+	DtISO string `json:"dt_iso"`
+	Entry int64  `json:"entry"`
+	Exit  int64  `json:"exit"`
+}
+
+const COMPARE_BY_BUILDING = "building"
+const COMPARE_BY_HALL = "hall"
+const COMPARE_BY_SHOW = "show"
+
+type CompareForm struct {
+	From     string `schema:"from,required"`
+	To       string `schema:"to,required"`
+	By       string `schema:"by"`
+	Timezone string `schema:"tz"`
+	GroupId  int64  `schema:"group"`
+	Format   string `schema:"format"`
+}
+
+// Events of one building, hall or show summed up. Capacity is known only for events
+// with vacancies from api, sell-through counts only those events
+type ComparisonLine struct {
+	Key          string  `json:"key"`
+	Title        string  `json:"title"`
+	Events       int64   `json:"events"`
+	Sell         int64   `json:"sell"`
+	Entry        int64   `json:"entry"`
+	Total        Money   `json:"total"`
+	Capacity     int64   `json:"capacity"`
+	SellThrough  float64 `json:"sell_through"`
+	Attendance   float64 `json:"attendance"`
+	capacitySell int64
+}
+type Comparison struct {
+	By    string           `json:"by"`
+	From  string           `json:"from"`
+	To    string           `json:"to"`
+	Lines []ComparisonLine `json:"lines"`
+}
+
+type TimelineSeries struct {
+	Id      int64            `json:"id"`
+	Name    string           `json:"name"`
+	Entry   int64            `json:"entry"`
+	Exit    int64            `json:"exit"`
+	Buckets []TimelineBucket `json:"buckets"`
+}
+type EventTimeline struct {
+	EventId             int64            `json:"event_id"`
+	Interval            int64            `json:"interval"`
+	DoorsOpen           int64            `json:"doors_open"`
+	DoorsOpenISO        string           `json:"doors_open_iso"`
+	Total               TimelineSeries   `json:"total"`
+	Terminals           []TimelineSeries `json:"terminals"`
+	Groups              []TimelineSeries `json:"groups"`
+	PeakMinute          TimelineBucket   `json:"peak_minute"`
+	FirstEntries        int64            `json:"first_entries"`
+	MedianFromDoorsOpen int64            `json:"median_from_doors_open"`
+}
+
+// Filter for no-show report, empty fields match everything
+type NoShowFilter struct {
+	Sector  string `schema:"sector"`
+	Price   string `schema:"price"`
+	Channel string `schema:"channel"`
+	Source  string `schema:"source"`
+	Format  string `schema:"format"`
+}
+
+// Sold ticket without accepted entry
+type NoShow struct {
+	Barcode  string `json:"barcode"`
+	Sector   string `json:"sector"`
+	Title    string `json:"title"`
+	Price    Money  `json:"price"`
+	OrderId  int    `json:"order_id"`
+	Customer string `json:"customer_title"`
+	Channel  string `json:"channel"`
+	Source   string `json:"source,omitempty"`
+	Dt       int    `json:"dt"`
+}
+type EventNoShows struct {
+	EventId int64    `json:"event_id"`
+	Sell    int      `json:"sell"`
+	Count   int      `json:"count"`
+	Total   Money    `json:"total"`
+	Tickets []NoShow `json:"tickets"`
+}
+
+// Sale channel of ticket: e-ticket, cashbox title or import source
+func saleChannel(ticket TicketExport) string {
+	switch {
+	case strings.HasPrefix(ticket.Source, IMPORT_SOURCE_PREFIX):
+		return ticket.Source
+	case ticket.IsEticket:
+		return "e-ticket"
+	}
+	return ticket.CashboxTitle
+}
+
+func (r *NoShowFilter) matches(ticket NoShow) bool {
+	if r.Channel != "" && !strings.EqualFold(r.Channel, ticket.Channel) {
+		return false
+	}
+	if r.Price != "" {
+		price, err := ParseMoney(r.Price)
+		if err != nil || price != ticket.Price {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *EventNoShows) fromTickets(tickets []TicketExport, entered map[string]bool, filter NoShowFilter) {
+	r.Tickets = []NoShow{}
+	r.Sell = len(tickets)
+	for _, ticket := range tickets {
+		if entered[ticket.TicketBarcode] {
+			continue
+		}
+		price, _ := ParseMoney(ticket.TicketPrice)
+		noShow := NoShow{ticket.TicketBarcode, ticket.TicketSector, ticket.TicketTitle, price, ticket.OrderID, ticket.CustomerTitle, saleChannel(ticket), ticket.Source, ticket.TicketDt}
+		if !filter.matches(noShow) {
+			continue
+		}
+		r.Tickets = append(r.Tickets, noShow)
+		r.Total += price
+	}
+	r.Count = len(r.Tickets)
+}
+
+// Sales breakdown dimensions
+const BREAKDOWN_PRICE = "price"
+const BREAKDOWN_SECTOR = "sector"
+const BREAKDOWN_CASHBOX = "cashbox"
+const BREAKDOWN_OPERATOR = "operator"
+const BREAKDOWN_ETICKET = "eticket"
+
+type BreakdownLine struct {
+	Key        string `json:"key"`
+	Sell       int64  `json:"sell"`
+	SellTotal  Money  `json:"sell_total"`
+	Entry      int64  `json:"entry"`
+	EntryTotal Money  `json:"entry_total"`
+}
+type Breakdown struct {
+	Dimension string          `json:"dimension"`
+	Lines     []BreakdownLine `json:"lines"`
+}
+type EventBreakdown struct {
+	EventId    int64       `json:"event_id"`
+	Breakdowns []Breakdown `json:"breakdowns"`
+}
+
+// Row of tickets aggregation grouped by dimension key and price
+type breakdownRow struct {
+	Id struct {
+		Key   interface{} `bson:"key"`
+		Price string      `bson:"price"`
+	} `bson:"_id"`
+	Count int64 `bson:"count"`
+}
+
+func breakdownKey(dimension string, key interface{}) string {
+	if dimension == BREAKDOWN_ETICKET {
+		if key == true {
+			return "e-ticket"
+		}
+		return "paper"
+	}
+	if key == nil || key == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%v", key)
+}
+
+func (r *Breakdown) fromRows(tickets []breakdownRow, entries []breakdownRow) {
+	lines := map[string]*BreakdownLine{}
+	keys := []string{}
+	line := func(key interface{}) *BreakdownLine {
+		k := breakdownKey(r.Dimension, key)
+		if _, ok := lines[k]; !ok {
+			lines[k] = &BreakdownLine{Key: k}
+			keys = append(keys, k)
+		}
+		return lines[k]
+	}
+	for _, row := range tickets {
+		price, _ := ParseMoney(row.Id.Price)
+		l := line(row.Id.Key)
+		l.Sell += row.Count
+		l.SellTotal += price.Mul(row.Count)
+	}
+	for _, row := range entries {
+		price, _ := ParseMoney(row.Id.Price)
+		l := line(row.Id.Key)
+		l.Entry += row.Count
+		l.EntryTotal += price.Mul(row.Count)
+	}
+	sort.Strings(keys)
+	r.Lines = []BreakdownLine{}
+	for _, k := range keys {
+		r.Lines = append(r.Lines, *lines[k])
+	}
+}
+
+// Precomputed event stats, kept up to date on sync and entry
+type EventRollup struct {
+	EventId int64       `json:"event_id" bson:"event_id"`
+	Sell    int64       `json:"sell" bson:"sell"`
+	Entry   int64       `json:"entry" bson:"entry"`
+	Inside  int64       `json:"inside" bson:"inside"`
+	Total   Money       `json:"total" bson:"total"`
+	Info    []PriceLine `json:"info" bson:"info"`
+	Updated int64       `json:"updated" bson:"updated"`
+}
+
+func (r *EventRollup) fromEventInfo(id int64, info EventInfo) {
+	r.EventId = id
+	r.Sell = info.Tickets()
+	r.Entry = info.Entrys()
+	r.Total = info.Total()
+	r.Info = info.Info
+	if r.Info == nil {
+		r.Info = []PriceLine{}
+	}
+	r.Updated = time.Now().Unix()
+}
+
 type Tickets struct {
 	Tickets int64 `json:"tickets" bson:"tickets"`
 }
@@ -275,6 +646,11 @@ func (r *Events) EventsIds() []int64 {
 	}
 	return ids
 }
+func (r *Events) localize() {
+	for i := range r.Events {
+		r.Events[i].localize()
+	}
+}
 func (r *Events) EventById(eventid int64) Event {
 	for i := range r.Events {
 		if r.Events[i].Id == eventid {
@@ -284,6 +660,189 @@ func (r *Events) EventById(eventid int64) Event {
 	return Event{}
 }
 
+const SYNC_ORIGIN_API = "sync"
+const SYNC_ORIGIN_WEBHOOK = "webhook"
+
+type SyncRun struct {
+	Id      int64    `json:"id" bson:"id"`
+	EventId int64    `json:"event_id" bson:"event_id"`
+	Dt      int64    `json:"dt" bson:"dt"`
+	Origin  string   `json:"origin" bson:"origin"`
+	Tickets int      `json:"tickets" bson:"tickets"`
+	Added   []string `json:"added" bson:"added"`
+	Removed []string `json:"removed" bson:"removed"`
+	Changed []string `json:"changed" bson:"changed"`
+}
+type SyncRunStats struct {
+	Id      int64  `json:"id" bson:"id"`
+	Dt      int64  `json:"dt" bson:"dt"`
+	Origin  string `json:"origin" bson:"origin"`
+	Tickets int    `json:"tickets" bson:"tickets"`
+	Added   int    `json:"added" bson:"added"`
+	Removed int    `json:"removed" bson:"removed"`
+	Changed int    `json:"changed" bson:"changed"`
+}
+type SyncHistory struct {
+	EventId int64          `json:"event_id"`
+	Runs    []SyncRunStats `json:"runs"`
+}
+type SyncDiff struct {
+	EventId int64    `json:"event_id"`
+	From    int64    `json:"from"`
+	To      int64    `json:"to"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed"`
+}
+
+func ticketChanged(a TicketExport, b TicketExport) bool {
+	return a.TicketID != b.TicketID || a.TicketPrice != b.TicketPrice || a.TicketSector != b.TicketSector ||
+		a.TicketTitle != b.TicketTitle || a.PlaceID != b.PlaceID
+}
+
+// Compare cached tickets with fresh ones from api, by barcode
+func (r *SyncRun) FromTickets(cached []TicketExport, fresh []TicketExport) {
+	r.Added, r.Removed, r.Changed = []string{}, []string{}, []string{}
+	old := map[string]TicketExport{}
+	for _, ticket := range cached {
+		old[ticket.TicketBarcode] = ticket
+	}
+	for _, ticket := range fresh {
+		oldTicket, ok := old[ticket.TicketBarcode]
+		if !ok {
+			r.Added = append(r.Added, ticket.TicketBarcode)
+			continue
+		}
+		if ticketChanged(oldTicket, ticket) {
+			r.Changed = append(r.Changed, ticket.TicketBarcode)
+		}
+		delete(old, ticket.TicketBarcode)
+	}
+	for _, ticket := range cached {
+		if _, ok := old[ticket.TicketBarcode]; ok {
+			r.Removed = append(r.Removed, ticket.TicketBarcode)
+		}
+	}
+	r.Tickets = len(fresh)
+}
+func (r *SyncRun) toStats() SyncRunStats {
+	return SyncRunStats{r.Id, r.Dt, r.Origin, r.Tickets, len(r.Added), len(r.Removed), len(r.Changed)}
+}
+
+// Replay runs (ordered by id) that happened after "from" run up to "to" run
+func (r *SyncDiff) FromRuns(runs []SyncRun) {
+	type state struct {
+		before bool
+		after  bool
+	}
+	states := map[string]*state{}
+	order := []string{}
+	touch := func(barcode string, before bool, after bool) {
+		s, ok := states[barcode]
+		if !ok {
+			s = &state{before: before}
+			states[barcode] = s
+			order = append(order, barcode)
+		}
+		s.after = after
+	}
+	for _, run := range runs {
+		for _, barcode := range run.Added {
+			touch(barcode, false, true)
+		}
+		for _, barcode := range run.Removed {
+			touch(barcode, true, false)
+		}
+		for _, barcode := range run.Changed {
+			touch(barcode, true, true)
+		}
+	}
+	r.Added, r.Removed, r.Changed = []string{}, []string{}, []string{}
+	for _, barcode := range order {
+		s := states[barcode]
+		switch {
+		case !s.before && s.after:
+			r.Added = append(r.Added, barcode)
+		case s.before && !s.after:
+			r.Removed = append(r.Removed, barcode)
+		case s.before && s.after:
+			r.Changed = append(r.Changed, barcode)
+		}
+	}
+}
+
+const WEBHOOK_ACTION_SALE = "sale"
+const WEBHOOK_ACTION_REFUND = "refund"
+const WEBHOOK_ACTION_REISSUE = "reissue"
+
+// Seconds a signed webhook is accepted after its dt, older ones are treated as replayed
+const WEBHOOK_MAX_AGE = 300
+
+// Sign key for incoming webhooks, not shared with api key
+const WEBHOOK_SECRET_KEY_ENV = "WEBHOOK_SECRET_KEY"
+
+type TicketWebhookRequest struct {
+	Data string `schema:"data,required"`
+	Sign string `schema:"sign,required"`
+}
+type TicketWebhook struct {
+	Action     string       `json:"action"`
+	Dt         int64        `json:"dt"`
+	EventId    int64        `json:"event_id"`
+	OldBarcode string       `json:"old_barcode"`
+	Ticket     TicketExport `json:"ticket"`
+}
+
+func (hook TicketWebhook) Fresh(now time.Time) bool {
+	age := now.Unix() - hook.Dt
+	return hook.Dt > 0 && age <= WEBHOOK_MAX_AGE && age >= -WEBHOOK_MAX_AGE
+}
+
+// Barcodes webhook touches, reissue touches old one too
+func (hook TicketWebhook) Barcodes() []string {
+	barcodes := []string{hook.Ticket.TicketBarcode}
+	if hook.OldBarcode != "" && hook.OldBarcode != hook.Ticket.TicketBarcode {
+		barcodes = append(barcodes, hook.OldBarcode)
+	}
+	return barcodes
+}
+func (request TicketWebhookRequest) Hash() string {
+	hash := sha256.Sum256([]byte(request.Data + request.Sign))
+	return hex.EncodeToString(hash[:])
+}
+
+// Applied webhook, kept while a replay of it could still be fresh
+type WebhookRecord struct {
+	Id       string    `bson:"_id"`
+	EventId  int64     `bson:"event_id"`
+	Barcodes []string  `bson:"barcodes"`
+	Dt       int64     `bson:"dt"`
+	Created  time.Time `bson:"created"`
+}
+
+type TicketImportForm struct {
+	Source  string `schema:"source"`
+	Format  string `schema:"format"`
+	DryRun  bool   `schema:"dry_run"`
+	Title   string `schema:"title"`
+	Dt      int64  `schema:"dt"`
+	VenueId int64  `schema:"venue_id"`
+	HallId  int64  `schema:"hall_id"`
+}
+type TicketImportError struct {
+	Row     int    `json:"row"`
+	Barcode string `json:"barcode,omitempty"`
+	Message string `json:"message"`
+}
+type TicketImport struct {
+	EventId  int64               `json:"event_id"`
+	Source   string              `json:"source"`
+	DryRun   bool                `json:"dry_run"`
+	Rows     int                 `json:"rows"`
+	Imported int                 `json:"imported"`
+	Errors   []TicketImportError `json:"errors"`
+}
+
 type Entry struct {
 	EventId       int64  `json:"event_id" bson:"event_id"`
 	TicketBarcode string `json:"ticket_barcode" bson:"ticket_barcode"`
@@ -304,12 +863,132 @@ type Log struct {
 	Code    int64  `json:"code" bson:"code"`
 }
 
+// Report of group events sent daily or weekly at hour of group timezone
+type ReportSchedule struct {
+	Id          int64    `json:"id" bson:"id" schema:"id"`
+	GroupId     int64    `json:"group_id" bson:"group_id" schema:"group_id,required"`
+	Period      string   `json:"period" bson:"period" schema:"period"`
+	Hour        int      `json:"hour" bson:"hour" schema:"hour"`
+	Weekday     int      `json:"weekday" bson:"weekday" schema:"weekday"`
+	Format      string   `json:"format" bson:"format" schema:"format"`
+	Recipients  []string `json:"recipients" bson:"recipients" schema:"recipients"`
+	LastRun     int64    `json:"last_run" bson:"last_run" schema:"-"`
+	LastAttempt int64    `json:"last_attempt" bson:"last_attempt" schema:"-"`
+	LastError   string   `json:"last_error,omitempty" bson:"last_error" schema:"-"`
+}
+
+// Log as stored, terminal is taken from "gate #N" of message
+type LogRecord struct {
+	Id         bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	Log        `bson:",inline"`
+	TerminalId int64     `json:"terminal_id,omitempty" bson:"terminal_id,omitempty"`
+	Created    time.Time `json:"-" bson:"created,omitempty"`
+	RequestId  string    `json:"request_id,omitempty" bson:"request_id,omitempty"`
+}
+
+// Entry as stored, created is a date for ttl index
+type EntryRecord struct {
+	Entry   `bson:",inline"`
+	Created time.Time `bson:"created"`
+}
+
+// Retention job run for one collection
+type RetentionRun struct {
+	Collection string  `json:"collection" bson:"collection"`
+	Mode       string  `json:"mode" bson:"mode"`
+	Dt         int64   `json:"dt" bson:"dt"`
+	Cutoff     int64   `json:"cutoff" bson:"cutoff"`
+	Archived   int     `json:"archived" bson:"archived"`
+	Removed    int     `json:"removed" bson:"removed"`
+	File       string  `json:"file,omitempty" bson:"file,omitempty"`
+	Duration   float64 `json:"duration" bson:"duration"`
+	Error      string  `json:"error,omitempty" bson:"error,omitempty"`
+}
+type RetentionStatus struct {
+	Policies []RetentionPolicy `json:"policies"`
+	Runs     []RetentionRun    `json:"runs"`
+}
+
+// Logs filter, newest first, pass cursor from X-Next-Cursor for next page
+type LogQuery struct {
+	From     int64  `schema:"from"`
+	To       int64  `schema:"to"`
+	Code     *int64 `schema:"code"`
+	Terminal int64  `schema:"terminal"`
+	Data     string `schema:"data"`
+	Message  string `schema:"message"`
+	Cursor   string `schema:"cursor"`
+	Limit    int    `schema:"limit"`
+}
+
+// Entries filter, newest first, pass cursor from X-Next-Cursor for next page
+type EntryQuery struct {
+	Event     int64  `schema:"event"`
+	Terminal  int64  `schema:"terminal"`
+	Direction string `schema:"direction"`
+	Code      *int64 `schema:"code"`
+	Barcode   string `schema:"barcode"`
+	From      int64  `schema:"from"`
+	To        int64  `schema:"to"`
+	Cursor    string `schema:"cursor"`
+	Limit     int    `schema:"limit"`
+}
+
+// Entry with terminal, event and ticket joined
+type EntryHistory struct {
+	Id           bson.ObjectId `json:"id" bson:"_id"`
+	Entry        `bson:",inline"`
+	DtISO        string `json:"dt_iso,omitempty" bson:"-"`
+	TerminalName string `json:"terminal_name,omitempty" bson:"-"`
+	EventTitle   string `json:"event_title,omitempty" bson:"-"`
+	TicketSector string `json:"ticket_sector,omitempty" bson:"-"`
+	TicketTitle  string `json:"ticket_title,omitempty" bson:"-"`
+	TicketPrice  string `json:"ticket_price,omitempty" bson:"-"`
+}
+
+// Cursor is dt and id of last record on page
+func pageCursor(dt int64, id bson.ObjectId) string {
+	return strconv.FormatInt(dt, 10) + "_" + id.Hex()
+}
+func parsePageCursor(cursor string) (int64, bson.ObjectId, bool) {
+	parts := strings.SplitN(cursor, "_", 2)
+	if len(parts) != 2 || !bson.IsObjectIdHex(parts[1]) {
+		return 0, "", false
+	}
+	dt, err := strconv.ParseInt(parts[0], 10, 64)
+	return dt, bson.ObjectIdHex(parts[1]), err == nil
+}
+
+// Who changed what from admin api, before and after are stored without secrets
+type AuditRecord struct {
+	Id        bson.ObjectId `json:"id" bson:"_id,omitempty"`
+	Dt        int64         `json:"dt" bson:"dt"`
+	Username  string        `json:"username" bson:"username"`
+	Ip        string        `json:"ip" bson:"ip"`
+	Action    string        `json:"action" bson:"action"`
+	Target    string        `json:"target" bson:"target"`
+	Before    interface{}   `json:"before,omitempty" bson:"before,omitempty"`
+	After     interface{}   `json:"after,omitempty" bson:"after,omitempty"`
+	Error     string        `json:"error,omitempty" bson:"error,omitempty"`
+	RequestId string        `json:"request_id,omitempty" bson:"request_id,omitempty"`
+}
+type AuditQuery struct {
+	From     int64  `schema:"from"`
+	To       int64  `schema:"to"`
+	Username string `schema:"username"`
+	Action   string `schema:"action"`
+	Target   string `schema:"target"`
+	Cursor   string `schema:"cursor"`
+	Limit    int    `schema:"limit"`
+}
+
 type Group struct {
 	Id              int64   `bson:"id" json:"id" schema:"id"`
 	Name            string  `bson:"name" json:"name" schema:"name,required"`
 	BuildingId      int64   `bson:"building_id" json:"building_id" schema:"building_id"`
 	BuildingName    string  `bson:"building_name" json:"building_name" schema:"building_name"`
 	BuildingAddress string  `bson:"building_address" json:"building_address" schema:"building_address"`
+	Timezone        string  `bson:"timezone" json:"timezone" schema:"timezone"`
 	Exclude_halls   []int64 `json:"-" bson:"exclude_halls" schema:"-"`
 }
 type Action struct {
@@ -0,0 +1,74 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Amount in kopecks, prices come from api as decimal strings
+type Money int64
+
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(strings.Replace(s, ",", ".", 1))
+	if s == "" {
+		return 0, nil
+	}
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimLeft(s, "+-")
+	parts := strings.SplitN(s, ".", 2)
+	if parts[0] == "" {
+		parts[0] = "0"
+	}
+	units, err := strconv.ParseUint(parts[0], 10, 63)
+	if err != nil {
+		return 0, fmt.Errorf("bad money value %q", s)
+	}
+	var kopecks uint64
+	if len(parts) == 2 {
+		fraction := parts[1] + "000"
+		for _, c := range fraction {
+			if c < '0' || c > '9' {
+				return 0, fmt.Errorf("bad money value %q", s)
+			}
+		}
+		kopecks, _ = strconv.ParseUint(fraction[:2], 10, 64)
+		//round half up
+		if fraction[2] >= '5' {
+			kopecks++
+		}
+	}
+	money := Money(units*100 + kopecks)
+	if negative {
+		money = -money
+	}
+	return money, nil
+}
+
+func (m Money) Mul(n int64) Money {
+	return m * Money(n)
+}
+func (m Money) Float() float64 {
+	return float64(m) / 100
+}
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+		m = -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/100, m%100)
+}
+
+// Json number with two decimals, no float rounding on the way
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+func (m *Money) UnmarshalJSON(data []byte) error {
+	money, err := ParseMoney(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
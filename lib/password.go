@@ -0,0 +1,84 @@
+package lib
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"unicode"
+)
+
+const PASSWORD_BCRYPT_COST = 12
+const PASSWORD_MIN_LENGTH = 10
+
+// bcrypt uses only that many bytes
+const PASSWORD_MAX_LENGTH = 72
+
+// Compared when login is unknown so response time does not tell it
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), PASSWORD_BCRYPT_COST)
+
+// bcrypt with own salt in hash
+func HashPassword(pass string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(pass), PASSWORD_BCRYPT_COST)
+	return string(hash), err
+}
+
+// Hash of users stored before bcrypt, md5 with common salt
+func LegacyHashPassword(pass string) string {
+	hash := md5.New()
+	hash.Write([]byte(pass + SALT))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func isLegacyHash(hash string) bool {
+	return !strings.HasPrefix(hash, "$2")
+}
+
+// Second result is true for legacy hash which has to be replaced
+func VerifyPassword(hash string, pass string) (bool, bool) {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(pass))
+		return false, false
+	}
+	if isLegacyHash(hash) {
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(LegacyHashPassword(pass))) == 1, true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)) == nil, false
+}
+
+// Rules for new passwords, nil if password is strong enough
+func CheckPasswordStrength(pass string, login string) *Exception {
+	if len([]rune(pass)) < PASSWORD_MIN_LENGTH {
+		return &Exception{WEAK_PASSWORD_EXEPTION, "password must have at least 10 characters"}
+	}
+	if len(pass) > PASSWORD_MAX_LENGTH {
+		return &Exception{WEAK_PASSWORD_EXEPTION, "password must have at most 72 bytes"}
+	}
+	var upper, lower, digit, other bool
+	for _, char := range pass {
+		switch {
+		case unicode.IsUpper(char):
+			upper = true
+		case unicode.IsLower(char):
+			lower = true
+		case unicode.IsDigit(char):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	classes := 0
+	for _, has := range []bool{upper, lower, digit, other} {
+		if has {
+			classes++
+		}
+	}
+	if classes < 3 {
+		return &Exception{WEAK_PASSWORD_EXEPTION, "password must have 3 of: upper case, lower case, digits, other characters"}
+	}
+	if login != "" && strings.Contains(strings.ToLower(pass), strings.ToLower(login)) {
+		return &Exception{WEAK_PASSWORD_EXEPTION, "password must not contain login"}
+	}
+	return nil
+}
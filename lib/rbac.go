@@ -0,0 +1,132 @@
+package lib
+
+import (
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/context"
+	"net/http"
+	"time"
+)
+
+const ROLE_ADMIN = "admin"
+const ROLE_MANAGER = "manager"
+const ROLE_OPERATOR = "operator"
+const ROLE_VIEWER = "viewer"
+
+// Login made admin on start, to get first admin for users stored before roles
+const ADMIN_LOGIN_ENV = "ADMIN_LOGIN"
+
+// Bootstrap token /init needs to create first admin, /init is closed if not set
+const INIT_TOKEN_ENV = "INIT_TOKEN"
+
+// No token needed
+const PERMISSION_PUBLIC = "public"
+
+// No token needed, handler checks terminal sign, webhook sign, metrics token or refresh token
+const PERMISSION_SIGNED = "signed"
+
+// Any role
+const PERMISSION_AUTHENTICATED = "authenticated"
+
+const PERMISSION_STATS_READ = "stats:read"
+const PERMISSION_REPORTS_READ = "reports:read"
+const PERMISSION_TICKETS_CHECK = "tickets:check"
+const PERMISSION_LOGS_READ = "logs:read"
+const PERMISSION_EVENTS_SYNC = "events:sync"
+const PERMISSION_EVENTS_WRITE = "events:write"
+const PERMISSION_REPORTS_WRITE = "reports:write"
+const PERMISSION_GROUPS_WRITE = "groups:write"
+const PERMISSION_TERMINALS_READ = "terminals:read"
+const PERMISSION_TERMINALS_WRITE = "terminals:write"
+const PERMISSION_USERS_READ = "users:read"
+const PERMISSION_USERS_WRITE = "users:write"
+const PERMISSION_MASTERKEYS_WRITE = "masterkeys:write"
+
+// Audit, retention and sql
+const PERMISSION_SYSTEM = "system"
+
+// Every role has permissions of roles before it
+var roles = []string{ROLE_VIEWER, ROLE_OPERATOR, ROLE_MANAGER, ROLE_ADMIN}
+var rolePermissions = map[string][]string{
+	ROLE_VIEWER:   {PERMISSION_AUTHENTICATED, PERMISSION_STATS_READ, PERMISSION_REPORTS_READ},
+	ROLE_OPERATOR: {PERMISSION_TICKETS_CHECK, PERMISSION_LOGS_READ, PERMISSION_EVENTS_SYNC},
+	ROLE_MANAGER:  {PERMISSION_EVENTS_WRITE, PERMISSION_REPORTS_WRITE, PERMISSION_GROUPS_WRITE, PERMISSION_TERMINALS_READ},
+	ROLE_ADMIN:    {PERMISSION_TERMINALS_WRITE, PERMISSION_USERS_READ, PERMISSION_USERS_WRITE, PERMISSION_MASTERKEYS_WRITE, PERMISSION_SYSTEM},
+}
+
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Unknown roles and permissions are denied
+func RoleAllows(role string, permission string) bool {
+	if !IsRole(role) {
+		return false
+	}
+	for _, current := range roles {
+		for _, allowed := range rolePermissions[current] {
+			if allowed == permission {
+				return true
+			}
+		}
+		if current == role {
+			break
+		}
+	}
+	return false
+}
+
+func NewToken(user User, sessionId string, expires time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sid":      sessionId,
+		"username": user.Login,
+		"role":     user.Role,
+		"admin":    user.Role == ROLE_ADMIN,
+		"ver":      user.TokenVersion,
+		"exp":      expires.Unix(),
+	})
+	return token.SignedString([]byte(GetSecretKey()))
+}
+
+// User and session of token if user is still active and session is not revoked
+func tokenUser(claims jwt.MapClaims) (User, Session, bool) {
+	username, _ := claims["username"].(string)
+	version, _ := claims["ver"].(float64)
+	sessionId, _ := claims["sid"].(string)
+	user := repository.GetUser(username)
+	if user.Login == "" || !user.Active || user.TokenVersion != int(version) || sessionId == "" {
+		return user, Session{}, false
+	}
+	session := repository.GetSession(sessionId)
+	return user, session, session.Login == user.Login && session.Active(time.Now())
+}
+
+// Role is taken from stored user, so role change works at once
+func requestRole(req *http.Request) string {
+	if user, ok := context.Get(req, "user").(User); ok {
+		return user.Role
+	}
+	claims, _ := context.Get(req, "decoded").(jwt.MapClaims)
+	role, _ := claims["role"].(string)
+	return role
+}
+
+// Token and role check for route permission, routes without known permission are closed
+func Authorize(route Route) http.HandlerFunc {
+	switch route.Permission {
+	case PERMISSION_PUBLIC, PERMISSION_SIGNED:
+		return route.HandlerFunc
+	}
+	if !RoleAllows(ROLE_ADMIN, route.Permission) {
+		logger.Warn("Route has no known permission and is closed", "route", route.Name, "permission", route.Permission)
+	}
+	return AuthenticationMiddleware(func(w http.ResponseWriter, req *http.Request) {
+		role := requestRole(req)
+		if !RoleAllows(role, route.Permission) {
+			requestLogger(req).Warn("Forbidden", "route", route.Name, "username", requestUser(req), "role", role, "permission", route.Permission)
+			respondWithJson(w, http.StatusForbidden, Exception{FORBIDDEN_EXEPTION, route.Permission})
+			return
+		}
+		route.HandlerFunc(w, req)
+	})
+}
@@ -0,0 +1,266 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const REPORT_PERIOD_DAILY = "daily"
+const REPORT_PERIOD_WEEKLY = "weekly"
+const REPORT_FORMAT_HTML = "html"
+const REPORT_FORMAT_CSV = "csv"
+
+// Local hour to send report at if not set
+const REPORT_DEFAULT_HOUR = 8
+
+// Directory to write reports to, files are not written if not set
+const REPORT_DIR_ENV = "REPORT_DIR"
+
+// SMTP server as host:port, reports are not mailed if not set
+const SMTP_ADDR_ENV = "SMTP_ADDR"
+const SMTP_USER_ENV = "SMTP_USER"
+const SMTP_PASSWORD_ENV = "SMTP_PASSWORD"
+const SMTP_FROM_ENV = "SMTP_FROM"
+
+// Dial and whole session limit, hung server must not hold reports forever
+const SMTP_TIMEOUT = 30 * time.Second
+
+// Failed report is tried again after this delay until it is delivered
+const REPORT_RETRY_DELAY = 10 * time.Minute
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"cell":   cellString,
+	"isText": func(cell interface{}) bool { _, ok := cell.(string); return ok },
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Subject}}</title>
+<style>body{font-family:sans-serif}table{border-collapse:collapse;margin-bottom:24px}td,th{border:1px solid #ccc;padding:4px 8px}td.n{text-align:right}</style>
+</head><body><h1>{{.Subject}}</h1>
+{{range .Tables}}<h2>{{.Title}}</h2><table><tr>{{range .Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td{{if not (isText .)}} class="n"{{end}}>{{cell .}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}</body></html>`))
+
+// Rendered report ready to deliver
+type Report struct {
+	Subject  string
+	Filename string
+	Tables   []Table
+	Format   string
+	Data     []byte
+}
+
+func (r *ReportSchedule) normalize(form map[string][]string) *Exception {
+	if r.Period == "" {
+		r.Period = REPORT_PERIOD_DAILY
+	}
+	if r.Format == "" {
+		r.Format = REPORT_FORMAT_HTML
+	}
+	if _, ok := form["hour"]; !ok {
+		r.Hour = REPORT_DEFAULT_HOUR
+	}
+	if _, ok := form["weekday"]; !ok {
+		r.Weekday = int(time.Monday)
+	}
+	if r.Period != REPORT_PERIOD_DAILY && r.Period != REPORT_PERIOD_WEEKLY {
+		return &Exception{PARSE_PARAMS_EXEPTION, "period must be daily or weekly"}
+	}
+	if r.Format != REPORT_FORMAT_HTML && r.Format != REPORT_FORMAT_CSV {
+		return &Exception{PARSE_PARAMS_EXEPTION, "format must be html or csv"}
+	}
+	if r.Hour < 0 || r.Hour > 23 || r.Weekday < 0 || r.Weekday > 6 {
+		return &Exception{PARSE_PARAMS_EXEPTION, "hour must be 0-23, weekday 0-6 (0 is sunday)"}
+	}
+	//recipients as repeated field or comma separated
+	recipients := []string{}
+	for _, value := range r.Recipients {
+		for _, recipient := range strings.Split(value, ",") {
+			if recipient = strings.TrimSpace(recipient); recipient != "" {
+				recipients = append(recipients, recipient)
+			}
+		}
+	}
+	r.Recipients = recipients
+	return nil
+}
+
+// Time report is due at on the day of now, false if it is not the day
+func (r *ReportSchedule) DueAt(now time.Time) (time.Time, bool) {
+	at := time.Date(now.Year(), now.Month(), now.Day(), r.Hour, 0, 0, 0, now.Location())
+	if r.Period == REPORT_PERIOD_WEEKLY && now.Weekday() != time.Weekday(r.Weekday) {
+		return at, false
+	}
+	return at, true
+}
+
+func (r *ReportSchedule) IsDue(now time.Time) bool {
+	at, ok := r.DueAt(now)
+	return ok && !now.Before(at) && r.LastRun < at.Unix() && now.Unix()-r.LastAttempt >= int64(REPORT_RETRY_DELAY.Seconds())
+}
+
+// Yesterday for daily report, 7 days before for weekly
+func (r *ReportSchedule) ReportRange(at time.Time) (time.Time, time.Time) {
+	days := 1
+	if r.Period == REPORT_PERIOD_WEEKLY {
+		days = 7
+	}
+	return Bod(at.AddDate(0, 0, -days)), Eod(at.AddDate(0, 0, -1))
+}
+
+func (r *Report) render() error {
+	var err error
+	switch r.Format {
+	case REPORT_FORMAT_CSV:
+		r.Data, err = r.Tables[0].csv()
+	default:
+		var buffer bytes.Buffer
+		err = reportTemplate.Execute(&buffer, r)
+		r.Data = buffer.Bytes()
+	}
+	return err
+}
+
+func (r *Report) writeTo(dir string) error {
+	return ioutil.WriteFile(filepath.Join(dir, r.Filename), r.Data, 0644)
+}
+
+func (r *Report) mail(addr string, from string, recipients []string) error {
+	header := "From: " + from + "\r\n" +
+		"To: " + strings.Join(recipients, ", ") + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", r.Subject) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n"
+	var body string
+	if r.Format == REPORT_FORMAT_HTML {
+		body = header + "Content-Type: text/html; charset=utf-8\r\n" +
+			"Content-Transfer-Encoding: base64\r\n\r\n" + wrapBase64(r.Data)
+	} else {
+		boundary := "report-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+		body = header + "Content-Type: multipart/mixed; boundary=" + boundary + "\r\n\r\n" +
+			"--" + boundary + "\r\n" +
+			"Content-Type: text/plain; charset=utf-8\r\n\r\n" +
+			r.Subject + "\r\n" +
+			"--" + boundary + "\r\n" +
+			"Content-Type: " + exportContentTypes[EXPORT_CSV] + "\r\n" +
+			"Content-Disposition: attachment; filename=\"" + r.Filename + "\"\r\n" +
+			"Content-Transfer-Encoding: base64\r\n\r\n" + wrapBase64(r.Data) +
+			"--" + boundary + "--\r\n"
+	}
+	var auth smtp.Auth
+	if user := os.Getenv(SMTP_USER_ENV); user != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", user, os.Getenv(SMTP_PASSWORD_ENV), host)
+	}
+	return sendMail(addr, auth, from, recipients, []byte(body))
+}
+
+// smtp.SendMail with SMTP_TIMEOUT for dial and session
+func sendMail(addr string, auth smtp.Auth, from string, recipients []string, body []byte) error {
+	dialer := net.Dialer{Timeout: SMTP_TIMEOUT}
+	conn, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(SMTP_TIMEOUT))
+	host, _, _ := net.SplitHostPort(addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(body); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func wrapBase64(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var lines []string
+	for len(encoded) > 76 {
+		lines = append(lines, encoded[:76])
+		encoded = encoded[76:]
+	}
+	lines = append(lines, encoded)
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func newReport(schedule ReportSchedule, group Group, from time.Time, to time.Time, stats []EventStats) (Report, error) {
+	report := Report{Format: schedule.Format}
+	report.Subject = fmt.Sprintf("%s: %s", group.Name, from.Format("2006-01-02"))
+	if schedule.Period == REPORT_PERIOD_WEEKLY {
+		report.Subject += " - " + to.Format("2006-01-02")
+	}
+	report.Filename = fmt.Sprintf("report_%d_%s_%s.%s", group.Id, schedule.Period, from.Format("2006-01-02"), schedule.Format)
+	report.Tables = append(report.Tables, statsTable("Events", stats))
+	for _, event := range stats {
+		report.Tables = append(report.Tables, eventInfoTable(event.DtISO+" "+event.Title, EventInfo{event.Info}))
+	}
+	err := report.render()
+	return report, err
+}
+
+// Write to report dir and mail to recipients, whatever is configured
+func (r *Report) deliver(recipients []string) error {
+	delivered := false
+	errors := []string{}
+	if dir := os.Getenv(REPORT_DIR_ENV); dir != "" {
+		if err := r.writeTo(dir); err != nil {
+			errors = append(errors, err.Error())
+		} else {
+			delivered = true
+		}
+	}
+	if addr := os.Getenv(SMTP_ADDR_ENV); addr != "" && len(recipients) > 0 {
+		if err := r.mail(addr, os.Getenv(SMTP_FROM_ENV), recipients); err != nil {
+			errors = append(errors, err.Error())
+		} else {
+			delivered = true
+		}
+	}
+	if !delivered && len(errors) == 0 {
+		errors = append(errors, "no delivery configured, set "+REPORT_DIR_ENV+" or "+SMTP_ADDR_ENV+" and recipients")
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("%s", strings.Join(errors, "; "))
+	}
+	return nil
+}
@@ -6,15 +6,21 @@ import (
 	"fmt"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Repository struct {
-	Server   string
-	Database string
-	Session  *mgo.Session
+	Server    string
+	Database  string
+	Session   *mgo.Session
+	requestId string
+	log       *slog.Logger
 }
 
 const SALT = "1c2cf9a0a9031262b894fac41f05e656"
@@ -29,11 +35,34 @@ const EVENTS_COLLECTION = "events"
 const ENTRY_COLLECTION = "entry"
 const LOGS_COLLECTION = "logs"
 const MASTERKEY_COLLECTION = "masterkey"
+const SYNC_HISTORY_COLLECTION = "sync_history"
+const EVENT_STATS_COLLECTION = "event_stats"
+const REPORTS_COLLECTION = "report_schedules"
+const AUDIT_COLLECTION = "audit"
+const RETENTION_COLLECTION = "retention_runs"
+const SESSIONS_COLLECTION = "sessions"
+const COUNTERS_COLLECTION = "counters"
+const WEBHOOKS_COLLECTION = "webhooks"
+const ENTRY_STATE_COLLECTION = "entry_state"
+const SYNC_HISTORY_LIMIT = 50
+
+// Events without rollup counted per maintenance run
+const ROLLUP_BACKFILL_LIMIT = 100
 
 var db *mgo.Database
 var ticketsLocked TicketsLocked
 var masterKeys MasterKeys
 
+// Integer of aggregation result, mongo gives int or int64 depending on size
+func bsonInt(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	}
+	return 0
+}
 func NullIsNow(t int64) int64 {
 	if t == 0 {
 		return time.Now().Unix()
@@ -48,27 +77,72 @@ func (r *Repository) Connect() {
 	var err error
 	r.Session, err = mgo.Dial(r.Server)
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("Can`t connect to mongo", "server", r.Server, "error", err)
+		os.Exit(1)
 	}
 	r.Session.SetMode(mgo.Eventual, false)
-	log.Println("Connected to ", r.Server, "with", r.Database, "database.")
+	logger.Info("Connected to mongo", "server", r.Server, "database", r.Database)
 	db = r.Session.DB(r.Database)
+	r.EnsureIndexes()
+	r.EnsureRetention()
+	r.EnsureAdmin()
 	// Optional. Switch the session to a monotonic behavior.
 	r.LoadMasterKeys()
 	//r.GenDemoData(1,600, 1,"demo")
 
 }
+func (r *Repository) EnsureIndexes() {
+	indexes := map[string][]mgo.Index{
+		EVENTS_COLLECTION:       {{Key: []string{"event_id"}}, {Key: []string{"event_dt"}}, {Key: []string{"venue_id", "event_dt"}}},
+		EVENT_STATS_COLLECTION:  {{Key: []string{"event_id"}, Unique: true}},
+		ENTRY_STATE_COLLECTION:  {{Key: []string{"event_id", "ticket_barcode"}, Unique: true}},
+		TICKETS_COLLECTION:      {{Key: []string{"event_id", "ticket_barcode"}}, {Key: []string{"ticket_barcode"}}},
+		RETENTION_COLLECTION:    {{Key: []string{"collection", "-dt"}}},
+		SYNC_HISTORY_COLLECTION: {{Key: []string{"event_id", "id"}}},
+		USER_COLLECTION:         {{Key: []string{"login"}, Unique: true}},
+		AUDIT_COLLECTION:        {{Key: []string{"-dt", "-_id"}}, {Key: []string{"username", "-dt"}}, {Key: []string{"target", "-dt"}}},
+		ENTRY_COLLECTION: {{Key: []string{"event_id", "ticket_barcode", "result_code"}}, {Key: []string{"ticket_barcode"}}, {Key: []string{"operation_dt"}},
+			{Key: []string{"event_id", "-operation_dt"}}, {Key: []string{"terminal_id", "-operation_dt"}}},
+		LOGS_COLLECTION: {{Key: []string{"-dt", "-_id"}}, {Key: []string{"code", "-dt"}}, {Key: []string{"terminal_id", "-dt"}},
+			{Key: []string{"data"}}, {Key: []string{"$text:message"}}},
+		SESSIONS_COLLECTION: {{Key: []string{"login", "-last_refresh"}}, {Key: []string{"refresh_hash"}}, {Key: []string{"previous_hash"}},
+			{Key: []string{"expires"}, ExpireAfter: time.Second}},
+		//dt may be WEBHOOK_MAX_AGE ahead, so twice that covers every hook a replay could be compared with
+		WEBHOOKS_COLLECTION: {{Key: []string{"event_id", "barcodes", "-dt"}}, {Key: []string{"created"}, ExpireAfter: 2 * WEBHOOK_MAX_AGE * time.Second}},
+	}
+	for collection, list := range indexes {
+		for _, index := range list {
+			if err := db.C(collection).EnsureIndex(index); err != nil {
+				logger.Error("Can`t create index", "collection", collection, "key", index.Key, "error", err)
+			}
+		}
+	}
+}
+
+// TTL index on created for ttl policies, dropped for others so archive mode sees all records
+func (r *Repository) EnsureRetention() {
+	for _, policy := range retentionPolicies() {
+		collection := db.C(policy.Collection)
+		if policy.Mode != RETENTION_MODE_TTL || policy.Days == 0 {
+			collection.DropIndexName(RETENTION_TTL_INDEX)
+			continue
+		}
+		index := mgo.Index{Key: []string{"created"}, Name: RETENTION_TTL_INDEX, ExpireAfter: policy.ttl()}
+		if err := collection.EnsureIndex(index); err != nil {
+			//days changed since index was made
+			collection.DropIndexName(RETENTION_TTL_INDEX)
+			if err := collection.EnsureIndex(index); err != nil {
+				logger.Error("Can`t create ttl index", "collection", policy.Collection, "error", err)
+			}
+		}
+	}
+}
 func getResultForEntry(entryItem Entry) (entry bool, exit bool) {
 	if entryItem == (Entry{}) || entryItem.Direction == "exit" {
 		return true, false
 	}
 	return false, true
 }
-func hashPassword(pass string) string {
-	hash := md5.New()
-	hash.Write([]byte(pass + SALT))
-	return hex.EncodeToString(hash.Sum(nil))
-}
 func genSecretKey() string {
 	hash := md5.New()
 	hash.Write([]byte(time.Now().String() + SALT))
@@ -76,8 +150,12 @@ func genSecretKey() string {
 }
 
 func (r *Repository) Maintenance() {
-	r.MaintenceActiveEvents(60)
-	r.SyncAllGroupsEvents()
+	maintenanceJob("active_events", func() *Exception {
+		r.MaintenceActiveEvents(60)
+		return nil
+	})
+	maintenanceJob("events_list", r.SyncAllGroupsEvents)
+	maintenanceJob("rollups", r.BackfillRollups)
 
 }
 
@@ -114,16 +192,22 @@ func (r *Repository) GenDemoData(startNum int, endNum int, eventId int, source s
 func (r *Repository) CheckUser(userLogin UserLogin) (bool, *Exception) {
 	//result := &User{}
 	//db.C("users").Insert(&User{"tester","just test"})
-	userCount, errFind := db.C("users").Find(bson.M{"active": true, "login": userLogin.Login, "password": hashPassword(userLogin.Password)}).Count()
-	if errFind != nil {
+	user := User{}
+	errFind := db.C(USER_COLLECTION).Find(bson.M{"active": true, "login": userLogin.Login}).One(&user)
+	if errFind != nil && errFind != mgo.ErrNotFound {
 		return false, &Exception{CANT_SELECT_EXEPTION, errFind.Error()}
 	}
-	//Correct user
-	if userCount == 1 {
-		return true, nil
+	valid, legacy := VerifyPassword(user.Password, userLogin.Password)
+	if !valid {
+		return false, nil
+	}
+	//Correct user, legacy md5 hash is replaced on login
+	if legacy {
+		if hash, err := HashPassword(userLogin.Password); err == nil {
+			db.C(USER_COLLECTION).Update(bson.M{"login": user.Login, "password": user.Password}, bson.M{"$set": bson.M{"password": hash}})
+		}
 	}
-	//Not found
-	return false, nil
+	return true, nil
 }
 func (r *Repository) Terminals() interface{} {
 	/*	query := []bson.M{{
@@ -147,6 +231,178 @@ func (r *Repository) Groups() interface{} {
 
 	return Groups{result}
 }
+func (r *Repository) GetUser(login string) User {
+	user := User{}
+	db.C(USER_COLLECTION).Find(bson.M{"login": login}).One(&user)
+	return user
+}
+func (r *Repository) Users() []User {
+	users := []User{}
+	db.C(USER_COLLECTION).Find(nil).Sort("login").All(&users)
+	return users
+}
+
+// Role or activity change, deactivated user loses sessions at once
+func (r *Repository) SetUser(form UserForm) *Exception {
+	user := r.GetUser(form.Login)
+	if user.Login == "" {
+		return &Exception{USER_NOT_FOUND_EXEPTION, form.Login}
+	}
+	set := bson.M{}
+	if form.Role != "" {
+		set["role"] = form.Role
+	}
+	if form.Active != nil {
+		set["active"] = *form.Active
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	deactivate := form.Active != nil && !*form.Active
+	if (deactivate || form.Role != "" && form.Role != ROLE_ADMIN) && r.isLastAdmin(user) {
+		return &Exception{LAST_ADMIN_EXEPTION, ""}
+	}
+	update := bson.M{"$set": set}
+	if deactivate {
+		update["$inc"] = bson.M{"token_version": 1}
+	}
+	if errUpdate := db.C(USER_COLLECTION).Update(bson.M{"login": form.Login}, update); errUpdate != nil {
+		return &Exception{CANT_INSERT_EXEPTION, errUpdate.Error()}
+	}
+	if deactivate {
+		_, ex := r.RevokeSessions(form.Login)
+		return ex
+	}
+	return nil
+}
+func (r *Repository) RemoveUser(login string) *Exception {
+	user := r.GetUser(login)
+	if user.Login == "" {
+		return &Exception{USER_NOT_FOUND_EXEPTION, login}
+	}
+	if r.isLastAdmin(user) {
+		return &Exception{LAST_ADMIN_EXEPTION, ""}
+	}
+	if errRemove := db.C(USER_COLLECTION).Remove(bson.M{"login": login}); errRemove != nil {
+		return &Exception{CANT_INSERT_EXEPTION, errRemove.Error()}
+	}
+	_, ex := r.RevokeSessions(login)
+	return ex
+}
+
+// New password revokes all sessions of user
+func (r *Repository) SetUserPassword(login string, password string) *Exception {
+	if ex := CheckPasswordStrength(password, login); ex != nil {
+		return ex
+	}
+	hash, errHash := HashPassword(password)
+	if errHash != nil {
+		return &Exception{WEAK_PASSWORD_EXEPTION, errHash.Error()}
+	}
+	errUpdate := db.C(USER_COLLECTION).Update(bson.M{"login": login}, bson.M{"$set": bson.M{"password": hash}, "$inc": bson.M{"token_version": 1}})
+	if errUpdate == mgo.ErrNotFound {
+		return &Exception{USER_NOT_FOUND_EXEPTION, login}
+	}
+	if errUpdate != nil {
+		return &Exception{CANT_INSERT_EXEPTION, errUpdate.Error()}
+	}
+	_, ex := r.RevokeSessions(login)
+	return ex
+}
+func (r *Repository) isLastAdmin(user User) bool {
+	if user.Role != ROLE_ADMIN || !user.Active {
+		return false
+	}
+	count, _ := db.C(USER_COLLECTION).Find(bson.M{"role": ROLE_ADMIN, "active": true}).Count()
+	return count <= 1
+}
+func (r *Repository) AddSession(session Session) *Exception {
+	if errInsert := db.C(SESSIONS_COLLECTION).Insert(session); errInsert != nil {
+		return &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
+	}
+	return nil
+}
+func (r *Repository) GetSession(id string) Session {
+	session := Session{}
+	if id != "" {
+		db.C(SESSIONS_COLLECTION).FindId(id).One(&session)
+	}
+	return session
+}
+
+// Active sessions of user, last used first
+func (r *Repository) Sessions(login string) []Session {
+	sessions := []Session{}
+	query := bson.M{"login": login, "revoked": bson.M{"$exists": false}, "expires": bson.M{"$gt": time.Now()}}
+	db.C(SESSIONS_COLLECTION).Find(query).Sort("-last_refresh").All(&sessions)
+	return sessions
+}
+
+// Rotates refresh token, old token used again revokes session as it was stolen
+func (r *Repository) RefreshSession(refreshToken string) (Session, string, *Exception) {
+	hash := hashRefreshToken(refreshToken)
+	now := time.Now()
+	session := Session{}
+	errFind := db.C(SESSIONS_COLLECTION).Find(bson.M{"refresh_hash": hash}).One(&session)
+	if errFind == mgo.ErrNotFound {
+		if db.C(SESSIONS_COLLECTION).Find(bson.M{"previous_hash": hash}).One(&session) == nil && session.Active(now) {
+			r.logger().Warn("Refresh token reused, session revoked", "username", session.Login, "session", session.Id)
+			r.RevokeSession(session.Id)
+		}
+		return Session{}, "", &Exception{REFRESH_TOKEN_EXEPTION, ""}
+	}
+	if errFind != nil {
+		return Session{}, "", &Exception{CANT_SELECT_EXEPTION, errFind.Error()}
+	}
+	if !session.Active(now) {
+		return Session{}, "", &Exception{REFRESH_TOKEN_EXEPTION, ""}
+	}
+	newToken := randomHex(32)
+	session.PreviousHash = hash
+	session.RefreshHash = hashRefreshToken(newToken)
+	session.LastRefresh = now.Unix()
+	//condition on old hash, so of two refreshes with same token only one wins
+	errUpdate := db.C(SESSIONS_COLLECTION).Update(bson.M{"_id": session.Id, "refresh_hash": hash},
+		bson.M{"$set": bson.M{"refresh_hash": session.RefreshHash, "previous_hash": hash, "last_refresh": session.LastRefresh}})
+	if errUpdate == mgo.ErrNotFound {
+		return Session{}, "", &Exception{REFRESH_TOKEN_EXEPTION, ""}
+	}
+	if errUpdate != nil {
+		return Session{}, "", &Exception{CANT_INSERT_EXEPTION, errUpdate.Error()}
+	}
+	return session, newToken, nil
+}
+func (r *Repository) RevokeSession(id string) *Exception {
+	errUpdate := db.C(SESSIONS_COLLECTION).Update(bson.M{"_id": id, "revoked": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked": time.Now().Unix()}})
+	if errUpdate != nil && errUpdate != mgo.ErrNotFound {
+		return &Exception{CANT_INSERT_EXEPTION, errUpdate.Error()}
+	}
+	return nil
+}
+func (r *Repository) RevokeSessions(login string) (int, *Exception) {
+	info, errUpdate := db.C(SESSIONS_COLLECTION).UpdateAll(bson.M{"login": login, "revoked": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked": time.Now().Unix()}})
+	if errUpdate != nil {
+		return 0, &Exception{CANT_INSERT_EXEPTION, errUpdate.Error()}
+	}
+	return info.Updated, nil
+}
+func (r *Repository) UsersCount() int {
+	count, _ := db.C(USER_COLLECTION).Count()
+	return count
+}
+
+// Users stored before roles have none and can do nothing until role is set, ADMIN_LOGIN user gets admin
+func (r *Repository) EnsureAdmin() {
+	login := os.Getenv(ADMIN_LOGIN_ENV)
+	if login == "" {
+		return
+	}
+	if err := db.C(USER_COLLECTION).Update(bson.M{"login": login}, bson.M{"$set": bson.M{"role": ROLE_ADMIN}}); err != nil {
+		logger.Error("Can`t make admin", "login", login, "error", err)
+	}
+}
 func (r *Repository) AddUser(user User) *Exception {
 	//Try to find user
 	userCount, errFind := db.C(USER_COLLECTION).Find(bson.M{"login": user.Login}).Count()
@@ -156,7 +412,15 @@ func (r *Repository) AddUser(user User) *Exception {
 	if userCount > 0 {
 		return &Exception{USER_EXIST_EXEPTION, ""}
 	}
-	user.Password = hashPassword(user.Password)
+	//every new user, first admin too
+	if ex := CheckPasswordStrength(user.Password, user.Login); ex != nil {
+		return ex
+	}
+	hash, errHash := HashPassword(user.Password)
+	if errHash != nil {
+		return &Exception{WEAK_PASSWORD_EXEPTION, errHash.Error()}
+	}
+	user.Password = hash
 	user.Active = true
 	errInsert := db.C(USER_COLLECTION).Insert(user)
 	if errInsert != nil {
@@ -167,12 +431,10 @@ func (r *Repository) AddUser(user User) *Exception {
 func (r *Repository) SetGroup(group Group) *Exception {
 
 	db.C(GROUPS_COLLECTION).Upsert(bson.M{"name": group.Name}, group)
-	log.Println(group)
 	return nil
 }
 
 func (r *Repository) SetTerminal(terminal Terminal) *Exception {
-	log.Println(terminal)
 	db.C(TERMINALS_COLLECTION).Update(bson.M{"id": terminal.Id}, bson.M{"$set": terminal})
 	return nil
 }
@@ -240,16 +502,405 @@ func (r *Repository) LoadMasterKeys() {
 
 func (r *Repository) Log(log Log) *Exception {
 	log.Dt = time.Now().Unix()
-	errInsert := db.C(LOGS_COLLECTION).Insert(log)
+	record := LogRecord{Log: log, Created: time.Now(), RequestId: r.requestId}
+	if match := gateInMessage.FindStringSubmatch(log.Message); match != nil {
+		record.TerminalId, _ = strconv.ParseInt(match[1], 10, 64)
+	}
+	errInsert := db.C(LOGS_COLLECTION).Insert(record)
 	if errInsert != nil {
 		return &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
 	}
 	return nil
 }
-func (r *Repository) Logs() []Log {
-	var logs []Log
-	db.C(LOGS_COLLECTION).Find(nil).All(&logs)
-	return logs
+func (r *Repository) Logs(query LogQuery) ([]LogRecord, string, *Exception) {
+	defer observeRepository("Logs", time.Now())
+	and := []bson.M{}
+	if query.From != 0 || query.To != 0 {
+		dt := bson.M{}
+		if query.From != 0 {
+			dt["$gte"] = query.From
+		}
+		if query.To != 0 {
+			dt["$lte"] = query.To
+		}
+		and = append(and, bson.M{"dt": dt})
+	}
+	if query.Code != nil {
+		and = append(and, bson.M{"code": *query.Code})
+	}
+	if query.Terminal != 0 {
+		//records written before terminal_id was stored have gate in message only
+		and = append(and, bson.M{"$or": []bson.M{
+			bson.M{"terminal_id": query.Terminal},
+			bson.M{"terminal_id": bson.M{"$exists": false}, "message": bson.M{"$regex": "gate #" + strconv.FormatInt(query.Terminal, 10) + `(\D|$)`}}}})
+	}
+	if query.Data != "" {
+		and = append(and, bson.M{"data": bson.M{"$regex": regexp.QuoteMeta(query.Data), "$options": "i"}})
+	}
+	if query.Message != "" {
+		and = append(and, bson.M{"$text": bson.M{"$search": query.Message}})
+	}
+	if query.Cursor != "" {
+		dt, id, ok := parsePageCursor(query.Cursor)
+		if !ok {
+			return nil, "", &Exception{PARSE_PARAMS_EXEPTION, "bad cursor"}
+		}
+		and = append(and, bson.M{"$or": []bson.M{bson.M{"dt": bson.M{"$lt": dt}}, bson.M{"dt": dt, "_id": bson.M{"$lt": id}}}})
+	}
+	selector := bson.M{}
+	if len(and) > 0 {
+		selector["$and"] = and
+	}
+	logs := []LogRecord{}
+	errFind := db.C(LOGS_COLLECTION).Find(selector).Sort("-dt", "-_id").Limit(query.Limit + 1).All(&logs)
+	if errFind != nil {
+		return nil, "", &Exception{CANT_SELECT_EXEPTION, errFind.Error()}
+	}
+	next := ""
+	if len(logs) > query.Limit {
+		logs = logs[:query.Limit]
+		next = pageCursor(logs[len(logs)-1].Dt, logs[len(logs)-1].Id)
+	}
+	return logs, next, nil
+}
+func (r *Repository) Entries(query EntryQuery) ([]EntryHistory, string, *Exception) {
+	defer observeRepository("Entries", time.Now())
+	and := []bson.M{}
+	if query.Event != 0 {
+		and = append(and, bson.M{"event_id": query.Event})
+	}
+	if query.Terminal != 0 {
+		and = append(and, bson.M{"terminal_id": query.Terminal})
+	}
+	if query.Direction != "" {
+		and = append(and, bson.M{"direction": query.Direction})
+	}
+	if query.Code != nil {
+		and = append(and, bson.M{"result_code": *query.Code})
+	}
+	if query.Barcode != "" {
+		and = append(and, bson.M{"ticket_barcode": query.Barcode})
+	}
+	if query.From != 0 || query.To != 0 {
+		dt := bson.M{}
+		if query.From != 0 {
+			dt["$gte"] = query.From
+		}
+		if query.To != 0 {
+			dt["$lte"] = query.To
+		}
+		and = append(and, bson.M{"operation_dt": dt})
+	}
+	if query.Cursor != "" {
+		dt, id, ok := parsePageCursor(query.Cursor)
+		if !ok {
+			return nil, "", &Exception{PARSE_PARAMS_EXEPTION, "bad cursor"}
+		}
+		and = append(and, bson.M{"$or": []bson.M{bson.M{"operation_dt": bson.M{"$lt": dt}}, bson.M{"operation_dt": dt, "_id": bson.M{"$lt": id}}}})
+	}
+	selector := bson.M{}
+	if len(and) > 0 {
+		selector["$and"] = and
+	}
+	entries := []EntryHistory{}
+	errFind := db.C(ENTRY_COLLECTION).Find(selector).Sort("-operation_dt", "-_id").Limit(query.Limit + 1).All(&entries)
+	if errFind != nil {
+		return nil, "", &Exception{CANT_SELECT_EXEPTION, errFind.Error()}
+	}
+	next := ""
+	if len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+		next = pageCursor(entries[len(entries)-1].OperationDt, entries[len(entries)-1].Id)
+	}
+	r.joinEntries(entries)
+	return entries, next, nil
+}
+
+// Terminal names, event titles and ticket sectors for page of entries
+func (r *Repository) joinEntries(entries []EntryHistory) {
+	terminalIds, eventIds, barcodes := []int64{}, []int64{}, []string{}
+	for _, entry := range entries {
+		terminalIds = append(terminalIds, entry.TerminalId)
+		eventIds = append(eventIds, entry.EventId)
+		barcodes = append(barcodes, entry.TicketBarcode)
+	}
+	var terminals []Terminal
+	db.C(TERMINALS_COLLECTION).Find(bson.M{"id": bson.M{"$in": terminalIds}}).All(&terminals)
+	terminalNames := map[int64]string{}
+	for _, terminal := range terminals {
+		terminalNames[terminal.Id] = terminal.Name
+	}
+	var events []Event
+	db.C(EVENTS_COLLECTION).Find(bson.M{"event_id": bson.M{"$in": eventIds}}).All(&events)
+	eventsById := map[int64]Event{}
+	for _, event := range events {
+		eventsById[event.Id] = event
+	}
+	var tickets []Ticket
+	db.C(TICKETS_COLLECTION).Find(bson.M{"event_id": bson.M{"$in": eventIds}, "ticket_barcode": bson.M{"$in": barcodes}}).All(&tickets)
+	ticketsByBarcode := map[string]Ticket{}
+	for _, ticket := range tickets {
+		ticketsByBarcode[strconv.FormatInt(ticket.EventId, 10)+"_"+ticket.TicketBarcode] = ticket
+	}
+	for i := range entries {
+		entry := &entries[i]
+		event := eventsById[entry.EventId]
+		ticket := ticketsByBarcode[strconv.FormatInt(entry.EventId, 10)+"_"+entry.TicketBarcode]
+		entry.DtISO = IsoTime(entry.OperationDt, LoadLocation(event.Timezone))
+		entry.TerminalName = terminalNames[entry.TerminalId]
+		entry.EventTitle = event.Title
+		entry.TicketSector, entry.TicketTitle, entry.TicketPrice = ticket.TicketSector, ticket.TicketTitle, ticket.TicketPrice
+	}
+}
+func (r *Repository) RunRetention() *Exception {
+	now := time.Now()
+	var errors []string
+	for _, policy := range retentionPolicies() {
+		var last RetentionRun
+		db.C(RETENTION_COLLECTION).Find(bson.M{"collection": policy.Collection}).Sort("-dt").One(&last)
+		if !policy.isDue(last, now) {
+			continue
+		}
+		run := r.Retain(policy, now)
+		if run.Error != "" {
+			errors = append(errors, policy.Collection+": "+run.Error)
+		}
+		if errInsert := db.C(RETENTION_COLLECTION).Insert(run); errInsert != nil {
+			errors = append(errors, errInsert.Error())
+		}
+	}
+	if len(errors) > 0 {
+		return &Exception{RETENTION_EXEPTION, strings.Join(errors, "; ")}
+	}
+	return nil
+}
+
+// Remove records older than policy days, ttl index removes records with created date,
+// records stored before it are removed here
+func (r *Repository) Retain(policy RetentionPolicy, now time.Time) RetentionRun {
+	defer observeRepository("Retain", time.Now())
+	run := RetentionRun{Collection: policy.Collection, Mode: policy.Mode, Dt: now.Unix(), Cutoff: policy.cutoff(now)}
+	var err error
+	if policy.Mode == RETENTION_MODE_ARCHIVE {
+		err = r.archive(policy, &run)
+	} else {
+		var info *mgo.ChangeInfo
+		info, err = db.C(policy.Collection).RemoveAll(bson.M{policy.DtField: bson.M{"$lt": run.Cutoff}, "created": bson.M{"$exists": false}})
+		if info != nil {
+			run.Removed = info.Removed
+		}
+	}
+	if err != nil {
+		run.Error = err.Error()
+		r.logger().Error("Retention failed", "collection", policy.Collection, "mode", policy.Mode, "error", err)
+	}
+	run.Duration = time.Since(now).Seconds()
+	return run
+}
+
+// Write expired records to archive, delete them once archive is on disk
+func (r *Repository) archive(policy RetentionPolicy, run *RetentionRun) error {
+	if policy.ArchiveDir == "" {
+		return fmt.Errorf("%s is not set", RETENTION_ARCHIVE_DIR_ENV)
+	}
+	writer, err := newArchiveWriter(policy.ArchiveDir, policy.Collection, time.Unix(run.Dt, 0))
+	if err != nil {
+		return err
+	}
+	ids := []interface{}{}
+	iter := db.C(policy.Collection).Find(bson.M{policy.DtField: bson.M{"$lt": run.Cutoff}}).Sort(policy.DtField).Limit(RETENTION_ARCHIVE_LIMIT).Iter()
+	record := bson.M{}
+	for iter.Next(&record) {
+		if err = writer.Write(record); err != nil {
+			break
+		}
+		ids = append(ids, record["_id"])
+		record = bson.M{}
+	}
+	if errIter := iter.Close(); err == nil {
+		err = errIter
+	}
+	if err == nil && len(ids) > 0 {
+		err = writer.Close()
+	}
+	if err != nil || len(ids) == 0 {
+		writer.Discard()
+		return err
+	}
+	run.File = writer.path
+	run.Archived = len(ids)
+	for start := 0; start < len(ids); start += 1000 {
+		end := start + 1000
+		if end > len(ids) {
+			end = len(ids)
+		}
+		info, errRemove := db.C(policy.Collection).RemoveAll(bson.M{"_id": bson.M{"$in": ids[start:end]}})
+		if errRemove != nil {
+			return errRemove
+		}
+		run.Removed += info.Removed
+	}
+	return nil
+}
+
+func (r *Repository) Retention() RetentionStatus {
+	status := RetentionStatus{Policies: retentionPolicies(), Runs: []RetentionRun{}}
+	db.C(RETENTION_COLLECTION).Find(nil).Sort("-dt").Limit(RETENTION_RUNS_SHOWN).All(&status.Runs)
+	return status
+}
+func (r *Repository) AddAudit(record AuditRecord) *Exception {
+	record.Dt = time.Now().Unix()
+	errInsert := db.C(AUDIT_COLLECTION).Insert(record)
+	if errInsert != nil {
+		r.logger().Error("Can`t write audit", "action", record.Action, "target", record.Target, "error", errInsert)
+		return &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
+	}
+	return nil
+}
+func (r *Repository) Audit(query AuditQuery) ([]AuditRecord, string, *Exception) {
+	defer observeRepository("Audit", time.Now())
+	selector := bson.M{}
+	if query.From != 0 || query.To != 0 {
+		dt := bson.M{}
+		if query.From != 0 {
+			dt["$gte"] = query.From
+		}
+		if query.To != 0 {
+			dt["$lte"] = query.To
+		}
+		selector["dt"] = dt
+	}
+	if query.Username != "" {
+		selector["username"] = query.Username
+	}
+	if query.Action != "" {
+		selector["action"] = query.Action
+	}
+	if query.Target != "" {
+		selector["target"] = query.Target
+	}
+	if query.Cursor != "" {
+		dt, id, ok := parsePageCursor(query.Cursor)
+		if !ok {
+			return nil, "", &Exception{PARSE_PARAMS_EXEPTION, "bad cursor"}
+		}
+		selector["$or"] = []bson.M{bson.M{"dt": bson.M{"$lt": dt}}, bson.M{"dt": dt, "_id": bson.M{"$lt": id}}}
+	}
+	records := []AuditRecord{}
+	errFind := db.C(AUDIT_COLLECTION).Find(selector).Sort("-dt", "-_id").Limit(query.Limit + 1).All(&records)
+	if errFind != nil {
+		return nil, "", &Exception{CANT_SELECT_EXEPTION, errFind.Error()}
+	}
+	next := ""
+	if len(records) > query.Limit {
+		records = records[:query.Limit]
+		next = pageCursor(records[len(records)-1].Dt, records[len(records)-1].Id)
+	}
+	return records, next, nil
+}
+func (r *Repository) GetGroupByName(name string) *Group {
+	var group Group
+	if db.C(GROUPS_COLLECTION).Find(bson.M{"name": name}).One(&group) != nil {
+		return nil
+	}
+	return &group
+}
+func (r *Repository) ReportSchedules() []ReportSchedule {
+	schedules := []ReportSchedule{}
+	db.C(REPORTS_COLLECTION).Find(nil).Sort("id").All(&schedules)
+	return schedules
+}
+func (r *Repository) GetReportSchedule(id int64) (ReportSchedule, *Exception) {
+	var schedule ReportSchedule
+	errFind := db.C(REPORTS_COLLECTION).Find(bson.M{"id": id}).One(&schedule)
+	if errFind != nil {
+		return schedule, &Exception{REPORT_NOT_FOUND_EXEPTION, "report " + strconv.FormatInt(id, 10)}
+	}
+	return schedule, nil
+}
+func (r *Repository) AddReportSchedule(schedule ReportSchedule) (ReportSchedule, *Exception) {
+	if r.GetGroupById(schedule.GroupId).Id == 0 {
+		return schedule, &Exception{PARSE_PARAMS_EXEPTION, "group " + strconv.FormatInt(schedule.GroupId, 10) + " not found"}
+	}
+	id, errId := nextId(db, REPORTS_COLLECTION, func() int64 {
+		var last ReportSchedule
+		db.C(REPORTS_COLLECTION).Find(nil).Sort("-id").One(&last)
+		return last.Id
+	})
+	if errId != nil {
+		return schedule, &Exception{CANT_INSERT_EXEPTION, errId.Error()}
+	}
+	schedule.Id = id
+	errInsert := db.C(REPORTS_COLLECTION).Insert(schedule)
+	if errInsert != nil {
+		return schedule, &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
+	}
+	return schedule, nil
+}
+func (r *Repository) SetReportSchedule(schedule ReportSchedule) (ReportSchedule, *Exception) {
+	current, ex := r.GetReportSchedule(schedule.Id)
+	if ex != nil {
+		return schedule, ex
+	}
+	schedule.LastRun, schedule.LastAttempt, schedule.LastError = current.LastRun, current.LastAttempt, current.LastError
+	errUpdate := db.C(REPORTS_COLLECTION).Update(bson.M{"id": schedule.Id}, schedule)
+	if errUpdate != nil {
+		return schedule, &Exception{CANT_INSERT_EXEPTION, errUpdate.Error()}
+	}
+	return schedule, nil
+}
+func (r *Repository) RemoveReportSchedule(id int64) *Exception {
+	errRemove := db.C(REPORTS_COLLECTION).Remove(bson.M{"id": id})
+	if errRemove != nil {
+		return &Exception{REPORT_NOT_FOUND_EXEPTION, errRemove.Error()}
+	}
+	return nil
+}
+
+// Build report of schedule for day at and deliver it, result is stored in schedule
+func (r *Repository) RunReport(schedule ReportSchedule, at time.Time) (ReportSchedule, *Exception) {
+	defer observeRepository("RunReport", time.Now())
+	group := r.GetGroupById(schedule.GroupId)
+	from, to := schedule.ReportRange(at.In(LoadLocation(group.Timezone)))
+	stats, ex := r.AllStats(from.Unix(), to.Unix(), group.Id)
+	if ex != nil {
+		return schedule, ex
+	}
+	report, errReport := newReport(schedule, group, from, to, stats)
+	if errReport == nil {
+		errReport = report.deliver(schedule.Recipients)
+	}
+	//last run only for delivered report, failed one is tried again
+	schedule.LastAttempt = time.Now().Unix()
+	if errReport != nil {
+		schedule.LastError = errReport.Error()
+		db.C(REPORTS_COLLECTION).Update(bson.M{"id": schedule.Id}, bson.M{"$set": bson.M{"last_attempt": schedule.LastAttempt, "last_error": schedule.LastError}})
+		return schedule, &Exception{REPORT_EXEPTION, errReport.Error()}
+	}
+	schedule.LastRun, schedule.LastError = schedule.LastAttempt, ""
+	db.C(REPORTS_COLLECTION).Update(bson.M{"id": schedule.Id}, bson.M{"$set": bson.M{"last_run": schedule.LastRun, "last_attempt": schedule.LastAttempt, "last_error": ""}})
+	return schedule, nil
+}
+
+// Send reports whose hour has come in group timezone, last failure is returned for maintenance metrics
+func (r *Repository) RunDueReports() *Exception {
+	var failed *Exception
+	for _, schedule := range r.ReportSchedules() {
+		group := r.GetGroupById(schedule.GroupId)
+		now := time.Now().In(LoadLocation(group.Timezone))
+		if !schedule.IsDue(now) {
+			continue
+		}
+		at, _ := schedule.DueAt(now)
+		message := "Report #" + strconv.FormatInt(schedule.Id, 10) + " for group " + group.Name
+		if _, ex := r.RunReport(schedule, at); ex != nil {
+			r.Log(Log{0, strconv.FormatInt(schedule.Id, 10), message + " failed: " + ex.Error, http.StatusInternalServerError})
+			failed = ex
+			continue
+		}
+		r.Log(Log{0, strconv.FormatInt(schedule.Id, 10), message + " sent", OK_CODE_RESPONSE})
+	}
+	return failed
 }
 func (r *Repository) RemoveGroup(group Group) *Exception {
 
@@ -261,22 +912,39 @@ func (r *Repository) RemoveGroup(group Group) *Exception {
 func (r *Repository) SyncAllGroupsEvents() *Exception {
 	groups := Groups{}
 	db.C(GROUPS_COLLECTION).Find(nil).All(&groups.Groups)
+	//one building failing does not stop others
+	var result *Exception
 	for _, element := range groups.Groups {
-		r.SyncEventsList(element.BuildingId)
+		if ex := r.SyncEventsList(element.BuildingId); ex != nil {
+			result = ex
+		}
 	}
-	return nil
+	return result
 }
 
-func (r *Repository) SyncEventsList(buildingId int64) {
-	pageEvents := api.PageEventList(buildingId, time.Now().Add(-time.Second*60*60*24).Unix(), time.Now().Add(time.Second*60*60*24*90).Unix())
+func (r *Repository) SyncEventsList(buildingId int64) *Exception {
+	defer observeRepository("SyncEventsList", time.Now())
+	pageEvents, err := r.api().PageEventList(buildingId, time.Now().Add(-time.Second*60*60*24).Unix(), time.Now().Add(time.Second*60*60*24*90).Unix())
+	if err != nil {
+		return &Exception{API_EXEPTION, err.Error()}
+	}
 	r.AddEvents(pageEvents.ToEvents())
+	//venue timezone for groups which have no own
+	if tz := pageEvents.Timezone(); tz != "" {
+		db.C(GROUPS_COLLECTION).UpdateAll(bson.M{"building_id": buildingId, "timezone": bson.M{"$in": []interface{}{"", nil}}}, bson.M{"$set": bson.M{"timezone": tz}})
+	}
+	return nil
 }
 func (r *Repository) AddEvents(events Events) *Exception {
+	defer observeRepository("AddEvents", time.Now())
 
+	cached := Events{}
+	db.C(EVENTS_COLLECTION).Find(bson.M{"event_id": bson.M{"$in": events.EventsIds()}}).All(&cached.Events)
 	bulk := db.C(EVENTS_COLLECTION).Bulk()
 	timeUnix := time.Now().Unix()
 	for _, element := range events.Events {
 		element.LastUpdate = timeUnix
+		element.ApplyLifecycle(cached.EventById(element.Id), element.Status)
 		bulk.Upsert(bson.M{"event_id": element.Id}, element)
 	}
 	bulk.Run()
@@ -302,19 +970,38 @@ func (r *Repository) SyncEvent(eventId int64) (Event, *Exception) {
 	session := r.Session.Clone()
 	defer session.Close()
 
-	eventExport := api.GetEventACS(eventId)
+	start := time.Now()
+	eventExport, errApi := r.api().GetEventACS(eventId)
+	if errApi != nil {
+		r.logger().Warn("Event sync failed", "event_id", eventId, "error", errApi)
+		observeSync(start, "api_error", nil, 0)
+		return r.GetEventById(eventId), &Exception{API_EXEPTION, errApi.Error()}
+	}
+	//Api failed or event unknown to api (e.g. imported event), keep cache as is
+	if eventExport.Content.Data.Event.EventID != int(eventId) {
+		r.logger().Warn("Event sync failed", "event_id", eventId, "api_message", eventExport.Result.Message)
+		observeSync(start, "api_error", nil, 0)
+		return r.GetEventById(eventId), &Exception{API_EXEPTION, eventExport.Result.Message}
+	}
 	timeUnix := time.Now().Unix()
-	eventExport.Content.Data.Event.LastUpdate = timeUnix
-	//sync Event
-	session.DB(r.Database).C(EVENTS_COLLECTION).Upsert(bson.M{"event_id": eventExport.Content.Data.Event.EventID}, eventExport.Content.Data.Event)
+	acsEvent := eventExport.Content.Data.Event
+	//sync Event, api export has no state so keep cached one
+	cachedEvent := r.GetEventById(eventId)
+	syncedEvent := Event{Id: eventId, Title: acsEvent.ShowTitle, EventDT: int64(acsEvent.EventDt), VenueId: int64(acsEvent.VenueID), VenueTitle: acsEvent.VenueTitle,
+		HallId: int64(acsEvent.HallID), Hall: acsEvent.HallTitle, ShowId: int64(acsEvent.ShowID), Timezone: cachedEvent.Timezone, Vacancies: cachedEvent.Vacancies, LastUpdate: timeUnix}
+	syncedEvent.ApplyLifecycle(cachedEvent, cachedEvent.Status)
+	session.DB(r.Database).C(EVENTS_COLLECTION).Upsert(bson.M{"event_id": eventId}, syncedEvent)
+	source := api.Source()
+	//snapshot before sync for history
+	var cached []TicketExport
+	session.DB(r.Database).C(TICKETS_COLLECTION).Find(bson.M{"event_id": eventExport.Content.Data.Event.EventID, "source": source}).All(&cached)
 	//sync Tickets
 	bulk := session.DB(r.Database).C(TICKETS_COLLECTION).Bulk()
-	source := api.Source()
 	for _, element := range eventExport.Content.Data.Event.Tickets {
 		element.EventID = eventExport.Content.Data.Event.EventID
 		element.LastUpdate = timeUnix
 		element.Source = source
-		bulk.Upsert(bson.M{"ticket_id": element.TicketID, "event_id": element.EventID}, element)
+		bulk.Upsert(bson.M{"ticket_id": element.TicketID, "event_id": element.EventID, "source": source}, element)
 	}
 	_, err := bulk.Run()
 	//log.Println("delete old")
@@ -325,10 +1012,241 @@ func (r *Repository) SyncEvent(eventId int64) (Event, *Exception) {
 	var event Event
 	session.DB(r.Database).C(EVENTS_COLLECTION).Find(bson.M{"event_id": eventId}).One(&event)
 	event.TicketsCached = r.GetTicketsCountByEvent(event)
+	if err == nil {
+		run := SyncRun{EventId: eventId, Dt: timeUnix, Origin: SYNC_ORIGIN_API}
+		run.FromTickets(cached, eventExport.Content.Data.Event.Tickets)
+		observeSync(start, "ok", &run, len(eventExport.Content.Data.Event.Tickets))
+		r.logger().Debug("Event synced", "event_id", eventId, "tickets", len(eventExport.Content.Data.Event.Tickets),
+			"added", len(run.Added), "removed", len(run.Removed), "changed", len(run.Changed))
+		if ex := r.AddSyncRun(session, run); ex != nil {
+			return event, ex
+		}
+		stats := run.toStats()
+		event.LastSync = &stats
+		if len(run.Added)+len(run.Removed)+len(run.Changed) > 0 {
+			r.UpdateEventRollup(eventId)
+		}
+		streamHub.MarkDirty(event)
+	} else {
+		r.logger().Error("Event tickets sync failed", "event_id", eventId, "error", err)
+		observeSync(start, "db_error", nil, 0)
+		return event, &Exception{CANT_INSERT_EXEPTION, err.Error()}
+	}
 	return event, nil
 }
+func (r *Repository) AddSyncRun(session *mgo.Session, run SyncRun) *Exception {
+	history := session.DB(r.Database).C(SYNC_HISTORY_COLLECTION)
+	id, errId := nextId(session.DB(r.Database), SYNC_HISTORY_COLLECTION+":"+strconv.FormatInt(run.EventId, 10), func() int64 {
+		var last SyncRun
+		history.Find(bson.M{"event_id": run.EventId}).Sort("-id").One(&last)
+		return last.Id
+	})
+	if errId != nil {
+		return &Exception{CANT_INSERT_EXEPTION, errId.Error()}
+	}
+	run.Id = id
+	errInsert := session.DB(r.Database).C(SYNC_HISTORY_COLLECTION).Insert(run)
+	if errInsert != nil {
+		return &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
+	}
+	return nil
+}
+
+// Atomic id sequence, counter starts from last id stored before it existed
+func nextId(database *mgo.Database, name string, last func() int64) (int64, error) {
+	counters := database.C(COUNTERS_COLLECTION)
+	if count, _ := counters.FindId(name).Count(); count == 0 {
+		errInsert := counters.Insert(bson.M{"_id": name, "seq": last()})
+		if errInsert != nil && !mgo.IsDup(errInsert) {
+			return 0, errInsert
+		}
+	}
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	_, errApply := counters.FindId(name).Apply(mgo.Change{Update: bson.M{"$inc": bson.M{"seq": 1}}, ReturnNew: true}, &counter)
+	return counter.Seq, errApply
+}
+func (r *Repository) SyncHistory(eventId int64, limit int) SyncHistory {
+	defer observeRepository("SyncHistory", time.Now())
+	var runs []SyncRun
+	history := SyncHistory{eventId, []SyncRunStats{}}
+	db.C(SYNC_HISTORY_COLLECTION).Find(bson.M{"event_id": eventId}).Sort("-id").Limit(limit).All(&runs)
+	for _, run := range runs {
+		history.Runs = append(history.Runs, run.toStats())
+	}
+	return history
+}
+func (r *Repository) SyncDiff(eventId int64, from int64, to int64) (SyncDiff, *Exception) {
+	defer observeRepository("SyncDiff", time.Now())
+	if from > to {
+		from, to = to, from
+	}
+	var runs []SyncRun
+	errFind := db.C(SYNC_HISTORY_COLLECTION).Find(bson.M{"event_id": eventId, "id": bson.M{"$gt": from, "$lte": to}}).Sort("id").All(&runs)
+	if errFind != nil {
+		return SyncDiff{}, &Exception{CANT_SELECT_EXEPTION, errFind.Error()}
+	}
+	diff := SyncDiff{EventId: eventId, From: from, To: to}
+	diff.FromRuns(runs)
+	return diff, nil
+}
+
+// Apply single ticket change pushed by ticketing system, without waiting for event sync
+func (r *Repository) ApplyTicketWebhook(hook TicketWebhook) (SyncRun, *Exception) {
+	defer observeRepository("ApplyTicketWebhook", time.Now())
+	session := r.Session.Clone()
+	defer session.Close()
+	tickets := session.DB(r.Database).C(TICKETS_COLLECTION)
+
+	source := api.Source()
+	timeUnix := time.Now().Unix()
+	run := SyncRun{EventId: hook.EventId, Dt: timeUnix, Origin: SYNC_ORIGIN_WEBHOOK, Added: []string{}, Removed: []string{}, Changed: []string{}}
+	ticket := hook.Ticket
+	ticket.EventID = int(hook.EventId)
+	ticket.LastUpdate = timeUnix
+	ticket.Source = source
+	if ticket.TicketBarcode == "" || hook.EventId == 0 || hook.Action != WEBHOOK_ACTION_REFUND && ticket.TicketID == 0 {
+		return run, &Exception{NOT_ENOUGH_PARAMS, "event_id, ticket barcode and ticket id are required"}
+	}
+
+	remove := func(barcode string) *Exception {
+		info, errRemove := tickets.RemoveAll(bson.M{"event_id": hook.EventId, "ticket_barcode": barcode, "source": source})
+		if errRemove != nil {
+			return &Exception{CANT_INSERT_EXEPTION, errRemove.Error()}
+		}
+		if info.Removed > 0 {
+			run.Removed = append(run.Removed, barcode)
+		}
+		return nil
+	}
+	upsert := func() *Exception {
+		var cached TicketExport
+		errFind := tickets.Find(bson.M{"event_id": hook.EventId, "ticket_barcode": ticket.TicketBarcode, "source": source}).One(&cached)
+		//keyed as checked above, so one document per barcode
+		_, errUpsert := tickets.Upsert(bson.M{"event_id": hook.EventId, "ticket_barcode": ticket.TicketBarcode, "source": source}, ticket)
+		if errUpsert != nil {
+			return &Exception{CANT_INSERT_EXEPTION, errUpsert.Error()}
+		}
+		if errFind == mgo.ErrNotFound {
+			run.Added = append(run.Added, ticket.TicketBarcode)
+		} else if ticketChanged(cached, ticket) {
+			run.Changed = append(run.Changed, ticket.TicketBarcode)
+		}
+		return nil
+	}
+
+	var ex *Exception
+	switch hook.Action {
+	case WEBHOOK_ACTION_SALE:
+		ex = upsert()
+	case WEBHOOK_ACTION_REFUND:
+		ex = remove(ticket.TicketBarcode)
+	case WEBHOOK_ACTION_REISSUE:
+		if hook.OldBarcode != "" && hook.OldBarcode != ticket.TicketBarcode {
+			ex = remove(hook.OldBarcode)
+		}
+		if ex == nil {
+			ex = upsert()
+		}
+	default:
+		return run, &Exception{PARSE_PARAMS_EXEPTION, "unknown action " + hook.Action}
+	}
+	if ex != nil {
+		return run, ex
+	}
+	run.Tickets = r.GetTicketsCountByEvent(Event{Id: hook.EventId})
+	r.UpdateEventRollup(hook.EventId)
+	streamHub.MarkDirty(r.GetEventById(hook.EventId))
+	return run, r.AddSyncRun(session, run)
+}
+
+// Refuses webhook seen before or older than last applied one for its barcodes, so sale can`t come back after refund
+func (r *Repository) RecordWebhook(hook TicketWebhook, request TicketWebhookRequest) *Exception {
+	webhooks := db.C(WEBHOOKS_COLLECTION)
+	newer, errFind := webhooks.Find(bson.M{"event_id": hook.EventId, "barcodes": bson.M{"$in": hook.Barcodes()}, "dt": bson.M{"$gt": hook.Dt}}).Count()
+	if errFind != nil {
+		return &Exception{CANT_SELECT_EXEPTION, errFind.Error()}
+	}
+	if newer > 0 {
+		return &Exception{WEBHOOK_REPLAY_EXEPTION, "newer webhook applied"}
+	}
+	record := WebhookRecord{Id: request.Hash(), EventId: hook.EventId, Barcodes: hook.Barcodes(), Dt: hook.Dt, Created: time.Now()}
+	errInsert := webhooks.Insert(record)
+	if mgo.IsDup(errInsert) {
+		return &Exception{WEBHOOK_REPLAY_EXEPTION, "same webhook applied"}
+	}
+	if errInsert != nil {
+		return &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
+	}
+	return nil
+}
+
+// Webhook which failed to apply may be sent again
+func (r *Repository) ForgetWebhook(request TicketWebhookRequest) {
+	db.C(WEBHOOKS_COLLECTION).RemoveId(request.Hash())
+}
+
+func (r *Repository) ImportTickets(eventId int64, form TicketImportForm, rows [][]string) (TicketImport, *Exception) {
+	defer observeRepository("ImportTickets", time.Now())
+	source := ImportSource(form.Source)
+	report := TicketImport{EventId: eventId, Source: source, DryRun: form.DryRun}
+	if len(rows) > 0 {
+		report.Rows = len(rows) - 1
+	}
+	tickets, rowByBarcode, errors := TicketsFromRows(rows, eventId, source)
+	report.Errors = errors
+
+	event := r.GetEventById(eventId)
+	if event.Id == 0 {
+		if form.VenueId == 0 || form.Dt == 0 {
+			return report, &Exception{EVENT_NOT_FOUND_EXEPTION, "set venue_id and dt to create event"}
+		}
+		event = Event{Id: eventId, Title: form.Title, EventDT: form.Dt, VenueId: form.VenueId, HallId: form.HallId}
+	}
+	//Barcodes already cached for event from other sources
+	barcodes := []string{}
+	for _, ticket := range tickets {
+		barcodes = append(barcodes, ticket.TicketBarcode)
+	}
+	var existing []TicketExport
+	errFind := db.C(TICKETS_COLLECTION).Find(bson.M{"event_id": eventId, "ticket_barcode": bson.M{"$in": barcodes}, "source": bson.M{"$ne": source}}).All(&existing)
+	if errFind != nil {
+		return report, &Exception{CANT_SELECT_EXEPTION, errFind.Error()}
+	}
+	for _, ticket := range existing {
+		report.Errors = append(report.Errors, TicketImportError{rowByBarcode[ticket.TicketBarcode], ticket.TicketBarcode, "barcode already exists in " + ticket.Source})
+	}
+	if len(report.Errors) > 0 {
+		return report, &Exception{IMPORT_EXEPTION, strconv.Itoa(len(report.Errors)) + " errors"}
+	}
+	report.Imported = len(tickets)
+	if form.DryRun {
+		return report, nil
+	}
+
+	timeUnix := time.Now().Unix()
+	if event.LastUpdate == 0 {
+		event.LastUpdate = timeUnix
+		db.C(EVENTS_COLLECTION).Upsert(bson.M{"event_id": eventId}, event)
+	}
+	bulk := db.C(TICKETS_COLLECTION).Bulk()
+	for _, ticket := range tickets {
+		ticket.LastUpdate = timeUnix
+		bulk.Upsert(bson.M{"event_id": eventId, "ticket_barcode": ticket.TicketBarcode, "source": source}, ticket)
+	}
+	_, errBulk := bulk.Run()
+	if errBulk != nil {
+		report.Imported = 0
+		return report, &Exception{CANT_INSERT_EXEPTION, errBulk.Error()}
+	}
+	r.UpdateEventRollup(eventId)
+	streamHub.MarkDirty(event)
+	return report, nil
+}
 
 func (r *Repository) ValidateTicket(barcode string, term Terminal) (SKDResponse, *Exception) {
+	defer observeRepository("ValidateTicket", time.Now())
 	curentGroups := r.GetGroupsByTerminal(term)
 	currentEvents := r.GetActiveEventsByGroups(curentGroups)
 	ticket := Ticket{}
@@ -336,8 +1254,11 @@ func (r *Repository) ValidateTicket(barcode string, term Terminal) (SKDResponse,
 
 	if (Ticket{}) != ticket {
 		entry := r.CheckTicketForEntry(ticket)
-
-		return SKDResponse{SKDResult{ENTRY_RESULT_CODE_ACCEPT}, ticket, currentEvents.EventById(ticket.EventId), entry.toAction()}, nil
+		event := currentEvents.EventById(ticket.EventId)
+		if code, rejected := event.RejectCode(); rejected {
+			return SKDResponse{SKDResult{code}, ticket, event, entry.toAction()}, nil
+		}
+		return SKDResponse{SKDResult{ENTRY_RESULT_CODE_ACCEPT}, ticket, event, entry.toAction()}, nil
 	}
 	//Not Found
 	ticket.TicketBarcode = barcode
@@ -345,7 +1266,10 @@ func (r *Repository) ValidateTicket(barcode string, term Terminal) (SKDResponse,
 }
 
 func (r *Repository) ValidateRegistrateTicket(barcode string, term Terminal, direction string) (SKDRegistrationResponse, *Exception) {
+	defer observeRepository("ValidateRegistrateTicket", time.Now())
+	scanLog := scanLogger(r.logger(), term, barcode, direction)
 	if masterKeys.is(barcode) { //Master key
+		scanLog.Info("Master key scan")
 		return SKDRegistrationResponse{SKDRegistrationResult{ENTRY_RESULT_CODE_ACCEPT, direction == "entry", direction == "exit"}, Ticket{}, Event{}, Action{}}, nil
 	}
 	curentGroups := r.GetGroupsByTerminal(term)
@@ -353,19 +1277,25 @@ func (r *Repository) ValidateRegistrateTicket(barcode string, term Terminal, dir
 	ticket := Ticket{}
 	db.C(TICKETS_COLLECTION).Find(bson.M{"ticket_barcode": barcode, "event_id": bson.M{"$in": currentEvents.EventsIds()}}).One(&ticket)
 
-	// 
-	log.Println("TERMINAL:", term.Name, direction, "TICKET:", ticket.TicketBarcode, "EVENT:", ticket.TicketTitle, ticket.TicketSector, "PRICE:", ticket.TicketPrice)
-
 	if (Ticket{}) != ticket {
+		scanLog = scanLog.With("event_id", ticket.EventId)
+		scanLog.Info("Ticket scan", "event", ticket.TicketTitle, "sector", ticket.TicketSector, "price", ticket.TicketPrice)
 		entryItem := r.CheckTicketForEntry(ticket)
 		entry, exit := getResultForEntry(entryItem)
+		event := currentEvents.EventById(ticket.EventId)
+		if code, rejected := event.RejectCode(); rejected {
+			entryRecord := Entry{ticket.EventId, ticket.TicketBarcode, term.Id, time.Now().Unix(), code, direction}
+			errInsert := r.AddEntry(entryRecord, ticket, currentEvents.EventById(ticket.EventId), term)
+			if errInsert != nil {
+				return SKDRegistrationResponse{}, &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
+			}
+			return SKDRegistrationResponse{SKDRegistrationResult{code, false, false}, ticket, event, entryItem.toAction()}, nil
+		}
 		if ticketsLocked.isLock(barcode) || (r.TicketEntryFirstTime(ticket)+BLOCKAFETRENTRY < time.Now().Unix()) {
-			log.Println("!!!")
-			log.Println("lock", entryItem)
-			log.Println(time.Now().Unix() - BLOCKAFETRENTRY)
+			scanLog.Debug("Ticket locked or entry expired", "locked", ticketsLocked.isLock(barcode), "last_entry_dt", entryItem.OperationDt)
 			//Reenty for LockTicket OR Block entry after expiration
 			entryRecord := Entry{ticket.EventId, ticket.TicketBarcode, term.Id, time.Now().Unix(), ENTRY_RESULT_CODE_REENTRY, direction}
-			errInsert := db.C(ENTRY_COLLECTION).Insert(entryRecord)
+			errInsert := r.AddEntry(entryRecord, ticket, currentEvents.EventById(ticket.EventId), term)
 			if errInsert != nil {
 				return SKDRegistrationResponse{}, &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
 			}
@@ -384,22 +1314,25 @@ func (r *Repository) ValidateRegistrateTicket(barcode string, term Terminal, dir
 
 		//reentry
 		entryRecord := Entry{ticket.EventId, ticket.TicketBarcode, term.Id, time.Now().Unix(), ENTRY_RESULT_CODE_REENTRY, direction}
-		errInsert := db.C(ENTRY_COLLECTION).Insert(entryRecord)
+		errInsert := r.AddEntry(entryRecord, ticket, currentEvents.EventById(ticket.EventId), term)
 		if errInsert != nil {
-			log.Println("error")
 			return SKDRegistrationResponse{}, &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
 		}
-
-		log.Println("reentry?")
+		scanLog.Debug("Reentry")
 		return SKDRegistrationResponse{SKDRegistrationResult{ENTRY_RESULT_CODE_REENTRY, false, false}, ticket, currentEvents.EventById(ticket.EventId), entryItem.toAction()}, nil
 	}
-	log.Println("1.2")
 	//Not Found
+	scanLog.Info("Ticket not found")
+	if count, burst := anomalyDetector.NotFound(term.Id, time.Now().Unix()); burst {
+		raiseAnomaly(r, scanLog, Anomaly{Type: ANOMALY_NOTFOUND_BURST, Dt: time.Now().Unix(), TerminalIds: []int64{term.Id}, Count: count,
+			Message: fmt.Sprintf("%d not found scans at gate #%d in %d seconds", count, term.Id, ANOMALY_BURST_WINDOW)}, currentEvents.Events)
+	}
 	ticket.TicketBarcode = barcode
 	return SKDRegistrationResponse{SKDRegistrationResult{ENTRY_RESULT_CODE_NOTFOUND, false, false}, ticket, Event{}, Action{}}, nil
 }
 
 func (r *Repository) RegistrateTicket(barcode string, term Terminal, direction string) (SKDResult, *Exception) {
+	defer observeRepository("RegistrateTicket", time.Now())
 	if masterKeys.is(barcode) { //Master key
 		return SKDResult{ENTRY_RESULT_CODE_ACCEPT}, nil
 	}
@@ -411,11 +1344,19 @@ func (r *Repository) RegistrateTicket(barcode string, term Terminal, direction s
 	if (Ticket{}) != ticket {
 		entryItem := r.CheckTicketForEntry(ticket)
 		entry, exit := getResultForEntry(entryItem)
-		fmt.Println(entry, exit)
+		event := currentEvents.EventById(ticket.EventId)
+		if code, rejected := event.RejectCode(); rejected {
+			entryRecord := Entry{ticket.EventId, ticket.TicketBarcode, term.Id, time.Now().Unix(), code, direction}
+			errInsert := r.AddEntry(entryRecord, ticket, currentEvents.EventById(ticket.EventId), term)
+			if errInsert != nil {
+				return SKDResult{ENTRY_RESULT_CODE_NOTFOUND}, &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
+			}
+			return SKDResult{code}, nil
+		}
 		if entry && direction == "entry" {
 			//Entry allowed
 			entryRecord := Entry{ticket.EventId, ticket.TicketBarcode, term.Id, time.Now().Unix(), ENTRY_RESULT_CODE_ACCEPT, direction}
-			errInsert := db.C(ENTRY_COLLECTION).Insert(entryRecord)
+			errInsert := r.AddEntry(entryRecord, ticket, currentEvents.EventById(ticket.EventId), term)
 			if errInsert != nil {
 				return SKDResult{ENTRY_RESULT_CODE_NOTFOUND}, &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
 			}
@@ -424,7 +1365,7 @@ func (r *Repository) RegistrateTicket(barcode string, term Terminal, direction s
 		if exit && direction == "exit" {
 			//Exit
 			entryRecord := Entry{ticket.EventId, ticket.TicketBarcode, term.Id, time.Now().Unix(), ENTRY_RESULT_CODE_ACCEPT, direction}
-			errInsert := db.C(ENTRY_COLLECTION).Insert(entryRecord)
+			errInsert := r.AddEntry(entryRecord, ticket, currentEvents.EventById(ticket.EventId), term)
 			if errInsert != nil {
 				return SKDResult{ENTRY_RESULT_CODE_NOTFOUND}, &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
 			}
@@ -432,7 +1373,7 @@ func (r *Repository) RegistrateTicket(barcode string, term Terminal, direction s
 		}
 		//reentry
 		entryRecord := Entry{ticket.EventId, ticket.TicketBarcode, term.Id, time.Now().Unix(), ENTRY_RESULT_CODE_REENTRY, direction}
-		errInsert := db.C(ENTRY_COLLECTION).Insert(entryRecord)
+		errInsert := r.AddEntry(entryRecord, ticket, currentEvents.EventById(ticket.EventId), term)
 		if errInsert != nil {
 			return SKDResult{ENTRY_RESULT_CODE_NOTFOUND}, &Exception{CANT_INSERT_EXEPTION, errInsert.Error()}
 		}
@@ -441,12 +1382,62 @@ func (r *Repository) RegistrateTicket(barcode string, term Terminal, direction s
 	//Not Found
 	return SKDResult{ENTRY_RESULT_CODE_NOTFOUND}, nil
 }
+
+// Store entry and push it to live dashboards
+func (r *Repository) AddEntry(entry Entry, ticket Ticket, event Event, term Terminal) error {
+	defer observeRepository("AddEntry", time.Now())
+	var prev Entry
+	errPrev := db.C(ENTRY_COLLECTION).Find(bson.M{"event_id": entry.EventId, "ticket_barcode": entry.TicketBarcode, "terminal_id": bson.M{"$ne": entry.TerminalId},
+		"operation_dt": bson.M{"$gte": entry.OperationDt - ANOMALY_DUPLICATE_WINDOW}}).Sort("-operation_dt").One(&prev)
+	errInsert := db.C(ENTRY_COLLECTION).Insert(EntryRecord{entry, time.Now()})
+	scanLog := scanLogger(r.logger(), term, entry.TicketBarcode, entry.Direction).With("event_id", entry.EventId)
+	if errInsert != nil {
+		scanLog.Error("Can`t insert entry", "result_code", entry.ResultCode, "error", errInsert)
+		return errInsert
+	}
+	scanLog.Debug("Entry stored", "result_code", entry.ResultCode)
+	if errPrev == nil {
+		raiseAnomaly(r, scanLog, duplicateScan(prev, entry), []Event{event})
+	}
+	var inside int64
+	var firstEntry bool
+	if entry.ResultCode == ENTRY_RESULT_CODE_ACCEPT {
+		inside, firstEntry = r.moveInside(entry)
+	}
+	if firstEntry || inside != 0 {
+		r.RollupEntry(entry.EventId, ticket.TicketPrice, firstEntry, inside)
+	}
+	streamHub.PublishScan(ScanResult{entry.EventId, entry.TicketBarcode, term.Id, term.Name, entry.Direction, entry.ResultCode, entry.OperationDt,
+		ticket.TicketSector, ticket.TicketTitle, ticket.TicketPrice}, event)
+	return nil
+}
+
+// Flip inside state of ticket, returns change of inside count (1, -1 or 0 for repeated entry or exit)
+// and whether it is first entry of ticket, state of ticket is created by its first entry
+func (r *Repository) moveInside(entry Entry) (int64, bool) {
+	selector := bson.M{"event_id": entry.EventId, "ticket_barcode": entry.TicketBarcode}
+	if entry.Direction == "entry" {
+		//already inside ticket makes upsert insert a duplicate
+		selector["inside"] = false
+		info, err := db.C(ENTRY_STATE_COLLECTION).Upsert(selector, bson.M{"$set": bson.M{"inside": true}})
+		if err != nil {
+			return 0, false
+		}
+		return 1, info.UpsertedId != nil
+	}
+	selector["inside"] = true
+	if err := db.C(ENTRY_STATE_COLLECTION).Update(selector, bson.M{"$set": bson.M{"inside": false}}); err != nil {
+		return 0, false
+	}
+	return -1, false
+}
 func (r *Repository) GetGroupsByTerminal(terminal Terminal) Groups {
 	groups := Groups{}
 	db.C(GROUPS_COLLECTION).Find(bson.M{"id": bson.M{"$in": terminal.Groups}}).All(&groups.Groups)
 	return groups
 }
 func (r *Repository) GetActiveEventsByGroups(groups Groups) Events {
+	defer observeRepository("GetActiveEventsByGroups", time.Now())
 	timeUnix := time.Now().Unix()
 	events := Events{}
 	db.C(EVENTS_COLLECTION).Find(bson.M{"event_dt": bson.M{"$lte": timeUnix + OPENBEFORE, "$gte": timeUnix - OPENAFTER}, "venue_id": bson.M{"$in": groups.BildingsIds()}, "hall_id": bson.M{"$nin": groups.ExcludeIds()}}).All(&events.Events)
@@ -466,7 +1457,44 @@ func (r *Repository) GetEventById(id int64) Event {
 	db.C(EVENTS_COLLECTION).Find(bson.M{"event_id": id}).One(&event)
 	return event
 }
+func (r *Repository) SetEventStatus(id int64, form EventStatusForm) *Exception {
+	event := r.GetEventById(id)
+	if event.Id == 0 {
+		return &Exception{EVENT_NOT_FOUND_EXEPTION, ""}
+	}
+	switch form.Status {
+	case EVENT_STATUS_AUTO:
+		event.StatusManual = false
+	case EVENT_STATUS_ACTIVE, EVENT_STATUS_CANCELLED, EVENT_STATUS_POSTPONED:
+		event.Status, event.StatusManual = form.Status, true
+	case EVENT_STATUS_RESCHEDULED:
+		if form.Dt == 0 {
+			return &Exception{NOT_ENOUGH_PARAMS, "dt is required to reschedule event"}
+		}
+		if event.OriginalDT == 0 {
+			event.OriginalDT = event.EventDT
+		}
+		event.EventDT = form.Dt
+		event.Status, event.StatusManual = form.Status, true
+	default:
+		return &Exception{PARSE_PARAMS_EXEPTION, "unknown status " + form.Status}
+	}
+	errUpdate := db.C(EVENTS_COLLECTION).Update(bson.M{"event_id": id}, bson.M{"$set": bson.M{"status": event.Status, "status_manual": event.StatusManual, "event_dt": event.EventDT, "original_dt": event.OriginalDT}})
+	if errUpdate != nil {
+		return &Exception{CANT_INSERT_EXEPTION, errUpdate.Error()}
+	}
+	return nil
+}
+
+// Ticket of event for entries grouped by barcode, same barcode of other event or second source is not joined
+func eventTicketLookup(id int64) bson.M {
+	return bson.M{"$lookup": bson.M{"from": TICKETS_COLLECTION, "let": bson.M{"barcode": "$_id"}, "as": "ticket",
+		"pipeline": []bson.M{
+			bson.M{"$match": bson.M{"event_id": id, "$expr": bson.M{"$eq": []interface{}{"$ticket_barcode", "$$barcode"}}}},
+			bson.M{"$limit": 1}}}}
+}
 func (r *Repository) GetEventInfo(id int64) EventInfo {
+	defer observeRepository("GetEventInfo", time.Now())
 	var tickets, entrys []bson.M
 	eventInfo := EventInfo{}
 	pipeTickets := db.C(TICKETS_COLLECTION).Pipe([]bson.M{
@@ -475,7 +1503,7 @@ func (r *Repository) GetEventInfo(id int64) EventInfo {
 	pipeEntry := db.C(ENTRY_COLLECTION).Pipe([]bson.M{
 		bson.M{"$match": bson.M{"event_id": id, "result_code": 1, "direction": "entry"}},
 		bson.M{"$group": bson.M{"_id": "$ticket_barcode"}},
-		bson.M{"$lookup": bson.M{"from": "tickets", "localField": "_id", "foreignField": "ticket_barcode", "as": "ticket"}},
+		eventTicketLookup(id),
 		bson.M{"$unwind": "$ticket"},
 		bson.M{"$group": bson.M{"_id": "$ticket.ticket_price", "count": bson.M{"$sum": 1}}}})
 
@@ -489,18 +1517,374 @@ func (r *Repository) GetEventInfo(id int64) EventInfo {
 	//pipeEntry.One(&resp.Entries)
 	return eventInfo
 }
-func (r *Repository) GetEventsByGroup(groupId int64) Events {
+func (r *Repository) GetGroupById(groupId int64) Group {
 	group := Group{}
-	events := Events{}
 	db.C(GROUPS_COLLECTION).Find(bson.M{"id": groupId}).One(&group)
-	db.C(EVENTS_COLLECTION).Find(bson.M{"venue_id": group.BuildingId, "hall_id": bson.M{"$nin": group.Exclude_halls}}).All(&events.Events)
-	for i, event := range events.Events {
-		//event.TicketsCached = r.GetTicketsCountByEvent(event)
-		events.Events[i] = event
+	return group
+}
+
+// Recount stored stats of event from its tickets and entries
+func (r *Repository) UpdateEventRollup(id int64) EventRollup {
+	defer observeRepository("UpdateEventRollup", time.Now())
+	var rollup EventRollup
+	rollup.fromEventInfo(id, r.GetEventInfo(id))
+	rollup.Inside = r.countInside(id)
+	db.C(EVENT_STATS_COLLECTION).Upsert(bson.M{"event_id": id}, rollup)
+	return rollup
+}
+
+// Count rollups of events which have none yet, a batch per run
+func (r *Repository) BackfillRollups() *Exception {
+	var missing []struct {
+		Id int64 `bson:"event_id"`
+	}
+	errPipe := db.C(EVENTS_COLLECTION).Pipe([]bson.M{
+		bson.M{"$lookup": bson.M{"from": EVENT_STATS_COLLECTION, "localField": "event_id", "foreignField": "event_id", "as": "stats"}},
+		bson.M{"$match": bson.M{"stats": bson.M{"$size": 0}}},
+		bson.M{"$project": bson.M{"event_id": 1}},
+		bson.M{"$limit": ROLLUP_BACKFILL_LIMIT}}).All(&missing)
+	if errPipe != nil {
+		return &Exception{CANT_SELECT_EXEPTION, errPipe.Error()}
+	}
+	for _, event := range missing {
+		r.UpdateEventRollup(event.Id)
+	}
+	return nil
+}
+
+// Count first entry of ticket and change of inside in rollup, recount whole event if price line is unknown
+func (r *Repository) RollupEntry(id int64, price string, first bool, inside int64) {
+	selector := bson.M{"event_id": id}
+	inc := bson.M{"inside": inside}
+	if first {
+		selector["info.price"] = price
+		inc["entry"], inc["info.$.entry"] = 1, 1
+	}
+	errUpdate := db.C(EVENT_STATS_COLLECTION).Update(selector, bson.M{"$inc": inc, "$set": bson.M{"updated": time.Now().Unix()}})
+	if errUpdate != nil {
+		r.UpdateEventRollup(id)
+	}
+}
+
+// Stats of events between dtf and dtt (optionally of one group) from rollups, one aggregation per page
+func (r *Repository) GetStats(dtf, dtt int64, groupId int64, offset int, limit int) ([]EventStats, int, *Exception) {
+	defer observeRepository("GetStats", time.Now())
+	match := bson.M{"event_dt": bson.M{"$gte": dtf, "$lte": dtt}}
+	if groupId != 0 {
+		group := r.GetGroupById(groupId)
+		match["venue_id"] = group.BuildingId
+		match["hall_id"] = bson.M{"$nin": group.Exclude_halls}
+	}
+	var result []struct {
+		Events []struct {
+			Event `bson:",inline"`
+			Stats []EventRollup `bson:"stats"`
+		} `bson:"events"`
+		Count []struct {
+			Count int `bson:"count"`
+		} `bson:"count"`
+	}
+	errPipe := db.C(EVENTS_COLLECTION).Pipe([]bson.M{
+		bson.M{"$match": match},
+		bson.M{"$sort": bson.D{{Name: "event_dt", Value: 1}, {Name: "event_id", Value: 1}}},
+		bson.M{"$facet": bson.M{
+			"events": []bson.M{
+				bson.M{"$skip": offset},
+				bson.M{"$limit": limit},
+				bson.M{"$lookup": bson.M{"from": EVENT_STATS_COLLECTION, "localField": "event_id", "foreignField": "event_id", "as": "stats"}}},
+			"count": []bson.M{bson.M{"$count": "count"}}}}}).All(&result)
+	if errPipe != nil {
+		return nil, 0, &Exception{CANT_SELECT_EXEPTION, errPipe.Error()}
+	}
+	stats := []EventStats{}
+	if len(result) == 0 {
+		return stats, 0, nil
+	}
+	count := 0
+	if len(result[0].Count) > 0 {
+		count = result[0].Count[0].Count
+	}
+	for i, row := range result[0].Events {
+		//missing rollup is counted by maintenance
+		var rollup EventRollup
+		if len(row.Stats) > 0 {
+			rollup = row.Stats[0]
+		}
+		row.Event.localize()
+		stats = append(stats, EventStats{int64(offset + i + 1), row.Id, row.EventDT, row.DtISO, row.Title, rollup.Sell, rollup.Entry, rollup.Total, rollup.Info})
+	}
+	return stats, count, nil
+}
+
+// Live counters of event from its rollup, kept current by each scan
+// All stats of events between dtf and dtt, fetched page by page so files and reports are not cut
+func (r *Repository) AllStats(dtf, dtt int64, groupId int64) ([]EventStats, *Exception) {
+	stats := []EventStats{}
+	for {
+		page, count, ex := r.GetStats(dtf, dtt, groupId, len(stats), STATS_MAX_LIMIT)
+		if ex != nil {
+			return nil, ex
+		}
+		stats = append(stats, page...)
+		if len(page) == 0 || len(stats) >= count {
+			return stats, nil
+		}
+	}
+}
+
+func (r *Repository) GetEventLiveStats(id int64) EventLiveStats {
+	defer observeRepository("GetEventLiveStats", time.Now())
+	var rollup EventRollup
+	if err := db.C(EVENT_STATS_COLLECTION).Find(bson.M{"event_id": id}).One(&rollup); err != nil {
+		rollup = r.UpdateEventRollup(id)
+	}
+	stats := EventLiveStats{EventId: id, Dt: time.Now().Unix(), Sell: rollup.Sell, Entry: rollup.Entry, Inside: rollup.Inside, Info: rollup.Info}
+	if stats.Sell > 0 {
+		stats.Occupancy = float64(stats.Inside) / float64(stats.Sell)
+	}
+	return stats
+}
+
+// Inside are tickets with last accepted action entry
+func (r *Repository) countInside(id int64) int64 {
+	var inside []bson.M
+	db.C(ENTRY_COLLECTION).Pipe([]bson.M{
+		bson.M{"$match": bson.M{"event_id": id, "result_code": ENTRY_RESULT_CODE_ACCEPT}},
+		bson.M{"$sort": bson.M{"operation_dt": 1}},
+		bson.M{"$group": bson.M{"_id": "$ticket_barcode", "direction": bson.M{"$last": "$direction"}}},
+		bson.M{"$match": bson.M{"direction": "entry"}},
+		bson.M{"$count": "inside"}}).All(&inside)
+	if len(inside) > 0 {
+		return bsonInt(inside[0]["inside"])
+	}
+	return 0
+}
+
+// Accepted entries and exits of event bucketed by interval (seconds) per terminal and group
+func (r *Repository) GetEventTimeline(id int64, interval int64) EventTimeline {
+	defer observeRepository("GetEventTimeline", time.Now())
+	event := r.GetEventById(id)
+	loc := LoadLocation(event.Timezone)
+	timeline := EventTimeline{EventId: id, Interval: interval}
+	if event.EventDT != 0 {
+		timeline.DoorsOpen = event.EventDT - OPENBEFORE
+		timeline.DoorsOpenISO = IsoTime(timeline.DoorsOpen, loc)
+	}
+	bucket := func(interval int64) bson.M {
+		return bson.M{"$subtract": []interface{}{"$operation_dt", bson.M{"$mod": []interface{}{"$operation_dt", interval}}}}
+	}
+	var rows []timelineRow
+	db.C(ENTRY_COLLECTION).Pipe([]bson.M{
+		bson.M{"$match": bson.M{"event_id": id, "result_code": ENTRY_RESULT_CODE_ACCEPT}},
+		bson.M{"$group": bson.M{"_id": bson.M{"bucket": bucket(interval), "terminal_id": "$terminal_id", "direction": "$direction"}, "count": bson.M{"$sum": 1}}}}).All(&rows)
+	var terminals []Terminal
+	var groups []Group
+	db.C(TERMINALS_COLLECTION).Find(nil).All(&terminals)
+	db.C(GROUPS_COLLECTION).Find(nil).All(&groups)
+	timeline.fromRows(rows, terminals, groups, loc)
+
+	var peak []bson.M
+	db.C(ENTRY_COLLECTION).Pipe([]bson.M{
+		bson.M{"$match": bson.M{"event_id": id, "result_code": ENTRY_RESULT_CODE_ACCEPT, "direction": "entry"}},
+		bson.M{"$group": bson.M{"_id": bucket(60), "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"count": -1, "_id": 1}},
+		bson.M{"$limit": 1}}).All(&peak)
+	if len(peak) > 0 {
+		dt, count := bsonInt(peak[0]["_id"]), bsonInt(peak[0]["count"])
+		timeline.PeakMinute = TimelineBucket{Dt: dt, DtISO: IsoTime(dt, loc), Entry: count}
 	}
+
+	var firsts []struct {
+		First int64 `bson:"first"`
+	}
+	db.C(ENTRY_COLLECTION).Pipe([]bson.M{
+		bson.M{"$match": bson.M{"event_id": id, "result_code": ENTRY_RESULT_CODE_ACCEPT, "direction": "entry"}},
+		bson.M{"$group": bson.M{"_id": "$ticket_barcode", "first": bson.M{"$min": "$operation_dt"}}}}).All(&firsts)
+	firstEntries := []int64{}
+	for _, first := range firsts {
+		firstEntries = append(firstEntries, first.First)
+	}
+	timeline.fromFirstEntries(firstEntries)
+	return timeline
+}
+
+// Duplicate scans at different gates, not found bursts on event gates and places sold twice
+func (r *Repository) GetEventAnomalies(id int64) (EventAnomalies, *Exception) {
+	defer observeRepository("GetEventAnomalies", time.Now())
+	result := EventAnomalies{EventId: id, Counts: map[string]int{}, Anomalies: []Anomaly{}}
+	event := r.GetEventById(id)
+	if event.Id == 0 {
+		return result, &Exception{EVENT_NOT_FOUND_EXEPTION, "event " + strconv.FormatInt(id, 10)}
+	}
+	loc := LoadLocation(event.Timezone)
+
+	var entries []Entry
+	errEntries := db.C(ENTRY_COLLECTION).Find(bson.M{"event_id": id}).Sort("ticket_barcode", "operation_dt").All(&entries)
+	if errEntries != nil {
+		return result, &Exception{CANT_SELECT_EXEPTION, errEntries.Error()}
+	}
+	result.add(DuplicateScans(entries), loc)
+
+	//Not found scans have no event, take scans on gates of event groups while doors are open
+	var groups []Group
+	db.C(GROUPS_COLLECTION).Find(bson.M{"building_id": event.VenueId, "exclude_halls": bson.M{"$ne": event.HallId}}).All(&groups)
+	groupIds := []int64{}
+	for _, group := range groups {
+		groupIds = append(groupIds, group.Id)
+	}
+	var terminals []Terminal
+	db.C(TERMINALS_COLLECTION).Find(bson.M{"groups": bson.M{"$in": groupIds}}).All(&terminals)
+	terminalIds := map[int64]bool{}
+	for _, terminal := range terminals {
+		terminalIds[terminal.Id] = true
+	}
+	var logs []Log
+	errLogs := db.C(LOGS_COLLECTION).Find(bson.M{"code": ENTRY_RESULT_CODE_NOTFOUND, "message": bson.M{"$regex": "^Result for"},
+		"dt": bson.M{"$gte": event.EventDT - OPENBEFORE, "$lte": event.EventDT + OPENAFTER}}).All(&logs)
+	if errLogs != nil {
+		return result, &Exception{CANT_SELECT_EXEPTION, errLogs.Error()}
+	}
+	result.add(NotFoundBursts(notFoundFromLogs(logs, terminalIds)), loc)
+
+	var tickets []TicketExport
+	errTickets := db.C(TICKETS_COLLECTION).Find(bson.M{"event_id": id, "place_id": bson.M{"$ne": 0}}).All(&tickets)
+	if errTickets != nil {
+		return result, &Exception{CANT_SELECT_EXEPTION, errTickets.Error()}
+	}
+	result.add(SharedPlaces(tickets), loc)
+	return result, nil
+}
+
+// Sell-through and attendance of events between dtf and dtt by building, hall or show
+func (r *Repository) GetComparison(dtf, dtt int64, groupId int64, by string) ([]ComparisonLine, *Exception) {
+	defer observeRepository("GetComparison", time.Now())
+	if by != COMPARE_BY_BUILDING && by != COMPARE_BY_HALL && by != COMPARE_BY_SHOW {
+		return nil, &Exception{PARSE_PARAMS_EXEPTION, "unknown comparison " + by}
+	}
+	selector := bson.M{"event_dt": bson.M{"$gte": dtf, "$lte": dtt}}
+	if groupId != 0 {
+		group := r.GetGroupById(groupId)
+		selector["venue_id"] = group.BuildingId
+		selector["hall_id"] = bson.M{"$nin": group.Exclude_halls}
+	}
+	var events []Event
+	errEvents := db.C(EVENTS_COLLECTION).Find(selector).All(&events)
+	if errEvents != nil {
+		return nil, &Exception{CANT_SELECT_EXEPTION, errEvents.Error()}
+	}
+	ids := []int64{}
+	for _, event := range events {
+		ids = append(ids, event.Id)
+	}
+	var rollups []EventRollup
+	errRollups := db.C(EVENT_STATS_COLLECTION).Find(bson.M{"event_id": bson.M{"$in": ids}}).All(&rollups)
+	if errRollups != nil {
+		return nil, &Exception{CANT_SELECT_EXEPTION, errRollups.Error()}
+	}
+	rollupById := map[int64]EventRollup{}
+	for _, rollup := range rollups {
+		rollupById[rollup.EventId] = rollup
+	}
+	return compareEvents(events, rollupById, by), nil
+}
+
+// Sold tickets of event with no accepted entry
+func (r *Repository) GetEventNoShows(id int64, filter NoShowFilter) (EventNoShows, *Exception) {
+	defer observeRepository("GetEventNoShows", time.Now())
+	result := EventNoShows{EventId: id, Tickets: []NoShow{}}
+	var entered []string
+	errEntered := db.C(ENTRY_COLLECTION).Find(bson.M{"event_id": id, "result_code": ENTRY_RESULT_CODE_ACCEPT, "direction": "entry"}).Distinct("ticket_barcode", &entered)
+	if errEntered != nil {
+		return result, &Exception{CANT_SELECT_EXEPTION, errEntered.Error()}
+	}
+	enteredMap := map[string]bool{}
+	for _, barcode := range entered {
+		enteredMap[barcode] = true
+	}
+	selector := bson.M{"event_id": id}
+	if filter.Sector != "" {
+		selector["ticket_sector"] = filter.Sector
+	}
+	if filter.Source != "" {
+		selector["source"] = filter.Source
+	}
+	var tickets []TicketExport
+	errTickets := db.C(TICKETS_COLLECTION).Find(selector).Sort("ticket_sector", "ticket_title").All(&tickets)
+	if errTickets != nil {
+		return result, &Exception{CANT_SELECT_EXEPTION, errTickets.Error()}
+	}
+	result.fromTickets(tickets, enteredMap, filter)
+	return result, nil
+}
+
+var breakdownFields = map[string]string{
+	BREAKDOWN_PRICE:    "$ticket_price",
+	BREAKDOWN_SECTOR:   "$ticket_sector",
+	BREAKDOWN_CASHBOX:  "$cashbox_title",
+	BREAKDOWN_OPERATOR: "$operator_title",
+	BREAKDOWN_ETICKET:  "$is_eticket",
+}
+
+// Sold and entered tickets with money totals by sale channel dimensions
+func (r *Repository) GetEventBreakdown(id int64, dimensions []string) (EventBreakdown, *Exception) {
+	defer observeRepository("GetEventBreakdown", time.Now())
+	result := EventBreakdown{EventId: id, Breakdowns: []Breakdown{}}
+	for _, dimension := range dimensions {
+		field, ok := breakdownFields[dimension]
+		if !ok {
+			return result, &Exception{PARSE_PARAMS_EXEPTION, "unknown dimension " + dimension}
+		}
+		var tickets, entries []breakdownRow
+		errTickets := db.C(TICKETS_COLLECTION).Pipe([]bson.M{
+			bson.M{"$match": bson.M{"event_id": id}},
+			bson.M{"$group": bson.M{"_id": bson.M{"key": field, "price": "$ticket_price"}, "count": bson.M{"$sum": 1}}}}).All(&tickets)
+		if errTickets != nil {
+			return result, &Exception{CANT_SELECT_EXEPTION, errTickets.Error()}
+		}
+		errEntries := db.C(ENTRY_COLLECTION).Pipe([]bson.M{
+			bson.M{"$match": bson.M{"event_id": id, "result_code": ENTRY_RESULT_CODE_ACCEPT, "direction": "entry"}},
+			bson.M{"$group": bson.M{"_id": "$ticket_barcode"}},
+			eventTicketLookup(id),
+			bson.M{"$unwind": "$ticket"},
+			bson.M{"$group": bson.M{"_id": bson.M{"key": "$ticket." + field[1:], "price": "$ticket.ticket_price"}, "count": bson.M{"$sum": 1}}}}).All(&entries)
+		if errEntries != nil {
+			return result, &Exception{CANT_SELECT_EXEPTION, errEntries.Error()}
+		}
+		breakdown := Breakdown{Dimension: dimension}
+		breakdown.fromRows(tickets, entries)
+		result.Breakdowns = append(result.Breakdowns, breakdown)
+	}
+	return result, nil
+}
+
+// Events of group between dtf and dtt, zero means no bound
+func (r *Repository) GetEventsByGroup(groupId int64, dtf, dtt int64) Events {
+	defer observeRepository("GetEventsByGroup", time.Now())
+	group := r.GetGroupById(groupId)
+	events := Events{}
+	query := bson.M{"venue_id": group.BuildingId, "hall_id": bson.M{"$nin": group.Exclude_halls}}
+	if dtf != 0 || dtt != 0 {
+		dtQuery := bson.M{}
+		if dtf != 0 {
+			dtQuery["$gte"] = dtf
+		}
+		if dtt != 0 {
+			dtQuery["$lte"] = dtt
+		}
+		query["event_dt"] = dtQuery
+	}
+	db.C(EVENTS_COLLECTION).Find(query).All(&events.Events)
+	for i := range events.Events {
+		//fallback to group timezone
+		if events.Events[i].Timezone == "" {
+			events.Events[i].Timezone = group.Timezone
+		}
+	}
+	events.localize()
 	return events
 }
 func (r *Repository) GetEventsByDt(dtf, dtt int64) Events {
+	defer observeRepository("GetEventsByDt", time.Now())
 	events := Events{}
 	db.C(EVENTS_COLLECTION).Find(bson.M{"event_dt": bson.M{"$lte": dtt, "$gte": dtf}}).All(&events.Events)
 	return events
@@ -511,10 +1895,16 @@ func (r *Repository) GetTicketsCountByEvent(event Event) int {
 	return ticketsCount
 }
 func (r *Repository) GetTerminalById(terminalId int64) Terminal {
+	defer observeRepository("GetTerminalById", time.Now())
 	term := Terminal{}
 	db.C(TERMINALS_COLLECTION).Find(bson.M{"id": terminalId}).One(&term)
 	return term
 }
+func (r *Repository) GetTerminalByName(name string) Terminal {
+	term := Terminal{}
+	db.C(TERMINALS_COLLECTION).Find(bson.M{"name": name}).One(&term)
+	return term
+}
 func (r *Repository) GetAuthTerminalById(terminalId int64) AuthStruct {
 	term := AuthStruct{}
 	db.C(TERMINALS_COLLECTION).Find(bson.M{"id": terminalId}).One(&term.Auth)
@@ -534,6 +1924,7 @@ func (r *Repository) TicketEntryFirstTime(ticket Ticket) int64 {
 	return NullIsNow(entry.OperationDt)
 }
 func (r *Repository) CheckTicket(check CheckTiket) CheckResult {
+	defer observeRepository("CheckTicket", time.Now())
 	ticket := Ticket{}
 	var entry []bson.M
 	db.C(TICKETS_COLLECTION).Find(bson.M{"ticket_barcode": check.Barcode}).One(&ticket)
@@ -545,8 +1936,8 @@ func (r *Repository) CheckTicket(check CheckTiket) CheckResult {
 
 	//db.C(ENTRY_COLLECTION).Find(bson.M{"ticket_barcode": check.Barcode}).Sort("operation_dt").All(&entry)
 
-	fmt.Println(entry)
 	event := r.GetEventById(ticket.EventId)
+	event.localize()
 	ticket.TicketBarcode = check.Barcode
 	return CheckResult{event, ticket, entry}
 }
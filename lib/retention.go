@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const RETENTION_MODE_TTL = "ttl"
+const RETENTION_MODE_ARCHIVE = "archive"
+
+// Days to keep and mode per collection, e.g. RETENTION_LOGS_DAYS, RETENTION_ENTRY_MODE
+const RETENTION_ENV_PREFIX = "RETENTION_"
+
+// Directory for archives, archive mode deletes nothing if not set
+const RETENTION_ARCHIVE_DIR_ENV = "RETENTION_ARCHIVE_DIR"
+
+const RETENTION_TTL_INDEX = "retention_ttl"
+
+// Seconds between runs for one collection, runs go on without pause while a run hits the limit
+const RETENTION_RUN_INTERVAL = 3600
+
+// Records archived by one run, ids of them are held in memory
+const RETENTION_ARCHIVE_LIMIT = 10000
+
+// Runs shown by /retention
+const RETENTION_RUNS_SHOWN = 50
+
+type RetentionPolicy struct {
+	Collection string `json:"collection"`
+	Days       int    `json:"days"`
+	Mode       string `json:"mode"`
+	DtField    string `json:"dt_field"`
+	ArchiveDir string `json:"archive_dir,omitempty"`
+}
+
+// Collections with retention and their unix time field
+var retentionCollections = []RetentionPolicy{
+	{Collection: LOGS_COLLECTION, DtField: "dt"},
+	{Collection: ENTRY_COLLECTION, DtField: "operation_dt"},
+}
+
+// Policies from env, 0 days keeps forever
+func retentionPolicies() []RetentionPolicy {
+	policies := []RetentionPolicy{}
+	for _, policy := range retentionCollections {
+		name := RETENTION_ENV_PREFIX + strings.ToUpper(policy.Collection)
+		policy.Days, _ = strconv.Atoi(os.Getenv(name + "_DAYS"))
+		if policy.Days < 0 {
+			policy.Days = 0
+		}
+		policy.Mode = strings.ToLower(os.Getenv(name + "_MODE"))
+		if policy.Mode != RETENTION_MODE_ARCHIVE {
+			policy.Mode = RETENTION_MODE_TTL
+		}
+		if policy.Mode == RETENTION_MODE_ARCHIVE {
+			policy.ArchiveDir = os.Getenv(RETENTION_ARCHIVE_DIR_ENV)
+		}
+		policies = append(policies, policy)
+	}
+	return policies
+}
+
+func (p RetentionPolicy) cutoff(now time.Time) int64 {
+	return now.AddDate(0, 0, -p.Days).Unix()
+}
+
+func (p RetentionPolicy) ttl() time.Duration {
+	return time.Duration(p.Days) * 24 * time.Hour
+}
+
+func (p RetentionPolicy) isDue(last RetentionRun, now time.Time) bool {
+	if p.Days == 0 {
+		return false
+	}
+	return now.Unix()-last.Dt >= RETENTION_RUN_INTERVAL || last.Archived >= RETENTION_ARCHIVE_LIMIT
+}
+
+// Gzipped ndjson, one record per line
+type archiveWriter struct {
+	path string
+	file *os.File
+	gzip *gzip.Writer
+	json *json.Encoder
+}
+
+func newArchiveWriter(dir string, collection string, now time.Time) (*archiveWriter, error) {
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.ndjson.gz", collection, now.Format("20060102_150405")))
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	writer := gzip.NewWriter(file)
+	return &archiveWriter{path, file, writer, json.NewEncoder(writer)}, nil
+}
+
+func (w *archiveWriter) Write(record interface{}) error {
+	return w.json.Encode(record)
+}
+
+// Flushed to disk, records may be deleted only after that
+func (w *archiveWriter) Close() error {
+	if err := w.gzip.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// Remove archive without records or failed one
+func (w *archiveWriter) Discard() {
+	w.file.Close()
+	os.Remove(w.path)
+}
@@ -14,136 +14,354 @@ type Route struct {
 	Hadle       string
 	Pattern     string
 	HandlerFunc http.HandlerFunc
+	Permission  string
 }
 type Routes []Route
 
 var routes = Routes{
 
+	Route{
+		"Metrics",
+		"GET",
+		"", "",
+		"/metrics", MetricsHandler,
+		PERMISSION_SIGNED,
+	},
 	Route{
 		"Logs",
 		"Get",
 		"", "",
-		"/logs", AuthenticationMiddleware(controller.LogsHandler),
+		"/logs", controller.LogsHandler,
+		PERMISSION_LOGS_READ,
+	},
+	Route{
+		"Entries",
+		"GET",
+		"", "",
+		"/entries", controller.EntriesHandler,
+		PERMISSION_LOGS_READ,
+	},
+	Route{
+		"Retention",
+		"GET",
+		"", "",
+		"/retention", controller.RetentionHandler,
+		PERMISSION_SYSTEM,
+	},
+	Route{
+		"Audit",
+		"GET",
+		"", "",
+		"/audit", controller.AuditHandler,
+		PERMISSION_SYSTEM,
 	},
 	Route{
 		"Login",
 		"POST",
 		"", "",
 		"/login", controller.LoginHandler,
+		PERMISSION_PUBLIC,
 	},
 	Route{
 		"Logout",
-		"GET",
+		"POST",
 		"", "",
-		"/logout", AuthenticationMiddleware(controller.LogoutHandler),
+		"/logout", controller.LogoutHandler,
+		PERMISSION_AUTHENTICATED,
 	},
 	Route{
 		"Stats",
 		"POST",
 		"", "",
 		"/stats", controller.StatsHandler,
+		PERMISSION_STATS_READ,
+	},
+	Route{
+		"Compare",
+		"GET",
+		"", "",
+		"/compare", controller.CompareHandler,
+		PERMISSION_STATS_READ,
 	},
 	Route{
 		"AddUser",
 		"POST",
 		"", "",
-		"/add_user", AuthenticationMiddleware(controller.AddUserHandler),
+		"/add_user", controller.AddUserHandler,
+		PERMISSION_USERS_WRITE,
+	},
+	Route{
+		"Users",
+		"GET",
+		"", "",
+		"/users", controller.UsersHandler,
+		PERMISSION_USERS_READ,
+	},
+	Route{
+		"SetUser",
+		"POST",
+		"", "",
+		"/set_user", controller.SetUserHandler,
+		PERMISSION_USERS_WRITE,
+	},
+	Route{
+		"RemoveUser",
+		"POST",
+		"", "",
+		"/remove_user", controller.RemoveUserHandler,
+		PERMISSION_USERS_WRITE,
+	},
+	Route{
+		"SetUserPassword",
+		"POST",
+		"", "",
+		"/set_user_password", controller.SetUserPasswordHandler,
+		PERMISSION_USERS_WRITE,
+	},
+	Route{
+		"ChangePassword",
+		"POST",
+		"", "",
+		"/change_password", controller.ChangePasswordHandler,
+		PERMISSION_AUTHENTICATED,
+	},
+	Route{
+		"Refresh",
+		"POST",
+		"", "",
+		"/refresh", controller.RefreshHandler,
+		PERMISSION_SIGNED,
+	},
+	Route{
+		"Sessions",
+		"GET",
+		"", "",
+		"/sessions", controller.SessionsHandler,
+		PERMISSION_AUTHENTICATED,
+	},
+	Route{
+		"RevokeSession",
+		"POST",
+		"", "",
+		"/revoke_session", controller.RevokeSessionHandler,
+		PERMISSION_AUTHENTICATED,
+	},
+	Route{
+		"RevokeSessions",
+		"POST",
+		"", "",
+		"/revoke_sessions", controller.RevokeSessionsHandler,
+		PERMISSION_AUTHENTICATED,
 	},
 	Route{
 		"Terminals",
 		"GET",
 		"", "",
-		"/terminals", AuthenticationMiddleware(controller.Terminals),
+		"/terminals", controller.Terminals,
+		PERMISSION_TERMINALS_READ,
 	},
 	Route{
 		"Terminals",
 		"POST",
 		"", "",
-		"/add_terminal", AuthenticationMiddleware(controller.AddTerminalHandler),
+		"/add_terminal", controller.AddTerminalHandler,
+		PERMISSION_TERMINALS_WRITE,
 	},
 	Route{
 		"CheckTicket",
 		"POST",
 		"", "",
 		"/check_ticket", controller.CheckTicketHandler,
+		PERMISSION_TICKETS_CHECK,
 	},
 	Route{
 		"TerminalSet",
 		"POST",
 		"", "",
 		"/terminal/{id}", controller.TerminalSet,
+		PERMISSION_TERMINALS_WRITE,
 	},
 	Route{
 		"TerminalsAuth",
 		"GET",
 		"", "",
-		"/terminal/{id}/auth.png", AuthenticationMiddleware(controller.TerimalAuthPng),
+		"/terminal/{id}/auth.png", controller.TerimalAuthPng,
+		PERMISSION_TERMINALS_WRITE,
 	},
 	Route{
 		"INIT",
-		"GET",
+		"POST",
 		"", "",
 		"/init", controller.InitInstance,
+		PERMISSION_SIGNED,
 	},
 	Route{
 		"Groups",
 		"GET",
 		"", "",
-		"/groups", AuthenticationMiddleware(controller.Groups),
+		"/groups", controller.Groups,
+		PERMISSION_STATS_READ,
 	},
 	Route{
 		"EventsByGroup",
 		"GET",
 		"", "",
-		"/events/{id}", AuthenticationMiddleware(controller.EventsByGroupHandler),
+		"/events/{id}", controller.EventsByGroupHandler,
+		PERMISSION_STATS_READ,
 	},
 	Route{
 		"EventsInfo",
 		"GET",
 		"", "",
 		"/event/{id}/info", controller.EventInfo,
+		PERMISSION_STATS_READ,
 	},
 	Route{
 		"EventSync",
 		"GET",
 		"", "",
 		"/event/{id}/sync", controller.EventSync,
+		PERMISSION_EVENTS_SYNC,
+	},
+	Route{
+		"EventSyncHistory",
+		"GET",
+		"", "",
+		"/event/{id}/sync/history", controller.EventSyncHistory,
+		PERMISSION_STATS_READ,
+	},
+	Route{
+		"EventBreakdown",
+		"GET",
+		"", "",
+		"/event/{id}/breakdown", controller.EventBreakdownHandler,
+		PERMISSION_STATS_READ,
+	},
+	Route{
+		"EventNoShow",
+		"GET",
+		"", "",
+		"/event/{id}/noshow", controller.EventNoShowHandler,
+		PERMISSION_STATS_READ,
+	},
+	Route{
+		"EventAnomalies",
+		"GET",
+		"", "",
+		"/event/{id}/anomalies", controller.EventAnomaliesHandler,
+		PERMISSION_LOGS_READ,
+	},
+	Route{
+		"EventTimeline",
+		"GET",
+		"", "",
+		"/event/{id}/timeline", controller.EventTimelineHandler,
+		PERMISSION_STATS_READ,
+	},
+	Route{
+		"EventStream",
+		"GET",
+		"", "",
+		"/event/{id}/stream", controller.EventStream,
+		PERMISSION_STATS_READ,
+	},
+	Route{
+		"GroupStream",
+		"GET",
+		"", "",
+		"/group/{id}/stream", controller.GroupStream,
+		PERMISSION_STATS_READ,
+	},
+	Route{
+		"EventStatus",
+		"POST",
+		"", "",
+		"/event/{id}/status", controller.EventStatusHandler,
+		PERMISSION_EVENTS_WRITE,
+	},
+	Route{
+		"ImportTickets",
+		"POST",
+		"", "",
+		"/event/{id}/import", controller.ImportTicketsHandler,
+		PERMISSION_EVENTS_WRITE,
 	},
 	Route{
 		"AddGroup",
 		"POST",
 		"", "",
-		"/add_group", AuthenticationMiddleware(controller.AddGroupHandler),
+		"/add_group", controller.AddGroupHandler,
+		PERMISSION_GROUPS_WRITE,
 	},
 	Route{
 		"AddMasterKey",
 		"POST",
 		"", "",
-		"/add_masterkey", AuthenticationMiddleware(controller.AddMasterKeyHandler),
+		"/add_masterkey", controller.AddMasterKeyHandler,
+		PERMISSION_MASTERKEYS_WRITE,
 	},
 	Route{
 		"SetGroup",
 		"POST",
 		"", "",
-		"/set_group", AuthenticationMiddleware(controller.SetGroupHandler),
+		"/set_group", controller.SetGroupHandler,
+		PERMISSION_GROUPS_WRITE,
 	},
 	Route{
 		"RemoveGroup",
 		"POST",
 		"", "",
-		"/remove_group", AuthenticationMiddleware(controller.RemoveGroupHandler),
+		"/remove_group", controller.RemoveGroupHandler,
+		PERMISSION_GROUPS_WRITE,
+	},
+	Route{
+		"Reports",
+		"GET",
+		"", "",
+		"/reports", controller.ReportsHandler,
+		PERMISSION_REPORTS_READ,
+	},
+	Route{
+		"AddReport",
+		"POST",
+		"", "",
+		"/add_report", controller.AddReportHandler,
+		PERMISSION_REPORTS_WRITE,
+	},
+	Route{
+		"SetReport",
+		"POST",
+		"", "",
+		"/set_report", controller.SetReportHandler,
+		PERMISSION_REPORTS_WRITE,
+	},
+	Route{
+		"RemoveReport",
+		"POST",
+		"", "",
+		"/remove_report", controller.RemoveReportHandler,
+		PERMISSION_REPORTS_WRITE,
+	},
+	Route{
+		"RunReport",
+		"POST",
+		"", "",
+		"/report/{id}/run", controller.RunReportHandler,
+		PERMISSION_REPORTS_WRITE,
 	},
 	Route{
 		"Buildings",
 		"get",
 		"", "",
 		"/buildings", controller.GetBuildings,
+		PERMISSION_STATS_READ,
 	},
 	Route{
 		"SQL",
 		"POST",
 		"", "",
-		"/sql", AuthenticationMiddleware(controller.SqlHandler),
+		"/sql", controller.SqlHandler,
+		PERMISSION_SYSTEM,
 	},
 	Route{
 		"Validation",
@@ -151,6 +369,7 @@ var routes = Routes{
 		"sign", "{sign}",
 		"/validation/{gate}/{ticket}",
 		controller.Validation,
+		PERMISSION_SIGNED,
 	},
 	Route{
 		"ValidationRegistration",
@@ -158,6 +377,7 @@ var routes = Routes{
 		"sign", "{sign}",
 		"/validation/{gate}/{direction:entry|exit}/{ticket}",
 		controller.ValidationRegistration,
+		PERMISSION_SIGNED,
 	},
 	Route{
 		"Registration",
@@ -165,6 +385,15 @@ var routes = Routes{
 		"sign", "{sign}",
 		"/registration/{gate}/{direction:entry|exit}/{ticket}",
 		controller.Registration,
+		PERMISSION_SIGNED,
+	},
+	Route{
+		"TicketWebhook",
+		"POST",
+		"", "",
+		"/webhook/ticket",
+		controller.TicketWebhookHandler,
+		PERMISSION_SIGNED,
 	},
 	Route{
 		"Request",
@@ -172,6 +401,7 @@ var routes = Routes{
 		"", "",
 		"/request",
 		controller.Request,
+		PERMISSION_SIGNED,
 	},
 }
 
@@ -179,7 +409,7 @@ func NewRouter() *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
 	for _, route := range routes {
 		var handler http.Handler
-		handler = route.HandlerFunc
+		handler = loggingMiddleware(instrumentRoute(route, Authorize(route)))
 		if route.Queries == "" {
 			router.
 				Methods(route.Method).
@@ -0,0 +1,119 @@
+package lib
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/gorilla/context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Access token lives short, refresh token renews it while session is not revoked
+const ACCESS_TOKEN_TTL = 15 * time.Minute
+const REFRESH_TOKEN_TTL = 30 * 24 * time.Hour
+
+// Refresh token is sent only to /refresh and never to scripts or other sites
+const REFRESH_COOKIE = "refresh_token"
+const REFRESH_COOKIE_PATH = "/refresh"
+
+func randomHex(size int) string {
+	token := make([]byte, size)
+	rand.Read(token)
+	return hex.EncodeToString(token)
+}
+
+// Only hash is stored, so database dump does not give working refresh tokens
+func hashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (s Session) Active(now time.Time) bool {
+	return s.Id != "" && s.Revoked == 0 && s.Expires.After(now)
+}
+
+// Access token does not outlive its session
+func accessExpires(session Session, now time.Time) time.Time {
+	expires := now.Add(ACCESS_TOKEN_TTL)
+	if session.Expires.Before(expires) {
+		return session.Expires
+	}
+	return expires
+}
+
+func newSession(req *http.Request, login string, now time.Time) (Session, string) {
+	refreshToken := randomHex(32)
+	session := Session{
+		Id:          randomHex(16),
+		Login:       login,
+		Created:     now.Unix(),
+		LastRefresh: now.Unix(),
+		Expires:     now.Add(REFRESH_TOKEN_TTL),
+		Ip:          clientIp(req),
+		UserAgent:   req.UserAgent(),
+		RefreshHash: hashRefreshToken(refreshToken),
+	}
+	return session, refreshToken
+}
+
+// Session of checked token
+func requestSession(req *http.Request) Session {
+	session, _ := context.Get(req, "session").(Session)
+	return session
+}
+
+// Refresh token is kept only in cookie, as access token
+func requestRefreshToken(req *http.Request) string {
+	if cookie, err := req.Cookie(REFRESH_COOKIE); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// Secure cookies over https, directly or behind trusted proxy
+func secureRequest(req *http.Request) bool {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return req.TLS != nil || isTrustedProxy(host) && req.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func tokenCookie(req *http.Request, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{Name: "token", Value: value, Expires: expires,
+		HttpOnly: true, Secure: secureRequest(req), SameSite: http.SameSiteStrictMode}
+}
+
+func refreshCookie(req *http.Request, value string, expires time.Time) *http.Cookie {
+	return &http.Cookie{Name: REFRESH_COOKIE, Value: value, Path: REFRESH_COOKIE_PATH, Expires: expires,
+		HttpOnly: true, Secure: secureRequest(req), SameSite: http.SameSiteStrictMode}
+}
+
+// New session on login or password change
+func startSession(w http.ResponseWriter, r *http.Request, user User) {
+	session, refreshToken := newSession(r, user.Login, time.Now())
+	if ex := repository.AddSession(session); ex != nil {
+		respondWithJson(w, http.StatusInternalServerError, ex)
+		return
+	}
+	respondWithToken(w, r, user, session, refreshToken)
+}
+
+func respondWithToken(w http.ResponseWriter, r *http.Request, user User, session Session, refreshToken string) {
+	expires := accessExpires(session, time.Now())
+	tokenString, errorToken := NewToken(user, session.Id, expires)
+	if errorToken != nil {
+		requestLogger(r).Error("Can`t sign token", "username", user.Login, "error", errorToken)
+	}
+	http.SetCookie(w, tokenCookie(r, tokenString, expires))
+	http.SetCookie(w, refreshCookie(r, refreshToken, session.Expires))
+	json.NewEncoder(w).Encode(JwtToken{Token: tokenString, Expires: expires.Unix(), RefreshExpires: session.Expires.Unix()})
+}
+
+func clearTokenCookies(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, tokenCookie(r, "", time.Now()))
+	http.SetCookie(w, refreshCookie(r, "", time.Now()))
+}
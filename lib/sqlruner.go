@@ -3,16 +3,15 @@ package lib
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
 	_ "github.com/lib/pq"
-	"log"
 )
 
 func RunMe(connStr string, query string) string {
-	log.Println(query)
+	logger.Debug("Run sql", "query", query)
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		log.Fatal(err)
+		logger.Error("Can`t open sql connection", "error", err)
+		return ""
 	}
 	defer db.Close()
 
@@ -52,7 +51,6 @@ func RunMe(connStr string, query string) string {
 	if err != nil {
 		return ""
 	}
-	fmt.Println(string(jsonData))
 	return string(jsonData)
 
 }
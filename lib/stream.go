@@ -0,0 +1,181 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const STREAM_MESSAGE_STATS = "stats"
+const STREAM_MESSAGE_SCAN = "scan"
+const STREAM_MESSAGE_ANOMALY = "anomaly"
+
+// Stats are recounted not more often than that, however many scans come
+const STREAM_STATS_INTERVAL = 2 * time.Second
+const STREAM_KEEPALIVE_INTERVAL = 15 * time.Second
+const STREAM_BUFFER = 64
+
+type StreamMessage struct {
+	Type    string
+	EventId int64
+	Data    interface{}
+}
+
+type streamSubscriber struct {
+	eventId  int64
+	group    *Group
+	messages chan StreamMessage
+}
+
+func (r *streamSubscriber) watches(event Event) bool {
+	if r.group == nil {
+		return r.eventId == event.Id
+	}
+	if event.VenueId != r.group.BuildingId {
+		return false
+	}
+	for _, hall := range r.group.Exclude_halls {
+		if hall == event.HallId {
+			return false
+		}
+	}
+	return true
+}
+
+// Fan out scans and recounted stats to dashboard streams
+type StreamHub struct {
+	mutex       sync.Mutex
+	subscribers map[*streamSubscriber]bool
+	dirty       map[int64]Event
+}
+
+var streamHub = NewStreamHub()
+
+func NewStreamHub() *StreamHub {
+	return &StreamHub{subscribers: map[*streamSubscriber]bool{}, dirty: map[int64]Event{}}
+}
+
+func (h *StreamHub) subscribe(subscriber *streamSubscriber) *streamSubscriber {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	subscriber.messages = make(chan StreamMessage, STREAM_BUFFER)
+	h.subscribers[subscriber] = true
+	return subscriber
+}
+func (h *StreamHub) unsubscribe(subscriber *streamSubscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.subscribers, subscriber)
+}
+func (h *StreamHub) watchers(event Event) []*streamSubscriber {
+	watchers := []*streamSubscriber{}
+	for subscriber := range h.subscribers {
+		if subscriber.watches(event) {
+			watchers = append(watchers, subscriber)
+		}
+	}
+	return watchers
+}
+func (h *StreamHub) send(watchers []*streamSubscriber, message StreamMessage) {
+	for _, subscriber := range watchers {
+		select {
+		case subscriber.messages <- message:
+		default:
+			//slow client, drop message
+		}
+	}
+}
+
+// Push scan now and recount event stats on next tick
+func (h *StreamHub) PublishScan(scan ScanResult, event Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	watchers := h.watchers(event)
+	if len(watchers) == 0 {
+		return
+	}
+	h.send(watchers, StreamMessage{STREAM_MESSAGE_SCAN, event.Id, scan})
+	h.dirty[event.Id] = event
+}
+
+func (h *StreamHub) PublishAnomaly(anomaly Anomaly, event Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.send(h.watchers(event), StreamMessage{STREAM_MESSAGE_ANOMALY, event.Id, anomaly})
+}
+
+// Event tickets or entries changed
+func (h *StreamHub) MarkDirty(event Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if len(h.watchers(event)) > 0 {
+		h.dirty[event.Id] = event
+	}
+}
+
+func (h *StreamHub) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		h.mutex.Lock()
+		dirty := h.dirty
+		h.dirty = map[int64]Event{}
+		h.mutex.Unlock()
+		for _, event := range dirty {
+			stats := repository.GetEventLiveStats(event.Id)
+			h.mutex.Lock()
+			h.send(h.watchers(event), StreamMessage{STREAM_MESSAGE_STATS, event.Id, stats})
+			h.mutex.Unlock()
+		}
+	}
+}
+
+func writeStreamMessage(w http.ResponseWriter, message StreamMessage) error {
+	data, err := json.Marshal(message.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Type, data)
+	return err
+}
+
+// Server-Sent Events until client goes away, initial stats first
+func serveStream(w http.ResponseWriter, r *http.Request, subscriber *streamSubscriber, initial []EventLiveStats) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithJson(w, http.StatusInternalServerError, Exception{Message: "Streaming unsupported"})
+		return
+	}
+	streamHub.subscribe(subscriber)
+	defer streamHub.unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	for _, stats := range initial {
+		writeStreamMessage(w, StreamMessage{STREAM_MESSAGE_STATS, stats.EventId, stats})
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(STREAM_KEEPALIVE_INTERVAL)
+	defer keepalive.Stop()
+	for {
+		select {
+		case message := <-subscriber.messages:
+			if writeStreamMessage(w, message) != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package lib
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Zone for venues without timezone, server local zone if not set
+const VENUE_TZ_ENV = "VENUE_TZ"
+
+func DefaultLocation() *time.Location {
+	if name := os.Getenv(VENUE_TZ_ENV); name != "" {
+		if loc, ok := parseLocation(name); ok {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// IANA name ("Asia/Yekaterinburg") or UTC offset in hours ("5", "+05", "+05:00")
+func parseLocation(name string) (*time.Location, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, false
+	}
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc, true
+	}
+	sign := 1
+	offset := strings.TrimPrefix(strings.TrimPrefix(name, "UTC"), "GMT")
+	if strings.HasPrefix(offset, "-") {
+		sign = -1
+	}
+	offset = strings.TrimLeft(offset, "+-")
+	parts := strings.SplitN(offset, ":", 2)
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil || hours > 14 {
+		return nil, false
+	}
+	minutes := 0
+	if len(parts) == 2 {
+		minutes, err = strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, false
+		}
+	}
+	return time.FixedZone(name, sign*(hours*60*60+minutes*60)), true
+}
+
+func LoadLocation(name string) *time.Location {
+	if loc, ok := parseLocation(name); ok {
+		return loc
+	}
+	return DefaultLocation()
+}
+
+// ISO-8601 with venue offset, empty for zero time
+func IsoTime(unix int64, loc *time.Location) string {
+	if unix == 0 {
+		return ""
+	}
+	return time.Unix(unix, 0).In(loc).Format(time.RFC3339)
+}
@@ -2,7 +2,7 @@ package main
 
 import (
 	"github.com/ekstyle/go_backend/lib"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path"
@@ -13,7 +13,7 @@ func GetPort() string {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
-		log.Println("PORT environment not set. Use", port)
+		slog.Info("PORT environment not set", "port", port)
 	}
 	return ":" + port
 }
@@ -32,8 +32,11 @@ func HandlerFs(publicDir string) http.Handler {
 }
 
 func main() {
+	lib.Init()
 	r := lib.NewRouter()
 	r.PathPrefix("/").Handler(HandlerFs("/public"))
 
-	log.Fatal(http.ListenAndServe(GetPort(), r))
+	err := http.ListenAndServe(GetPort(), r)
+	slog.Error("Server stopped", "error", err)
+	os.Exit(1)
 }
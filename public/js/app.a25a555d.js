@@ -1,2 +1,2 @@
-(function(t){function e(e){for(var a,s,o=e[0],c=e[1],l=e[2],d=0,f=[];d<o.length;d++)s=o[d],Object.prototype.hasOwnProperty.call(i,s)&&i[s]&&f.push(i[s][0]),i[s]=0;for(a in c)Object.prototype.hasOwnProperty.call(c,a)&&(t[a]=c[a]);u&&u(e);while(f.length)f.shift()();return r.push.apply(r,l||[]),n()}function n(){for(var t,e=0;e<r.length;e++){for(var n=r[e],a=!0,o=1;o<n.length;o++){var c=n[o];0!==i[c]&&(a=!1)}a&&(r.splice(e--,1),t=s(s.s=n[0]))}return t}var a={},i={app:0},r=[];function s(e){if(a[e])return a[e].exports;var n=a[e]={i:e,l:!1,exports:{}};return t[e].call(n.exports,n,n.exports,s),n.l=!0,n.exports}s.m=t,s.c=a,s.d=function(t,e,n){s.o(t,e)||Object.defineProperty(t,e,{enumerable:!0,get:n})},s.r=function(t){"undefined"!==typeof Symbol&&Symbol.toStringTag&&Object.defineProperty(t,Symbol.toStringTag,{value:"Module"}),Object.defineProperty(t,"__esModule",{value:!0})},s.t=function(t,e){if(1&e&&(t=s(t)),8&e)return t;if(4&e&&"object"===typeof t&&t&&t.__esModule)return t;var n=Object.create(null);if(s.r(n),Object.defineProperty(n,"default",{enumerable:!0,value:t}),2&e&&"string"!=typeof t)for(var a in t)s.d(n,a,function(e){return t[e]}.bind(null,a));return n},s.n=function(t){var e=t&&t.__esModule?function(){return t["default"]}:function(){return t};return s.d(e,"a",e),e},s.o=function(t,e){return Object.prototype.hasOwnProperty.call(t,e)},s.p="/";var o=window["webpackJsonp"]=window["webpackJsonp"]||[],c=o.push.bind(o);o.push=e,o=o.slice();for(var l=0;l<o.length;l++)e(o[l]);var u=c;r.push([0,"chunk-vendors"]),n()})({0:function(t,e,n){t.exports=n("56d7")},"2c27":function(t,e,n){"use strict";var a=n("ac06"),i=n.n(a);i.a},"2e8d":function(t,e,n){"use strict";var a=n("4a00"),i=n.n(a);i.a},4678:function(t,e,n){var a={"./af":"2bfb","./af.js":"2bfb","./ar":"8e73","./ar-dz":"a356","./ar-dz.js":"a356","./ar-kw":"423e","./ar-kw.js":"423e","./ar-ly":"1cfd","./ar-ly.js":"1cfd","./ar-ma":"0a84","./ar-ma.js":"0a84","./ar-sa":"8230","./ar-sa.js":"8230","./ar-tn":"6d83","./ar-tn.js":"6d83","./ar.js":"8e73","./az":"485c","./az.js":"485c","./be":"1fc1","./be.js":"1fc1","./bg":"84aa","./bg.js":"84aa","./bm":"a7fa","./bm.js":"a7fa","./bn":"9043","./bn.js":"9043","./bo":"d26a","./bo.js":"d26a","./br":"6887","./br.js":"6887","./bs":"2554","./bs.js":"2554","./ca":"d716","./ca.js":"d716","./cs":"3c0d","./cs.js":"3c0d","./cv":"03ec","./cv.js":"03ec","./cy":"9797","./cy.js":"9797","./da":"0f14","./da.js":"0f14","./de":"b469","./de-at":"b3eb","./de-at.js":"b3eb","./de-ch":"bb71","./de-ch.js":"bb71","./de.js":"b469","./dv":"598a","./dv.js":"598a","./el":"8d47","./el.js":"8d47","./en-SG":"cdab","./en-SG.js":"cdab","./en-au":"0e6b","./en-au.js":"0e6b","./en-ca":"3886","./en-ca.js":"3886","./en-gb":"39a6","./en-gb.js":"39a6","./en-ie":"e1d3","./en-ie.js":"e1d3","./en-il":"73332","./en-il.js":"73332","./en-nz":"6f50","./en-nz.js":"6f50","./eo":"65db","./eo.js":"65db","./es":"898b","./es-do":"0a3c","./es-do.js":"0a3c","./es-us":"55c9","./es-us.js":"55c9","./es.js":"898b","./et":"ec18","./et.js":"ec18","./eu":"0ff2","./eu.js":"0ff2","./fa":"8df4","./fa.js":"8df4","./fi":"81e9","./fi.js":"81e9","./fo":"0721","./fo.js":"0721","./fr":"9f26","./fr-ca":"d9f8","./fr-ca.js":"d9f8","./fr-ch":"0e49","./fr-ch.js":"0e49","./fr.js":"9f26","./fy":"7118","./fy.js":"7118","./ga":"5120","./ga.js":"5120","./gd":"f6b4","./gd.js":"f6b4","./gl":"8840","./gl.js":"8840","./gom-latn":"0caa","./gom-latn.js":"0caa","./gu":"e0c5","./gu.js":"e0c5","./he":"c7aa","./he.js":"c7aa","./hi":"dc4d","./hi.js":"dc4d","./hr":"4ba9","./hr.js":"4ba9","./hu":"5b14","./hu.js":"5b14","./hy-am":"d6b6","./hy-am.js":"d6b6","./id":"5038","./id.js":"5038","./is":"0558","./is.js":"0558","./it":"6e98","./it-ch":"6f12","./it-ch.js":"6f12","./it.js":"6e98","./ja":"079e","./ja.js":"079e","./jv":"b540","./jv.js":"b540","./ka":"201b","./ka.js":"201b","./kk":"6d79","./kk.js":"6d79","./km":"e81d","./km.js":"e81d","./kn":"3e92","./kn.js":"3e92","./ko":"22f8","./ko.js":"22f8","./ku":"2421","./ku.js":"2421","./ky":"9609","./ky.js":"9609","./lb":"440c","./lb.js":"440c","./lo":"b29d","./lo.js":"b29d","./lt":"26f9","./lt.js":"26f9","./lv":"b97c","./lv.js":"b97c","./me":"293c","./me.js":"293c","./mi":"688b","./mi.js":"688b","./mk":"6909","./mk.js":"6909","./ml":"02fb","./ml.js":"02fb","./mn":"958b","./mn.js":"958b","./mr":"39bd","./mr.js":"39bd","./ms":"ebe4","./ms-my":"6403","./ms-my.js":"6403","./ms.js":"ebe4","./mt":"1b45","./mt.js":"1b45","./my":"8689","./my.js":"8689","./nb":"6ce3","./nb.js":"6ce3","./ne":"3a39","./ne.js":"3a39","./nl":"facd","./nl-be":"db29","./nl-be.js":"db29","./nl.js":"facd","./nn":"b84c","./nn.js":"b84c","./pa-in":"f3ff","./pa-in.js":"f3ff","./pl":"8d57","./pl.js":"8d57","./pt":"f260","./pt-br":"d2d4","./pt-br.js":"d2d4","./pt.js":"f260","./ro":"972c","./ro.js":"972c","./ru":"957c","./ru.js":"957c","./sd":"6784","./sd.js":"6784","./se":"ffff","./se.js":"ffff","./si":"eda5","./si.js":"eda5","./sk":"7be6","./sk.js":"7be6","./sl":"8155","./sl.js":"8155","./sq":"c8f3","./sq.js":"c8f3","./sr":"cf1e","./sr-cyrl":"13e9","./sr-cyrl.js":"13e9","./sr.js":"cf1e","./ss":"52bd","./ss.js":"52bd","./sv":"5fbd","./sv.js":"5fbd","./sw":"74dc","./sw.js":"74dc","./ta":"3de5","./ta.js":"3de5","./te":"5cbb","./te.js":"5cbb","./tet":"576c","./tet.js":"576c","./tg":"3b1b","./tg.js":"3b1b","./th":"10e8","./th.js":"10e8","./tl-ph":"0f38","./tl-ph.js":"0f38","./tlh":"cf75","./tlh.js":"cf75","./tr":"0e81","./tr.js":"0e81","./tzl":"cf51","./tzl.js":"cf51","./tzm":"c109","./tzm-latn":"b53d","./tzm-latn.js":"b53d","./tzm.js":"c109","./ug-cn":"6117","./ug-cn.js":"6117","./uk":"ada2","./uk.js":"ada2","./ur":"5294","./ur.js":"5294","./uz":"2e8c","./uz-latn":"010e","./uz-latn.js":"010e","./uz.js":"2e8c","./vi":"2921","./vi.js":"2921","./x-pseudo":"fd7e","./x-pseudo.js":"fd7e","./yo":"7f33","./yo.js":"7f33","./zh-cn":"5c3a","./zh-cn.js":"5c3a","./zh-hk":"49ab","./zh-hk.js":"49ab","./zh-tw":"90ea","./zh-tw.js":"90ea"};function i(t){var e=r(t);return n(e)}function r(t){if(!n.o(a,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return a[t]}i.keys=function(){return Object.keys(a)},i.resolve=r,t.exports=i,i.id="4678"},"4a00":function(t,e,n){},5376:function(t,e,n){"use strict";var a=n("6688"),i=n.n(a);i.a},"548c":function(t,e,n){"use strict";var a=n("c8f8"),i=n.n(a);i.a},"56d7":function(t,e,n){"use strict";n.r(e);n("744f"),n("6c7b"),n("7514"),n("20d6"),n("1c4c"),n("6762"),n("cadf"),n("e804"),n("55dd"),n("d04f"),n("c8ce"),n("217b"),n("7f7f"),n("f400"),n("7f25"),n("536b"),n("d9ab"),n("f9ab"),n("32d7"),n("25c9"),n("9f3c"),n("042e"),n("c7c6"),n("f4ff"),n("049f"),n("7872"),n("a69f"),n("0b21"),n("6c1a"),n("c7c62"),n("84b4"),n("c5f6"),n("2e37"),n("fca0"),n("7cdf"),n("ee1d"),n("b1b1"),n("87f3"),n("9278"),n("5df2"),n("04ff"),n("f751"),n("4504"),n("fee7"),n("ffc1"),n("0d6d"),n("9986"),n("8e6e"),n("25db"),n("e4f7"),n("b9a1"),n("64d5"),n("9aea"),n("db97"),n("66c8"),n("57f0"),n("165b"),n("456d"),n("cf6a"),n("fd24"),n("8615"),n("551c"),n("097d"),n("df1b"),n("2397"),n("88ca"),n("ba16"),n("d185"),n("ebde"),n("2d34"),n("f6b3"),n("2251"),n("c698"),n("a19f"),n("9253"),n("9275"),n("3b2b"),n("3846"),n("4917"),n("a481"),n("28a5"),n("386d"),n("6b54"),n("4f7f"),n("8a81"),n("ac4d"),n("8449"),n("9c86"),n("fa83"),n("48c0"),n("a032"),n("aef6"),n("d263"),n("6c37"),n("9ec8"),n("5695"),n("2fdb"),n("d0b0"),n("5df3"),n("b54a"),n("f576"),n("ed50"),n("788d"),n("14b9"),n("f386"),n("f559"),n("1448"),n("673e"),n("242a"),n("c66f"),n("b05c"),n("34ef"),n("6aa2"),n("15ac"),n("af56"),n("b6e4"),n("9c29"),n("63d9"),n("4dda"),n("10ad"),n("c02b"),n("4795"),n("130f"),n("ac6a"),n("96cf");var a=n("2b0e"),i=n("ce5b"),r=n.n(i);n("bf40");a["default"].use(r.a,{});var s=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-app",{attrs:{dark:""}},[n("nav-bar"),n("v-content",[n("v-container",{attrs:{"fill-height":""}},[n("v-layout",{attrs:{"justify-center":"","align-center":""}},[n("v-flex",[n("router-view")],1)],1)],1)],1),n("v-footer",{attrs:{height:"auto"}},[n("v-layout",{attrs:{"justify-center":"",row:"",wrap:""}},[n("v-flex",{attrs:{"py-3":"","text-xs-center":"","grey--text":"",xs12:""}},[t._v("\n                ©2018 — "),n("strong",[t._v("Городские зрелищные кассы")])])],1)],1)],1)},o=[],c=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("nav",[n("v-toolbar",{attrs:{color:"indigo",dense:"",fixed:"","clipped-left":"",app:""}},[n("v-toolbar-side-icon",{on:{click:function(e){e.stopPropagation(),t.drawer=!t.drawer}}}),n("v-toolbar-title",{attrs:{to:{path:"/about"}}},[n("b",[t._v("kassy.ru ")]),n("small",[t._v(" | ")]),n("small",{staticClass:"captionText"},[n("span",{staticClass:"upperLetter"},[t._v("С")]),t._v("истема "),n("span",{staticClass:"upperLetter"},[t._v("К")]),t._v("онтроля "),n("span",{staticClass:"upperLetter"},[t._v("Д")]),t._v("оступа ")])]),n("v-spacer"),t.isAuth?n("v-btn",{attrs:{flat:""},on:{click:function(e){return e.stopPropagation(),t.logout(e)}}},[n("v-icon"),t._v("\n             Выйти\n        ")],1):n("v-btn",{attrs:{flat:""},on:{click:function(e){e.stopPropagation(),t.login_dialog=!0}}},[n("v-icon",[t._v("account_box")]),t._v("\n             Авторизация\n        ")],1)],1),n("v-navigation-drawer",{attrs:{clipped:"",fixed:"",app:"",dark:""},model:{value:t.drawer,callback:function(e){t.drawer=e},expression:"drawer"}},[n("v-list",{attrs:{dense:""}},t._l(t.items,(function(e){return!e.auth||t.isAuth?n("v-list-tile",{key:e.text,attrs:{to:e.path},on:{click:function(t){}}},[n("v-list-tile-action",[n("v-icon",[t._v(t._s(e.icon))])],1),n("v-list-tile-content",[t._v("\n                    "+t._s(e.text)+"\n                ")])],1):t._e()})),1)],1),n("v-snackbar",{attrs:{color:t.snackbar_color,"multi-line":"multi-line"===t.mode,timeout:t.timeout,vertical:"vertical"===t.mode},model:{value:t.snackbar,callback:function(e){t.snackbar=e},expression:"snackbar"}},[t._v("\n        "+t._s(t.snackbar_msg)+"\n        "),n("v-btn",{attrs:{dark:"",flat:""},on:{click:function(e){t.snackbar=!1}}},[t._v("\n            Close\n        ")])],1),n("v-dialog",{attrs:{"max-width":"400px"},model:{value:t.login_dialog,callback:function(e){t.login_dialog=e},expression:"login_dialog"}},[n("v-card",[n("v-card-title",[n("span",{staticClass:"headline"},[t._v("Авторизация")])]),n("v-card-text",[n("v-container",{attrs:{"grid-list-md":""}},[n("v-layout",{attrs:{wrap:""}},[n("v-flex",{attrs:{xs12:""}},[n("v-text-field",{attrs:{label:"Email",required:""},model:{value:t.login_input,callback:function(e){t.login_input=e},expression:"login_input"}})],1),n("v-flex",{attrs:{xs12:""}},[n("v-text-field",{attrs:{label:"Password",type:"password",required:""},model:{value:t.password_input,callback:function(e){t.password_input=e},expression:"password_input"}})],1)],1)],1)],1),n("v-card-actions",[n("v-spacer"),n("v-btn",{attrs:{color:"grey",flat:""},nativeOn:{click:function(e){t.login_dialog=!1}}},[t._v("Закрыть")]),n("v-btn",{attrs:{color:"green",flat:""},nativeOn:{click:function(e){return t.doLogin(e)}}},[t._v("Войти")])],1)],1)],1)],1)},l=[],u=n("2f62"),d="authorization/LOGIN",f="authorization/LOGOUT",m="terminals/GROUPS_GET",p="terminals/TERMINALS_GET",v="terminals/TERMINAL_ADD",h="terminals/TERMINAL_SET",g="terminals/GROUP_ADD",b="terminals/GROUP_REMOVE",x="terminals/GROUP_SET",_="sqlrunner/QUERY",y="authorization/MUTATE_LOGIN_SET",j="authorization/MUTATE_LOGOUT_SET",k="authorization/MUTATE_API_ERROR",w="terminals/MUTATE_TERMINALS_GROUPS",O="terminals/MUTATE_TERMINALS",P="sqlrunner/QUERY_RESULT",E="sqlrunner/QUERY_PREPARE";function D(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function S(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?D(n,!0).forEach((function(e){C(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):D(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function C(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var A,T,L={name:"NavBar",data:function(){return{drawer:!1,items:[{icon:"trending_up",text:"Терминалы",path:"/terminals",auth:!0},{icon:"find_in_page",text:"Проверка билета",path:"/check",auth:!1},{icon:"cloud_upload",text:"Импорт",path:"/upload",auth:!0},{icon:"history",text:"Логи",path:"/logs",auth:!0},{icon:"settings",text:"Настройки",path:"/settings",auth:!0},{icon:"blur_linear",text:"Статистика",path:"/stat",auth:!1}],login_dialog:!1,login_input:"",password_input:"",snackbar:!1,snackbar_msg:"",timeout:6e3,mode:"multi-line",snackbar_color:"error"}},methods:S({doLogin:function(){this.login_dialog=!1,this.login({login:this.login_input,password:this.password_input})}},Object(u["b"])({logout:f,login:d})),computed:S({},Object(u["c"])({isAuth:function(t){return t.authorization.isAuth},exp:function(t){return t.authorization.exp},apiErr:function(t){return t.authorization.apiErr}})),watch:{apiErr:function(){this.snackbar_msg=this.apiErr.response.status+" "+this.apiErr.response.statusText,this.snackbar=!0},isAuth:function(t){this.drawer=t}},mounted:function(){}},G=L,N=(n("548c"),n("2877")),R=Object(N["a"])(G,c,l,!1,null,"1a024898",null),I=R.exports,q={title:"SKD kassy.ru",name:"app",data:function(){return{}},components:{NavBar:I}},M=q,Y=Object(N["a"])(M,s,o,!1,null,null,null),z=Y.exports,B=n("bc3a"),U=n.n(B),Q=U.a.create({withCredentials:!0}),F=n("4328"),J=n.n(F),H=200,X=401,K={checkTicket:function(t){return new Promise((function(e,n){Q.post("/check_ticket",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},login:function(t){return new Promise((function(e,n){Q.post("/login",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},loguot:function(){return new Promise((function(t,e){Q.get("/logout").then((function(e){t(e)})).catch((function(t){e(t)}))}))},getGroups:function(){return new Promise((function(t,e){Q.get("/groups").then((function(e){t(e)})).catch((function(t){e(t)}))}))},getLogs:function(){return new Promise((function(t,e){Q.get("/logs").then((function(e){t(e)})).catch((function(t){e(t)}))}))},getStats:function(t){return new Promise((function(e,n){Q.post("/stats",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},getTerminalss:function(){return new Promise((function(t,e){Q.get("/terminals").then((function(e){t(e)})).catch((function(t){e(t)}))}))},setTerminal:function(t){return new Promise((function(e,n){Q.post("/terminal/"+t.id,J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},addTerminal:function(t){return new Promise((function(e,n){Q.post("/add_terminal",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},addGroup:function(t){return new Promise((function(e,n){Q.post("/add_group",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},syncEvent:function(t){return new Promise((function(e,n){Q.get("/event/".concat(t,"/sync")).then((function(t){e(t)})).catch((function(t){n(t)}))}))},getEventInfo:function(t){return new Promise((function(e,n){Q.get("/event/".concat(t,"/info")).then((function(t){e(t)})).catch((function(t){n(t)}))}))},setGroup:function(t){return new Promise((function(e,n){Q.post("/set_group",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},removeGroup:function(t){return new Promise((function(e,n){Q.post("/remove_group",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},getEventsByGroupId:function(t){return new Promise((function(e,n){Q.get("/events/"+t).then((function(t){e(t)})).catch((function(t){n(t)}))}))},sqlQuery:function(t){return new Promise((function(e,n){Q.post("/sql",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))}};function W(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var V={isAuth:!1,exp:null,apiErr:null,isInit:!1};function Z(t){t.isAuth=!1,t.exp=null,localStorage.setItem("authorization",JSON.stringify(t))}var $,tt,et=(A={initialiseStore:function(t){if(t.isInit=!0,localStorage.getItem("authorization")){var e=JSON.parse(localStorage.getItem("authorization"));Date.parse(e.exp)>Date.now()&&Object.assign(t,e)}}},W(A,y,(function(t,e){1e3*e.exp>Date.now()&&(t.exp=new Date(1e3*e.exp),t.isAuth=!0,localStorage.setItem("authorization",JSON.stringify(t)))})),W(A,j,(function(t,e){null!==e?e.code===H&&Z(t):Z(t)})),W(A,k,(function(t,e){t.apiErr=e,t.isAuth&&e.response.status===X&&Z(t)})),A),nt=(T={},W(T,d,(function(t,e){var n=t.commit;K.login(e).then((function(t){n(y,t.data)})).catch((function(t){n(k,t)}))})),W(T,f,(function(t){var e=t.commit;K.loguot().then((function(t){e(j,t.data)})).catch((function(t){e(k,t)}))})),T),at={state:V,mutations:et,actions:nt};function it(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var rt,st={groups:null,terminals:null},ot=($={},it($,w,(function(t,e){t.groups=e.groups})),it($,O,(function(t,e){t.terminals=e.terminals})),$),ct=(tt={},it(tt,m,(function(t){var e=t.commit;K.getGroups().then((function(t){e(w,t.data)})).catch((function(t){e(k,t)}))})),it(tt,p,(function(t){var e=t.commit;K.getTerminalss().then((function(t){e(O,t.data)})).catch((function(t){e(k,t)}))})),it(tt,h,(function(t,e){var n=t.commit,a=t.dispatch;K.setTerminal(e).then((function(){a(p)})).catch((function(t){n(k,t)}))})),it(tt,v,(function(t,e){var n=t.commit,a=t.dispatch;K.addTerminal(e).then((function(){a(p)})).catch((function(t){n(k,t)}))})),it(tt,g,(function(t,e){var n=t.commit,a=t.dispatch;K.addGroup(e).then((function(){a(m)})).catch((function(t){n(k,t)}))})),it(tt,x,(function(t,e){var n=t.commit,a=t.dispatch;K.setGroup(e).then((function(){a(m)})).catch((function(t){n(k,t)}))})),it(tt,b,(function(t,e){var n=t.commit,a=t.dispatch;K.removeGroup(e).then((function(){a(m)})).catch((function(t){n(k,t)}))})),tt),lt={state:st,mutations:ot,actions:ct};function ut(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var dt={results:[],isProcess:!1},ft=(rt={},ut(rt,P,(function(t,e){t.results.push(e),t.isProcess=!1})),ut(rt,E,(function(t){t.isProcess=!0,t.results=[]})),rt),mt=ut({},_,(function(t,e){var n=t.commit;n(E),K.sqlQuery(e).then((function(t){n(P,t.data)})).catch((function(t){console.log(t)}))})),pt={state:dt,mutations:ft,actions:mt};a["default"].use(u["a"]);var vt=new u["a"].Store({modules:{authorization:at,terminals:lt,sqlrunner:pt},state:{},mutations:{},actions:{}}),ht=n("8c4f"),gt=function(){var t=this,e=t.$createElement;t._self._c;return t._m(0)},bt=[function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"about"},[n("h1",[t._v("This is an about page")])])}],xt={},_t=Object(N["a"])(xt,gt,bt,!1,null,null,null),yt=_t.exports,jt=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",[n("v-text-field",{attrs:{"append-icon":"search",label:"Фильтр","single-line":"","hide-details":"",clearable:""},model:{value:t.search,callback:function(e){t.search=e},expression:"search"}}),n("v-data-table",{attrs:{loading:t.isLoading,items:t.logs,headers:t.headers,pagination:t.pagination,search:t.search,"select-all":"","item-key":"id"},on:{"update:pagination":function(e){t.pagination=e}},scopedSlots:t._u([{key:"headers",fn:function(e){return[n("tr",t._l(e.headers,(function(e){return n("th",{key:e.text,class:["column sortable",t.pagination.descending?"desc":"asc",e.value===t.pagination.sortBy?"active":""],on:{click:function(n){return t.changeSort(e.value)}}},[n("v-icon",{attrs:{small:""}},[t._v("arrow_upward")]),t._v("\n        "+t._s(e.text)+"\n      ")],1)})),0)]}},{key:"items",fn:function(e){return[n("tr",{class:{errorItem:t.isErrorCode(e.item.code)},attrs:{active:e.selected}},[n("td",{staticClass:"text-xs-left"},[n("small",{staticClass:"grey darken-2"},[t._v(t._s(t.frontEndDateFormat(e.item.dt)))]),n("br"),t._v(" "+t._s(t.frontEndDateFromNow(e.item.dt)))]),n("td",{staticClass:"text-xs-right"},[t._v(t._s(e.item.data))]),n("td",{staticClass:"text-xs-left"},[t._v(t._s(e.item.message))]),n("td",{staticClass:"text-xs-right"},[t._v(t._s(e.item.code))])])]}}])},[n("v-progress-linear",{attrs:{slot:"progress",color:"blue",indeterminate:""},slot:"progress"})],1)],1)},kt=[],wt=n("c1df"),Ot=n.n(wt);function Pt(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function Et(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?Pt(n,!0).forEach((function(e){Dt(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):Pt(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function Dt(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var St={name:"Logs",methods:Et({frontEndDateFormat:function(t){return Ot.a.locale("ru"),Ot.a.unix(t).format("DD/MM/YYYY HH:mm")},frontEndDateFromNow:function(t){return Ot.a.unix(t).fromNow()},changeSort:function(t){this.pagination.sortBy===t?this.pagination.descending=!this.pagination.descending:(this.pagination.sortBy=t,this.pagination.descending=!1)},isErrorCode:function(t){var e=[401,402],n=e.indexOf(t);return n>=0}},Object(u["b"])({groupsRemove:b,groupSet:x}),{getLogs:function(){var t=this;this.isLoading=!0,K.getLogs().then((function(e){t.logs=e.data,t.isLoading=!1})).catch((function(e){t.isLoading=!1,console.log(e)}))}}),data:function(){return{search:"",isLoading:!1,hidden:!1,isOpen:!1,pagination:{sortBy:"dt",descending:!0,rowsPerPage:25},headers:[{text:"Датa",value:"dt",align:"center",sort:"desc"},{text:"Данные",align:"left",value:"data",width:"10%"},{text:"Сообщение",value:"message",width:"90%",align:"right"},{text:"Код",value:"code"}],logs:[]}},mounted:function(){this.hidden=!1,this.getLogs()}},Ct=St,At=(n("2c27"),Object(N["a"])(Ct,jt,kt,!1,null,null,null)),Tt=At.exports,Lt=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",[n("v-list",{attrs:{"two-line":"",subheader:""}},[n("v-subheader",{attrs:{inset:""}},[t._v("Folders")]),t._l(t.items,(function(e){return n("v-list-tile",{key:e.title,attrs:{avatar:""},on:{click:function(t){}}},[n("v-list-tile-avatar",[n("v-icon",{class:[e.iconClass]},[t._v(t._s(e.icon))])],1),n("v-list-tile-content",[n("v-list-tile-title",[t._v(t._s(e.title))]),n("v-list-tile-sub-title",[t._v(t._s(e.subtitle))])],1),n("v-list-tile-action",[n("v-btn",{attrs:{icon:"",ripple:""}},[n("v-icon",{attrs:{color:"grey lighten-1"}},[t._v("info")])],1)],1)],1)}))],2),n("v-subheader",{staticClass:"pl-0"},[t._v("Время блокировки билета после проверки")]),n("v-slider",{attrs:{"thumb-label":"always"},model:{value:t.slider,callback:function(e){t.slider=e},expression:"slider"}}),n("v-card",{attrs:{flat:""}},[n("v-card-text",[n("v-container",{attrs:{fluid:""}},[n("v-layout",{attrs:{row:"",wrap:""}},[n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"red",color:"red",value:"red","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"red darken-3",color:"red darken-3",value:"red darken-3","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1),n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"indigo",color:"indigo",value:"indigo","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"indigo darken-3",color:"indigo darken-3",value:"indigo darken-3","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1),n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"orange",color:"orange",value:"orange","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"orange darken-3",color:"orange darken-3",value:"orange darken-3","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1)],1),n("v-layout",{staticClass:"mt-5",attrs:{row:"",wrap:""}},[n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"primary",color:"primary",value:"primary","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"secondary",color:"secondary",value:"secondary","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1),n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"success",color:"success",value:"success","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"info",color:"info",value:"info","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1),n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"warning",color:"warning",value:"warning","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"error",color:"error",value:"error","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1)],1)],1)],1)],1)],1)},Gt=[],Nt=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"hello"})},Rt=[],It={name:"HelloWorld",props:{msg:String}},qt=It,Mt=(n("bc4f"),Object(N["a"])(qt,Nt,Rt,!1,null,"c4e39d4a",null)),Yt=Mt.exports,zt={name:"home",components:{HelloWorld:Yt},data:function(){return{items:[{icon:"folder",iconClass:"grey lighten-1 white--text",title:"Photos",subtitle:"Jan 9, 2014"},{icon:"folder",iconClass:"grey lighten-1 white--text",title:"Recipes",subtitle:"Jan 17, 2014"},{icon:"folder",iconClass:"grey lighten-1 white--text",title:"Work",subtitle:"Jan 28, 2014"}],slider:11,ex11:["red","indigo","orange","primary","secondary","success","info","warning","error","red darken-3","indigo darken-3","orange darken-3"]}}},Bt=zt,Ut=Object(N["a"])(Bt,Lt,Gt,!1,null,"08ebdf80",null),Qt=Ut.exports,Ft=function(){var t=this,e=t.$createElement,a=t._self._c||e;return a("v-card",{staticClass:"mx-auto pt-5",attrs:{"max-width":"600"}},[a("v-card-text",{staticClass:"py-0"},[a("v-layout",{attrs:{row:"",wrap:""}},[a("v-flex",{attrs:{xs12:"",sm6:"",md4:""}},[a("v-img",{staticClass:"mt-2 ml-2",attrs:{src:n("91b8"),width:"130"}})],1),a("v-flex",{attrs:{xs12:"",sm6:"",md4:""}},[a("v-menu",{attrs:{"close-on-content-click":!1,"nudge-right":40,lazy:"",transition:"scale-transition","offset-y":"","full-width":"","min-width":"290px"},scopedSlots:t._u([{key:"activator",fn:function(e){var n=e.on;return[a("v-text-field",t._g({attrs:{label:"Начало периода","prepend-icon":"event",readonly:"",value:t.fromDateFormattedMomentjs}},n))]}}]),model:{value:t.menu,callback:function(e){t.menu=e},expression:"menu"}},[a("v-date-picker",{attrs:{locale:"ru"},on:{change:t.getStats,input:function(e){t.menu=!1}},model:{value:t.fromDate,callback:function(e){t.fromDate=e},expression:"fromDate"}})],1)],1),a("v-flex",{attrs:{xs12:"",sm6:"",md4:""}},[a("v-menu",{attrs:{"close-on-content-click":!1,"nudge-right":40,lazy:"",transition:"scale-transition","offset-y":"","full-width":"","min-width":"290px"},scopedSlots:t._u([{key:"activator",fn:function(e){var n=e.on;return[a("v-text-field",t._g({attrs:{value:t.toDateFormattedMomentjs,label:"Конец периода","prepend-icon":"event",readonly:""}},n))]}}]),model:{value:t.menu2,callback:function(e){t.menu2=e},expression:"menu2"}},[a("v-date-picker",{attrs:{locale:"ru"},on:{change:t.getStats,input:function(e){t.menu2=!1}},model:{value:t.toDate,callback:function(e){t.toDate=e},expression:"toDate"}})],1)],1)],1),a("events-stats-timeline",{staticClass:"pt-3",attrs:{transition:"slide-x-transition",events:t.events}})],1),a("v-progress-linear",{attrs:{active:t.loading,indeterminate:t.loading}})],1)},Jt=[],Ht=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",t._l(t.events,(function(e){return n("v-card",{key:e.id,staticClass:"mb-3 card-event"},[n("v-card-title",{attrs:{"primary-title":""}},[n("div",[n("div",{staticClass:"headline pb-1"},[n("span",{staticClass:"date"},[t._v(t._s(t.frontEndDateFormat(e.dt)))]),t._v(" "+t._s(e.title))])])]),n("v-card-text",[n("v-data-table",{staticClass:"elevation-1",attrs:{headers:t.headers,items:e.info,"hide-actions":!0},scopedSlots:t._u([{key:"items",fn:function(e){return[n("td",{staticClass:"light-green--text"},[t._v(t._s(e.item.id))]),n("td",{staticClass:"text-xs"},[t._v(t._s(e.item.sell))]),n("td",{staticClass:"text-xs"},[t._v(t._s(e.item.total))]),n("td",{staticClass:"text-xs"},[t._v(t._s(e.item.entry))])]}},{key:"footer",fn:function(){return[n("td",[n("strong",[t._v("Итого")])]),n("td",{staticClass:"text-xs"},[n("b",[t._v(t._s(e.sell))])]),n("td",{staticClass:"text-xs"},[n("b",[t._v(t._s(e.total))])]),n("td",{staticClass:"text-xs"},[n("b",[t._v(t._s(e.entry))])])]},proxy:!0}],null,!0)})],1)],1)})),1)},Xt=[],Kt={last_color:"",colors:["red","pink","purple","indigo","blue","teal","green","lime","yellow","orange","brown"],random_color:function(){return this.colors[Math.floor(Math.random()*this.colors.length)]},get_color:function(){var t=this.random_color();return t===this.last_color?this.get_color():(this.last_color=t,t)}},Wt={name:"EventsStatsTimeline",props:["events"],data:function(){return{headers:[{text:"Цена",align:"left",sortable:!0,value:"id"},{text:"Продано",value:"sell",sortable:!1},{text:"Сумма",value:"total",sortable:!1},{text:"Прошло",value:"entry",sortable:!1}]}},methods:{frontEndDateFormat:function(t){return Ot.a.locale("ru"),Ot.a.unix(t).format("DD.MM.YY  HH:mm ")},get_color:function(){return Kt.get_color()},percent:function(t){return t.entry/t.sell*100}},mounted:function(){}},Vt=Wt,Zt=(n("5376"),Object(N["a"])(Vt,Ht,Xt,!1,null,"782a9bce",null)),$t=Zt.exports,te={name:"Stats",components:{EventsStatsTimeline:$t},data:function(){return{fromDate:(new Date).toISOString().substr(0,10),toDate:(new Date).toISOString().substr(0,10),value:0,query:!1,show:!0,interval:0,loading:!1,loader:null,barcode:null,selected:[2],items:[],events:[],menu:!1,modal:!1,menu2:!1}},mounted:function(){},beforeDestroy:function(){clearInterval(this.interval)},methods:{today:function(){var t=Ot()();return Ot.a.lang("ru"),t.format("DD")},todayDay:function(){var t=Ot()();return Ot.a.lang("ru"),t.format("dddd")},todayMonth:function(){var t=Ot()();return Ot.a.lang("ru"),t.format("MMMM YYYYY")},getStats:function(){var t=this;null!=this.events&&this.events.clear(),this.loading=!0,K.getStats({from:this.fromDate,to:this.toDate}).then((function(e){console.log(e.data),t.loading=!1,t.events=e.data})).catch((function(e){t.loading=!1,console.log(e)}))}},computed:{fromDateFormattedMomentjs:function(){return console.log(this.fromDate),this.fromDate?Ot()(this.fromDate).format("DD.MM.YYYY"):""},toDateFormattedMomentjs:function(){return this.toDate?Ot()(this.toDate).format("DD.MM.YYYY"):""}}},ee=te,ne=(n("2e8d"),Object(N["a"])(ee,Ft,Jt,!1,null,null,null)),ae=ne.exports,ie=function(){var t=this,e=t.$createElement,a=t._self._c||e;return a("v-container",[a("v-flex",{attrs:{xs12:"",sm6:"","offset-sm3":""}},[a("v-layout",{attrs:{row:""}},[a("v-text-field",{attrs:{label:"Штрихкод",autofocus:""},nativeOn:{keyup:function(e){return!e.type.indexOf("key")&&t._k(e.keyCode,"enter",13,e.key,"Enter")?null:t.checkTicket()}},model:{value:t.barcode,callback:function(e){t.barcode=e},expression:"barcode"}}),a("v-btn",{attrs:{loading:t.loading,disabled:t.loading,color:"secondary",fab:""},nativeOn:{click:function(e){return t.checkTicket()}}},[a("v-icon",[t._v("find_in_page")])],1)],1),a("v-list",{attrs:{dense:""}},[t._l(t.items,(function(e,i){return[e.event.dt?a("v-subheader",{key:i},[t._v("\n              "+t._s(e.event.venue_title)+"/"+t._s(e.event.title)+" "),a("br"),t._v("\n              "+t._s(t.frontEndDateFormat(e.event.dt))+"\n            ")]):a("v-subheader",{key:i},[a("small",[t._v(t._s(e.ticket.barcode)+" Билет не найден")])]),e.ticket.dt?a("v-list-tile",{key:e.ticket},[a("v-list-tile-avatar",[a("img",{attrs:{src:n("ee37")}})]),a("v-list-tile-content",[a("v-list-tile-title",[t._v(t._s(e.ticket.barcode))]),a("v-list-tile-sub-title",[t._v(" "+t._s(t.frontEndDateFormat(e.ticket.dt)))])],1)],1):t._e(),t._l(e.entry,(function(e,n){return a("v-list-tile",{key:n},["entry"===e.direction&&1===e.result_code?a("v-icon",{staticClass:"light-blue--text"},[t._v("arrow_forward")]):"exit"===e.direction&&1===e.result_code?a("v-icon",{staticClass:"green--text "},[t._v("arrow_back")]):a("v-icon",{staticClass:"red--text text--lighten-2"},[t._v("cancel")]),a("small",[t._v(t._s(t.frontEndDateFormat(e.operation_dt)))]),t._v(" "+t._s(e.term.name)+"\n            ")],1)})),a("v-divider")]}))],2)],1)],1)},re=[],se={name:"Check",methods:{onBarcodeScanned:function(t){t!==this.barcode&&(this.barcode=t,this.checkTicket())},frontEndDateFormat:function(t){return Ot.a.locale("ru"),Ot.a.unix(t).format("DD/MM/YYYY HH:mm")},checkTicket:function(){var t=this;this.loading=!0,K.checkTicket({barcode:this.barcode}).then((function(e){t.barcode="",t.items.unshift(e.data),t.loading=!1})).catch((function(e){t.barcode="",t.loading=!1,t.loading=!1,console.log(e)}))}},data:function(){return{loading:!1,loader:null,barcode:null,selected:[2],items:[]}},mounted:function(){},created:function(){this.$barcodeScanner.init(this.onBarcodeScanned)},destroyed:function(){this.$barcodeScanner.destroy()}},oe=se,ce=Object(N["a"])(oe,ie,re,!1,null,null,null),le=ce.exports,ue=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",[n("v-flex",{attrs:{xs12:"",sm6:"","offset-sm3":""}})],1)},de=[],fe={name:"Check",methods:{},data:function(){return{loading:!1,loader:null,barcode:null,selected:[2],items:[]}},mounted:function(){}},me=fe,pe=Object(N["a"])(me,ue,de,!1,null,null,null),ve=pe.exports,he=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",{attrs:{"grid-list-md":""}},[n("v-layout",{attrs:{wrap:""}},[n("v-flex",{attrs:{sm12:""}},[n("v-toolbar",{attrs:{dense:""}},[n("v-toolbar-title",[t._v("Группы")]),n("v-spacer"),n("v-toolbar-items",[n("v-btn",{attrs:{flat:"",color:"green"},nativeOn:{click:function(e){return t.showDialog(e)}}},[n("v-icon",[t._v("add")])],1)],1)],1),n("v-expansion-panel",t._l(t.groups,(function(t){return n("terminal-group",{key:t.name,staticClass:"column",attrs:{group:t,lazy:""}})})),1),n("v-dialog",{attrs:{"max-width":"500px"},model:{value:t.dialog,callback:function(e){t.dialog=e},expression:"dialog"}},[n("v-card",[n("v-card-text",[n("v-text-field",{ref:"groupNameInput",attrs:{label:"Название группы"},nativeOn:{keyup:function(e){return!e.type.indexOf("key")&&t._k(e.keyCode,"enter",13,e.key,"Enter")?null:t.addGroup(e)}},model:{value:t.groupName,callback:function(e){t.groupName=e},expression:"groupName"}}),n("small",{staticClass:"grey--text"},[t._v("* Название должно быть уникальным")])],1),n("v-card-actions",[n("v-spacer"),n("v-btn",{attrs:{disabled:0==t.groupName.length,flat:"",color:"primary"},nativeOn:{click:function(e){return t.addGroup(e)}}},[t._v("Добавить\n                    ")])],1)],1)],1)],1),n("v-flex",{attrs:{sm12:""}},[n("v-toolbar",{attrs:{dense:""}},[n("v-toolbar-title",[t._v("Терминалы")]),n("v-spacer"),n("v-toolbar-items",[n("v-btn",{attrs:{flat:""},nativeOn:{click:function(e){return t.showDialogTerm(e)}}},[n("v-icon",[t._v("add")])],1)],1)],1),n("v-expansion-panel",t._l(t.terminals,(function(t){return n("terminal",{key:t.name,staticClass:"column",attrs:{terminal:t}})})),1),n("v-dialog",{attrs:{"max-width":"500px"},model:{value:t.dialogTerm,callback:function(e){t.dialogTerm=e},expression:"dialogTerm"}},[n("v-card",[n("v-card-text",[n("v-text-field",{ref:"terminalNameInput",attrs:{label:"Название терминала"},nativeOn:{keyup:function(e){return!e.type.indexOf("key")&&t._k(e.keyCode,"enter",13,e.key,"Enter")?null:t.addTerminal(e)}},model:{value:t.terminalName,callback:function(e){t.terminalName=e},expression:"terminalName"}}),n("small",{staticClass:"grey--text"},[t._v("* Название терминала должно быть уникальным")])],1),n("v-card-actions",[n("v-spacer"),n("v-btn",{attrs:{disabled:0==t.terminalName.length,flat:"",color:"primary"},nativeOn:{click:function(e){return t.addTerminal(e)}}},[t._v("Добавить\n                    ")])],1)],1)],1)],1)],1)],1)},ge=[],be=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-expansion-panel-content",[n("div",{attrs:{slot:"header"},slot:"header"},[t._v(t._s(t.group.name))]),n("v-card",{on:{mouseleave:t.mouseLeave,mouseover:t.mouseOver}},[n("building-card",{attrs:{building:t.group},on:{"change-building":t.building_change}})],1),n("v-text-field",{attrs:{"append-icon":"search",label:"Фильтр","single-line":"","hide-details":"",clearable:""},model:{value:t.search,callback:function(e){t.search=e},expression:"search"}}),n("v-data-table",{staticClass:"elevation-1",attrs:{loading:t.isLoading,items:t.events,headers:t.headers,pagination:t.pagination,search:t.search,"select-all":"","item-key":"id"},on:{"update:pagination":function(e){t.pagination=e}},scopedSlots:t._u([{key:"headers",fn:function(e){return[n("tr",[n("th",[n("v-btn",{attrs:{flat:"",icon:"",disabled:""}},[n("v-icon",{attrs:{color:"green"}},[t._v("loop")])],1)],1),t._l(e.headers,(function(e){return n("th",{key:e.text,class:["column sortable",t.pagination.descending?"desc":"asc",e.value===t.pagination.sortBy?"active":""],on:{click:function(n){return t.changeSort(e.value)}}},[n("v-icon",{attrs:{small:""}},[t._v("arrow_upward")]),t._v("\n                    "+t._s(e.text)+"\n                ")],1)}))],2)]}},{key:"items",fn:function(e){return[n("tr",{attrs:{active:e.selected},on:{click:function(n){return t.ping(e)}}},[n("td",[n("v-btn",{attrs:{flat:"",icon:"",disabled:"sync"===e.item.last_update},nativeOn:{click:function(n){return t.test(e.item.id)}}},[n("v-icon",{attrs:{small:"",color:"green"}},[t._v("loop")])],1)],1),n("td",{staticClass:"text-xs-left"},[n("small",{staticClass:"grey darken-2"},[t._v(t._s(t.frontEndDateFormat(e.item.dt)))]),t._v(" "+t._s(t.frontEndDateFromNow(e.item.dt)))]),n("td",[t._v("\n                    "+t._s(e.item.title)+"  "),n("span",{staticClass:"extPlaceCount"})]),n("td",{staticClass:"text-xs-center"},[n("small",[t._v(t._s(e.item.hall))])]),n("td",{staticClass:"text-xs-left"},["sync"!==e.item.last_update?n("small",{staticClass:"grey darken-2"},[t._v(t._s(t.frontEndDateFromNow(e.item.last_update))+" ")]):t._e()])])]}},{key:"expand",fn:function(e){return[n("v-card",[n("v-card-text",[t._v("\n                    Продано: "),t.event_info[e.item.id]?n("span",{staticClass:"atlasPlaceCount"},[t._v("  "+t._s(t.event_info[e.item.id].Tickets.tickets))]):t._e(),t._v("\n                    Прошло: "),t.event_info[e.item.id]?n("span",{staticClass:"entriesPlaceCount"},[t._v("  "+t._s(t.event_info[e.item.id].Entries.entries))]):t._e()])],1)]}}])},[n("v-progress-linear",{attrs:{slot:"progress",color:"blue",indeterminate:""},slot:"progress"})],1)],1)},xe=[],_e=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-card",{attrs:{color:"blue-grey darken-3",dark:""}},[t.show_input?n("v-card-text",[n("v-autocomplete",{attrs:{items:t.items,loading:t.isLoading,"search-input":t.search,color:"white","item-text":"building_name","item-value":"API",label:"Учреждение",placeholder:"Введите учреждение","prepend-icon":"mdi-database-search","return-object":""},on:{"update:searchInput":function(e){t.search=e},"update:search-input":function(e){t.search=e},change:t.change_input},model:{value:t.model,callback:function(e){t.model=e},expression:"model"}})],1):t._e(),n("v-divider"),n("v-expand-transition",[t.show_input?t._e():n("v-list",{staticClass:"blue-grey darken-2",attrs:{dense:""}},[n("v-list-tile",[n("small",[t._v("Учреждение")])]),t._l(t.fields,(function(e,a){return"building_name"===e.key||"building_address"===e.key?n("v-list-tile",{key:a},[n("v-list-tile-content",["building_address"===e.key?n("v-list-tile-sub-title",{domProps:{textContent:t._s(e.value)}}):t._e(),"building_name"===e.key?n("v-list-tile-title",{domProps:{textContent:t._s(e.value)}}):t._e()],1)],1):t._e()})),n("v-btn",{attrs:{disabled:!t.model,color:"grey darken-3"},on:{click:t.clear_input}},[t._v("\n                Сбросить\n                "),n("v-icon",{attrs:{right:""}},[t._v("eject")])],1)],2)],1),n("v-spacer")],1)},ye=[],je={props:["building"],data:function(){return{descriptionLimit:60,entries:[],isLoading:!1,model:null,search:null,show_input:!1}},methods:{change_input:function(t){this.$emit("change-building",{building_id:t.building_id,building_name:t.building_name,building_address:t.building_address}),this.show_input=!1},clear_input:function(){this.model=null,this.building_id=null,this.$emit("change-building",{building_id:null,building_name:null,building_address:null}),this.show_input=!0}},computed:{fields:function(){var t=this;return this.model?Object.keys(this.model).map((function(e){return{key:e,value:t.model[e]||"n/a"}})):[]},items:function(){return this.entries.map((function(t){return t.building_id=t.id,t.building_name=t.title,t.building_address=t.address,t}))}},watch:{search:function(t){var e=this;this.items.length>0||(this.isLoading=!0,U.a.get("/buildings").then((function(t){e.entries=t.data,console.log(e.items)})).catch((function(t){console.log(t)})).finally((function(){return e.isLoading=!1})))}},mounted:function(){this.model=this.building,0===this.building.building_id&&(this.show_input=!0)}},ke=je,we=Object(N["a"])(ke,_e,ye,!1,null,"16a55914",null),Oe=we.exports;function Pe(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function Ee(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?Pe(n,!0).forEach((function(e){De(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):Pe(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function De(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var Se={name:"TerminalGroup",components:{BuildingCard:Oe},props:["group"],methods:Ee({ping:function(t){var e=this;t.expanded=!t.expanded,K.getEventInfo(t.item.id).then((function(n){console.log(n.data),e.$set(e.event_info,t.item.id,n.data)}))},test:function(t){var e=this;this.syncids.push(t),this.isLoading=!0,this.events.filter((function(e){return e.id===t})).map((function(t){return t.last_update="sync"})),K.syncEvent(t).then((function(n){e.events.filter((function(t){return t.id===n.data.id})).map((function(a){var i=e.syncids.indexOf(t);return i>-1&&e.syncids.splice(i,1),0===e.syncids.length&&(e.isLoading=!1),a.last_update=n.data.last_update,a.tickets_cached=n.data.tickets_cached,a}))})).catch((function(t){e.isLoading=!1,console.log(t)})),console.log(t)},frontEndDateFormat:function(t){return Ot.a.locale("ru"),Ot.a.unix(t).format("DD/MM/YYYY HH:mm")},frontEndDateFromNow:function(t){return Ot.a.unix(t).fromNow()},changeSort:function(t){this.pagination.sortBy===t?this.pagination.descending=!this.pagination.descending:(this.pagination.sortBy=t,this.pagination.descending=!1)}},Object(u["b"])({groupsRemove:b,groupSet:x}),{building_change:function(t){var e=this;null!==t.building_id?(this.isLoading=!0,this.group=Object.assign(this.group,t),this.groupSet(this.group),setTimeout((function(){e.getEvents()}),1300)):this.events=[]},mouseOver:function(){this.hidden=!1},mouseLeave:function(){var t=this;setTimeout((function(){return t.hidden=!0}),800)},removeAction:function(){this.groupsRemove({name:this.group.name})},getEvents:function(){var t=this;this.isLoading=!0,K.getEventsByGroupId(this.group.id).then((function(e){console.log(e.data),t.events=e.data.events.slice(),t.isLoading=!1})).catch((function(e){t.isLoading=!1,console.log(e)}))}}),data:function(){return{event_info:[],syncids:[],search:"",isLoading:!1,hidden:!1,isOpen:!1,pagination:{sortBy:"dt"},headers:[{text:"Датa",value:"dt",align:"left",width:"100px"},{text:"Название мероприятия",align:"left",value:"title",width:"90%"},{text:"Зал",value:"hall",align:"right"},{text:"Актуальность данных",value:"last_update"}],events:[]}},mounted:function(){this.hidden=!1,this.getEvents()}},Ce=Se,Ae=(n("5d20"),Object(N["a"])(Ce,be,xe,!1,null,"926b7ce8",null)),Te=Ae.exports,Le=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-expansion-panel-content",[n("div",{attrs:{slot:"header"},slot:"header"},[n("b",[t._v(t._s(t.terminal.name))])]),n("v-card",{attrs:{extended:""}},[n("img",{attrs:{src:"/terminal/"+t.terminal.id+"/auth.png"}}),n("v-autocomplete",{attrs:{items:t.groups,disabled:!t.isEditing,box:"",chips:"",color:"blue-grey lighten-2",label:"Группы","item-text":"name","item-value":"name",multiple:""},scopedSlots:t._u([{key:"selection",fn:function(e){return[n("v-chip",{staticClass:"chip--select-multi",attrs:{selected:e.selected,close:t.isEditing},on:{input:function(t){return e.parent.selectItem(e.item)}}},[t._v("\n                    "+t._s(e.item.name)+"\n                ")])]}},{key:"item",fn:function(e){return["object"!==typeof e.item?[n("v-list-tile-content",{domProps:{textContent:t._s(e.item)}})]:[n("v-list-tile-content",{attrs:{hint:"123123"}},[n("v-list-tile-title",{domProps:{innerHTML:t._s(e.item.name)}}),n("v-list-tile-sub-title",{domProps:{innerHTML:t._s(e.item.group)}})],1)]]}}]),model:{value:t.model,callback:function(e){t.model=e},expression:"model"}}),n("v-btn",{attrs:{color:"blue darken-3",fab:"",small:"",absolute:"",bottom:"",right:""},on:{click:t.save_input}},[t.isEditing?n("v-icon",[t._v("done")]):n("v-icon",[t._v("edit")])],1)],1)],1)},Ge=[];function Ne(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function Re(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?Ne(n,!0).forEach((function(e){Ie(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):Ne(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function Ie(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}function qe(t,e){for(var n=0;n<e.length;n++)if(e[n].id===t)return e[n]}function Me(t,e){for(var n=0;n<e.length;n++)if(e[n].name===t)return e[n]}Array.prototype.clear=function(){this.length>0&&this.splice(0,this.length)};var Ye={name:"Terminal",components:{BuildingCard:Oe},props:["terminal"],methods:Re({},Object(u["b"])({groupsRemove:b,terminalSet:h}),{building_change:function(t){this.group=Object.assign(this.group,t),this.groupSet(this.group)},save_input:function(){var t=this;this.isEditing=!this.isEditing,this.isEditing||(this.terminal.groups=[],this.model.map((function(e){return t.terminal.groups.push(Me(e,t.groups).id)})),this.terminalSet(this.terminal))},mouseLeave:function(){var t=this;setTimeout((function(){return t.hidden=!0}),800)},removeAction:function(){this.groupsRemove({name:this.group.name})}}),data:function(){return{isEditing:!1,hidden:!1,model:[],isOpen:!1}},computed:Re({},Object(u["c"])({groups:function(t){return t.terminals.groups}})),mounted:function(){var t=this;this.hidden=!1,this.terminal.groups&&this.terminal.groups.map((function(e){return t.model.push(qe(e,t.groups).name)}))}},ze=Ye,Be=Object(N["a"])(ze,Le,Ge,!1,null,"27839ae6",null),Ue=Be.exports;function Qe(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function Fe(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?Qe(n,!0).forEach((function(e){Je(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):Qe(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function Je(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var He={name:"terminals",components:{TerminalGroup:Te,Terminal:Ue},data:function(){return{dialog:!1,dialogTerm:!1,groupName:"",terminalName:""}},computed:Fe({},Object(u["c"])({groups:function(t){return t.terminals.groups},terminals:function(t){return t.terminals.terminals}})),methods:Fe({addGroup:function(){this.dialog=!this.dialog,this.groupAdd({name:this.groupName})},addTerminal:function(){this.dialogTerm=!this.dialogTerm,this.terminalAdd({name:this.terminalName})},showDialog:function(){this.groupName="",this.dialog=!this.dialog,this.$nextTick(this.$refs.groupNameInput.focus)},showDialogTerm:function(){this.terminalName="",this.dialogTerm=!this.dialogTerm,this.$nextTick(this.$refs.terminalNameInput.focus)}},Object(u["b"])({groupsGet:m,terminalsGet:p,terminalAdd:v,groupAdd:g})),created:function(){this.groupsGet(),this.terminalsGet()}},Xe=He,Ke=Object(N["a"])(Xe,he,ge,!1,null,"9dae002c",null),We=Ke.exports,Ve=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",{attrs:{fluid:""}},[n("v-flex",{attrs:{xs12:""}},[n("v-autocomplete",{attrs:{disabled:t.isUpdating,items:t.dbs,box:"",chips:"",color:"blue-grey lighten-2",label:"Базы","item-text":"name","item-value":"name",multiple:""},scopedSlots:t._u([{key:"selection",fn:function(e){return[n("v-chip",{staticClass:"chip--select-multi",attrs:{selected:e.selected,close:""},on:{input:function(t){return e.parent.selectItem(e.item)}}},[t._v("\n                    "+t._s(e.item.name)+"\n                ")])]}},{key:"item",fn:function(e){return["object"!==typeof e.item?[n("v-list-tile-content",{domProps:{textContent:t._s(e.item)}})]:[n("v-list-tile-content",[n("v-list-tile-title",{domProps:{innerHTML:t._s(e.item.name)}})],1)]]}}]),model:{value:t.friends,callback:function(e){t.friends=e},expression:"friends"}})],1),n("v-flex",{attrs:{xs12:""}},[n("v-textarea",{attrs:{"auto-grow":"",name:"input-7-1",label:"SQL запрос",hint:"Внимательно проверьте запрос перед выполнением"},model:{value:t.sql_query,callback:function(e){t.sql_query=e},expression:"sql_query"}})],1),n("v-btn",{attrs:{color:"success"},on:{click:t.doQuery}},[t._v("Выполнить")]),n("v-data-table",{staticClass:"elevation-1",attrs:{items:t.JsonResult,headers:t.headers_data,"hide-actions":"",loading:t.isProcess},scopedSlots:t._u([{key:"items",fn:function(e){return t._l(e.item,(function(e){return n("td",{key:e.name},[t._v("\n                "+t._s(e)+"\n            ")])}))}}])},[n("v-progress-linear",{attrs:{slot:"progress",color:"blue",indeterminate:""},slot:"progress"}),n("template",{slot:"no-data"},[n("div",{staticClass:"text-xs-center"},[t._v("Нет данных")])])],2)],1)},Ze=[];function $e(t){return nn(t)||en(t)||tn()}function tn(){throw new TypeError("Invalid attempt to spread non-iterable instance")}function en(t){if(Symbol.iterator in Object(t)||"[object Arguments]"===Object.prototype.toString.call(t))return Array.from(t)}function nn(t){if(Array.isArray(t)){for(var e=0,n=new Array(t.length);e<t.length;e++)n[e]=t[e];return n}}function an(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function rn(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?an(n,!0).forEach((function(e){sn(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):an(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function sn(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var on={name:"SqlRunner",data:function(){return{isLoading:!1,headers:[],isUpdating:!1,sql_query:'SELECT "OrgName","OrgId","INN" FROM "Org"',friends:["Sandbox","Test"],dbs:[]}},computed:rn({},Object(u["c"])({results:function(t){return t.sqlrunner.results},isProcess:function(t){return t.sqlrunner.isProcess}}),{JsonResult:function(){var t,e=this;try{this.results.map((function(n,a){var i=JSON.parse(n);t="undefined"===typeof t?i:[].concat($e(t),$e(i)),t.push({server_alias:e.friends[a]})}))}catch(n){}return t},headers_data:function(){if(null!=this.JsonResult){var t=[],e=Object.keys(this.JsonResult[0]);return e.map((function(e){return t.push({text:e,vaule:e})})),t}return[{text:"",vaule:""}]}}),methods:rn({test:function(){console.log(this.results),console.log(this.headers_data)},doQuery:function(){var t=this;this.isLoading=!0;var e=[];this.friends.map((function(n){return e.push(t.dbs.find((function(t){return t.name===n})).connString)})),e.map((function(e){return t.sqlQuery({constring:e,query:t.sql_query})}))}},Object(u["b"])({sqlQuery:_})),watch:{isUpdating:function(t){var e=this;t&&setTimeout((function(){return e.isUpdating=!1}),3e3)}}},cn=on,ln=Object(N["a"])(cn,Ve,Ze,!1,null,"2bb5acc2",null),un=ln.exports;a["default"].use(ht["a"]);var dn=new ht["a"]({mode:"history",routes:[{path:"/",name:"home",title:"СКД",component:ae,meta:{title:"SKD"}},{path:"/about",name:"about",component:yt},{path:"/logs",name:"logs",component:Tt,meta:{requiresAuth:!0,title:"Logs"}},{path:"/upload",name:"upload",component:ve,meta:{requiresAuth:!0,title:"Logs"}},{path:"/terminals",name:"terminals",component:We,meta:{requiresAuth:!0,title:"Terminal"}},{path:"/check",name:"check",component:le,meta:{title:"Check Ticket"}},{path:"/settings",name:"settings",component:Qt,meta:{requiresAuth:!0,title:"Settings"}},{path:"/stat",name:"stat",component:ae,meta:{title:"Статистика"}},{path:"/sqlrunner",name:"sqlrunner",component:un,meta:{requiresAuth:!0}}]}),fn=n("2c0a"),mn=n.n(fn);a["default"].use(mn.a),a["default"].config.productionTip=!1,dn.beforeEach((function(t,e,n){document.title=t.meta.title,n()})),new a["default"]({store:vt,beforeCreate:function(){this.$store.commit("initialiseStore")},router:dn,render:function(t){return t(z)}}).$mount("#app")},"5d20":function(t,e,n){"use strict";var a=n("6256"),i=n.n(a);i.a},6256:function(t,e,n){},6688:function(t,e,n){},"91b8":function(t,e,n){t.exports=n.p+"img/oceanarium.c8d52b4b.svg"},ac06:function(t,e,n){},bc4f:function(t,e,n){"use strict";var a=n("eb9a"),i=n.n(a);i.a},c8f8:function(t,e,n){},eb9a:function(t,e,n){},ee37:function(t,e){t.exports="data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAADAAAAAwCAYAAABXAvmHAAAIOElEQVR42s0aC1BU1/Xc994usOBadJEQQAFlgQWEpH4yxemMtbQlBBvyswwhMQ5q1cR/YrTNOEljo9GJEm1aojVEhjGNtSEhaqa2tcmEptoYEWT5BKsBRCIqH5ddlt33bs97sviIvN19u6vlzNy5537fPZ937jnnPQIBgC2fRzH6CCYd0dlYTFjisRiw6IamWLFcxXIBixnLSUu3ULfxgcuCv88mPh+6Gg9tYOfiDoVAaS4QMkkaoJQiTrzAuxA7AhQqrH3CiQ0zO3wiRjUBr395LxccRhbiyvXYNPrLwSFoRkJ2OOxQti7jkvOOEfBmU0wOcm8Xcs+ogtPu8ZFtJISuXpl86VhACdh5LlrPcmQ3okX05iKCNSVD6/3BFcbKqQDPrTK19/lNQElDTApWH0Dg1MVbaMaSvyqlvcFnAkoaYrMo0CqUbvgwtxCwTVy1vM8X3MO8bgIkb1VKW7VqAnbh4XHwGBIQhpvIxI0PwLarlvf5gnsxz4JqlbNagYhRCdhpjknBAXHB9wKlD35CDxKRtcZ0uzrdRsD2mhg9pyWnkAdJEheQDYjLxH2zLe/3B1expgnLrLWpbX1uCdhpnvwuTi+CYRHjMpm4b7Xl/f7gqtaUrzG1Pq1IwBv1sTkg3o5jG3JRCsP3xDABr3wew4WGM/UgmksUHbhELMflbaU5anH1a5odNpr64ow25wgCdtRNKcbW3uEtb43JcXlbaY5aXP0aCovXp3+zb5iAF49GMxNjOTPc/ctKgpmGpTA9/Bfw2bfb4Ou+T7xZ0mztFUyb57QJEgGv18bNw+q4Sm75LQGxMSdyHcyOWCFdXjx1wEety2lL39+8WZ/9wvSLf5c6tp2N24/VQnUWwz8rRAhD5kZtgu8bFkn67bp9ncIgrfzml+S/N/7pYS8o25BxcRFZfSiauceovYybRKix2f7Zfob+JPplkjmxcGjPka4ET+3kQMsj0GVrVNwLsa7+63wUee1MfAY2zvipxl4DAxw8GLsVUsPzFefwPA/vtTwN7fYv3G9G4T7y2zMJSxAtBfUWQ7Xes0RD8qa8AcnjcxSdOYfDQf/asp3U2vaLgvK071Ky5aupJYit9NZ31zA6ep+hgHRYa2i75bTXfj/LaGl+3G6SOH6eojc66BiEY81b6LmBCsIwnvfFuoS8enrqh9jI80r8jBYWJLwNceOycBMBTnTsgJNX9g29U8qgYXXwaPxbEI/rlEA8fFXTZmgaPCz5ut4Azqoiv/lyWjXy4geebkCC2js/bjvqbp4sHgBivn4UPm7dRB2CddS1WjaMLJhaCrFhMxXjgQG7jVY2bSItgx+LD/L6Vka+/Yu88p9pZ3CvTE/m78cxG+GByGdGDWiu2JroofMrSLe9dcTaYFZPCxL/SKJDMxSDFttAPxxufIFecBxHyzr6s5Vw3KaGvHwqEQmADHeiuickDYpNh92Kc4Dvg8oL66Gl91OpreMmQqFxP0SGJCuusQ5Y4P2GtdDGfzripvEWkIyzZPMXxmqGRRVyQ60GxpFHY34P0yJniMkDxZBSoDxUd+6lHf215EfR68AQnKAYUlpsveS9hlXQwf9b4qUvl6LAowq9VJ30IcvR+eDJFDqD4IeGtTAnvpBqNBq/YuLe/uv0oPlZ0klPux7ikyvCO8lH5FefJe3itLDSG5EJAkBi0M9gvvEl0IdOUC9zhB7LVahoWA5dtM73tOAQ8A54k2z8R9ISjY6Uehs9iZwcTxPIIwlb6ZSINLTXjNcSuHajk1SYl8E1aBxhhXz1qzAuWEqe/yQpI0hPaqQQRcVNzDp0NDtqA5k1JR84jnNLgJh46+ptp+UNy0gPnIfvPMs3FUJDOmiBTPLUnnjm3rTgy6wGItSKEN9ZSAvNh7zkDRAaMk5xXmf3RcDDQx9p9VNpRqhPV2+HI0qi6PnjKfs1IWShGgm4alGYBpJEHzduJTGGRGlcLoFL11rIgcbl0E86AheRIY7qU7Y9u2GR1LHmqGmeNhSOo4vuczyg4fXkodhfw/2Tf0pRpSS1ae1qpAcaVxArc8XLPb3DqUDpYD9k73zQfDOgKXprKjNpWrCZC/YvpKROAjPCCyA7YRl03jiPLvE6GGCuBUxtXOAcgOa+K07T3iebhGEurKxKKw4Kw6CecSs6eXvUOVRAFXJoicA4gNF4dIfBw9jtOJpy5P7ikofO3QrqRVj0TjKnj9TUcyGQGHCWBZL7Nvja1sunlhaYR6ZVRHi2Mj0H74QjDDccc8NYyswJqKIOK83d83Dd7YktF6yonP6uNgyKZImnsZEXQsuPdr/8dw/XKqcWRSiuSNMHhbGnNKE3k7tjhQBHPzQ57XRW6RO17pO7Iiw9lJHCaqGa05ExkV53WmmP4ICsPzx21nN63QVL/pyZxWjgmCYYwv6f74BjACx4+Jy3H6vx/gOHCxa/j0RwpAotU/iIVOzdUCEqWZxugad5ex+vUf+JyQXFf8pMwRv6Ay6EGAnraXZgQPSxnDbajDdu/r4FNb5/5HPBU2UZek0wu5vRQhEbJEX4d0QCovLwdjSXg1DOO4Xnyp6s8f8zqxyeOXh/Dvppu9ggMOL7IftY7ec7gI4T6jnh7dCM6Op3Cr4K7IduOTyxJ53TTdBKvxqwGiISAlI2Qe1OVHI7AA+OrjGVfjWwW5xlB5ecvXO/Gsjh59tSmfHRIXMRLUQCchkWJhEWdYtFX4iRZXBkFxEemIj6TXlEeehCv+YIjlbc+NZ+4i9r6+7Ozx6jQe6rJmbCZF06atRsPKcJ63j4zu82qBpXsf8i4vWIn+xpt9VVbaz3+3eb/wGxx1j/I/aT+QAAAABJRU5ErkJggg=="}});
+(function(t){function e(e){for(var a,s,o=e[0],c=e[1],l=e[2],d=0,f=[];d<o.length;d++)s=o[d],Object.prototype.hasOwnProperty.call(i,s)&&i[s]&&f.push(i[s][0]),i[s]=0;for(a in c)Object.prototype.hasOwnProperty.call(c,a)&&(t[a]=c[a]);u&&u(e);while(f.length)f.shift()();return r.push.apply(r,l||[]),n()}function n(){for(var t,e=0;e<r.length;e++){for(var n=r[e],a=!0,o=1;o<n.length;o++){var c=n[o];0!==i[c]&&(a=!1)}a&&(r.splice(e--,1),t=s(s.s=n[0]))}return t}var a={},i={app:0},r=[];function s(e){if(a[e])return a[e].exports;var n=a[e]={i:e,l:!1,exports:{}};return t[e].call(n.exports,n,n.exports,s),n.l=!0,n.exports}s.m=t,s.c=a,s.d=function(t,e,n){s.o(t,e)||Object.defineProperty(t,e,{enumerable:!0,get:n})},s.r=function(t){"undefined"!==typeof Symbol&&Symbol.toStringTag&&Object.defineProperty(t,Symbol.toStringTag,{value:"Module"}),Object.defineProperty(t,"__esModule",{value:!0})},s.t=function(t,e){if(1&e&&(t=s(t)),8&e)return t;if(4&e&&"object"===typeof t&&t&&t.__esModule)return t;var n=Object.create(null);if(s.r(n),Object.defineProperty(n,"default",{enumerable:!0,value:t}),2&e&&"string"!=typeof t)for(var a in t)s.d(n,a,function(e){return t[e]}.bind(null,a));return n},s.n=function(t){var e=t&&t.__esModule?function(){return t["default"]}:function(){return t};return s.d(e,"a",e),e},s.o=function(t,e){return Object.prototype.hasOwnProperty.call(t,e)},s.p="/";var o=window["webpackJsonp"]=window["webpackJsonp"]||[],c=o.push.bind(o);o.push=e,o=o.slice();for(var l=0;l<o.length;l++)e(o[l]);var u=c;r.push([0,"chunk-vendors"]),n()})({0:function(t,e,n){t.exports=n("56d7")},"2c27":function(t,e,n){"use strict";var a=n("ac06"),i=n.n(a);i.a},"2e8d":function(t,e,n){"use strict";var a=n("4a00"),i=n.n(a);i.a},4678:function(t,e,n){var a={"./af":"2bfb","./af.js":"2bfb","./ar":"8e73","./ar-dz":"a356","./ar-dz.js":"a356","./ar-kw":"423e","./ar-kw.js":"423e","./ar-ly":"1cfd","./ar-ly.js":"1cfd","./ar-ma":"0a84","./ar-ma.js":"0a84","./ar-sa":"8230","./ar-sa.js":"8230","./ar-tn":"6d83","./ar-tn.js":"6d83","./ar.js":"8e73","./az":"485c","./az.js":"485c","./be":"1fc1","./be.js":"1fc1","./bg":"84aa","./bg.js":"84aa","./bm":"a7fa","./bm.js":"a7fa","./bn":"9043","./bn.js":"9043","./bo":"d26a","./bo.js":"d26a","./br":"6887","./br.js":"6887","./bs":"2554","./bs.js":"2554","./ca":"d716","./ca.js":"d716","./cs":"3c0d","./cs.js":"3c0d","./cv":"03ec","./cv.js":"03ec","./cy":"9797","./cy.js":"9797","./da":"0f14","./da.js":"0f14","./de":"b469","./de-at":"b3eb","./de-at.js":"b3eb","./de-ch":"bb71","./de-ch.js":"bb71","./de.js":"b469","./dv":"598a","./dv.js":"598a","./el":"8d47","./el.js":"8d47","./en-SG":"cdab","./en-SG.js":"cdab","./en-au":"0e6b","./en-au.js":"0e6b","./en-ca":"3886","./en-ca.js":"3886","./en-gb":"39a6","./en-gb.js":"39a6","./en-ie":"e1d3","./en-ie.js":"e1d3","./en-il":"73332","./en-il.js":"73332","./en-nz":"6f50","./en-nz.js":"6f50","./eo":"65db","./eo.js":"65db","./es":"898b","./es-do":"0a3c","./es-do.js":"0a3c","./es-us":"55c9","./es-us.js":"55c9","./es.js":"898b","./et":"ec18","./et.js":"ec18","./eu":"0ff2","./eu.js":"0ff2","./fa":"8df4","./fa.js":"8df4","./fi":"81e9","./fi.js":"81e9","./fo":"0721","./fo.js":"0721","./fr":"9f26","./fr-ca":"d9f8","./fr-ca.js":"d9f8","./fr-ch":"0e49","./fr-ch.js":"0e49","./fr.js":"9f26","./fy":"7118","./fy.js":"7118","./ga":"5120","./ga.js":"5120","./gd":"f6b4","./gd.js":"f6b4","./gl":"8840","./gl.js":"8840","./gom-latn":"0caa","./gom-latn.js":"0caa","./gu":"e0c5","./gu.js":"e0c5","./he":"c7aa","./he.js":"c7aa","./hi":"dc4d","./hi.js":"dc4d","./hr":"4ba9","./hr.js":"4ba9","./hu":"5b14","./hu.js":"5b14","./hy-am":"d6b6","./hy-am.js":"d6b6","./id":"5038","./id.js":"5038","./is":"0558","./is.js":"0558","./it":"6e98","./it-ch":"6f12","./it-ch.js":"6f12","./it.js":"6e98","./ja":"079e","./ja.js":"079e","./jv":"b540","./jv.js":"b540","./ka":"201b","./ka.js":"201b","./kk":"6d79","./kk.js":"6d79","./km":"e81d","./km.js":"e81d","./kn":"3e92","./kn.js":"3e92","./ko":"22f8","./ko.js":"22f8","./ku":"2421","./ku.js":"2421","./ky":"9609","./ky.js":"9609","./lb":"440c","./lb.js":"440c","./lo":"b29d","./lo.js":"b29d","./lt":"26f9","./lt.js":"26f9","./lv":"b97c","./lv.js":"b97c","./me":"293c","./me.js":"293c","./mi":"688b","./mi.js":"688b","./mk":"6909","./mk.js":"6909","./ml":"02fb","./ml.js":"02fb","./mn":"958b","./mn.js":"958b","./mr":"39bd","./mr.js":"39bd","./ms":"ebe4","./ms-my":"6403","./ms-my.js":"6403","./ms.js":"ebe4","./mt":"1b45","./mt.js":"1b45","./my":"8689","./my.js":"8689","./nb":"6ce3","./nb.js":"6ce3","./ne":"3a39","./ne.js":"3a39","./nl":"facd","./nl-be":"db29","./nl-be.js":"db29","./nl.js":"facd","./nn":"b84c","./nn.js":"b84c","./pa-in":"f3ff","./pa-in.js":"f3ff","./pl":"8d57","./pl.js":"8d57","./pt":"f260","./pt-br":"d2d4","./pt-br.js":"d2d4","./pt.js":"f260","./ro":"972c","./ro.js":"972c","./ru":"957c","./ru.js":"957c","./sd":"6784","./sd.js":"6784","./se":"ffff","./se.js":"ffff","./si":"eda5","./si.js":"eda5","./sk":"7be6","./sk.js":"7be6","./sl":"8155","./sl.js":"8155","./sq":"c8f3","./sq.js":"c8f3","./sr":"cf1e","./sr-cyrl":"13e9","./sr-cyrl.js":"13e9","./sr.js":"cf1e","./ss":"52bd","./ss.js":"52bd","./sv":"5fbd","./sv.js":"5fbd","./sw":"74dc","./sw.js":"74dc","./ta":"3de5","./ta.js":"3de5","./te":"5cbb","./te.js":"5cbb","./tet":"576c","./tet.js":"576c","./tg":"3b1b","./tg.js":"3b1b","./th":"10e8","./th.js":"10e8","./tl-ph":"0f38","./tl-ph.js":"0f38","./tlh":"cf75","./tlh.js":"cf75","./tr":"0e81","./tr.js":"0e81","./tzl":"cf51","./tzl.js":"cf51","./tzm":"c109","./tzm-latn":"b53d","./tzm-latn.js":"b53d","./tzm.js":"c109","./ug-cn":"6117","./ug-cn.js":"6117","./uk":"ada2","./uk.js":"ada2","./ur":"5294","./ur.js":"5294","./uz":"2e8c","./uz-latn":"010e","./uz-latn.js":"010e","./uz.js":"2e8c","./vi":"2921","./vi.js":"2921","./x-pseudo":"fd7e","./x-pseudo.js":"fd7e","./yo":"7f33","./yo.js":"7f33","./zh-cn":"5c3a","./zh-cn.js":"5c3a","./zh-hk":"49ab","./zh-hk.js":"49ab","./zh-tw":"90ea","./zh-tw.js":"90ea"};function i(t){var e=r(t);return n(e)}function r(t){if(!n.o(a,t)){var e=new Error("Cannot find module '"+t+"'");throw e.code="MODULE_NOT_FOUND",e}return a[t]}i.keys=function(){return Object.keys(a)},i.resolve=r,t.exports=i,i.id="4678"},"4a00":function(t,e,n){},5376:function(t,e,n){"use strict";var a=n("6688"),i=n.n(a);i.a},"548c":function(t,e,n){"use strict";var a=n("c8f8"),i=n.n(a);i.a},"56d7":function(t,e,n){"use strict";n.r(e);n("744f"),n("6c7b"),n("7514"),n("20d6"),n("1c4c"),n("6762"),n("cadf"),n("e804"),n("55dd"),n("d04f"),n("c8ce"),n("217b"),n("7f7f"),n("f400"),n("7f25"),n("536b"),n("d9ab"),n("f9ab"),n("32d7"),n("25c9"),n("9f3c"),n("042e"),n("c7c6"),n("f4ff"),n("049f"),n("7872"),n("a69f"),n("0b21"),n("6c1a"),n("c7c62"),n("84b4"),n("c5f6"),n("2e37"),n("fca0"),n("7cdf"),n("ee1d"),n("b1b1"),n("87f3"),n("9278"),n("5df2"),n("04ff"),n("f751"),n("4504"),n("fee7"),n("ffc1"),n("0d6d"),n("9986"),n("8e6e"),n("25db"),n("e4f7"),n("b9a1"),n("64d5"),n("9aea"),n("db97"),n("66c8"),n("57f0"),n("165b"),n("456d"),n("cf6a"),n("fd24"),n("8615"),n("551c"),n("097d"),n("df1b"),n("2397"),n("88ca"),n("ba16"),n("d185"),n("ebde"),n("2d34"),n("f6b3"),n("2251"),n("c698"),n("a19f"),n("9253"),n("9275"),n("3b2b"),n("3846"),n("4917"),n("a481"),n("28a5"),n("386d"),n("6b54"),n("4f7f"),n("8a81"),n("ac4d"),n("8449"),n("9c86"),n("fa83"),n("48c0"),n("a032"),n("aef6"),n("d263"),n("6c37"),n("9ec8"),n("5695"),n("2fdb"),n("d0b0"),n("5df3"),n("b54a"),n("f576"),n("ed50"),n("788d"),n("14b9"),n("f386"),n("f559"),n("1448"),n("673e"),n("242a"),n("c66f"),n("b05c"),n("34ef"),n("6aa2"),n("15ac"),n("af56"),n("b6e4"),n("9c29"),n("63d9"),n("4dda"),n("10ad"),n("c02b"),n("4795"),n("130f"),n("ac6a"),n("96cf");var a=n("2b0e"),i=n("ce5b"),r=n.n(i);n("bf40");a["default"].use(r.a,{});var s=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-app",{attrs:{dark:""}},[n("nav-bar"),n("v-content",[n("v-container",{attrs:{"fill-height":""}},[n("v-layout",{attrs:{"justify-center":"","align-center":""}},[n("v-flex",[n("router-view")],1)],1)],1)],1),n("v-footer",{attrs:{height:"auto"}},[n("v-layout",{attrs:{"justify-center":"",row:"",wrap:""}},[n("v-flex",{attrs:{"py-3":"","text-xs-center":"","grey--text":"",xs12:""}},[t._v("\n                ©2018 — "),n("strong",[t._v("Городские зрелищные кассы")])])],1)],1)],1)},o=[],c=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("nav",[n("v-toolbar",{attrs:{color:"indigo",dense:"",fixed:"","clipped-left":"",app:""}},[n("v-toolbar-side-icon",{on:{click:function(e){e.stopPropagation(),t.drawer=!t.drawer}}}),n("v-toolbar-title",{attrs:{to:{path:"/about"}}},[n("b",[t._v("kassy.ru ")]),n("small",[t._v(" | ")]),n("small",{staticClass:"captionText"},[n("span",{staticClass:"upperLetter"},[t._v("С")]),t._v("истема "),n("span",{staticClass:"upperLetter"},[t._v("К")]),t._v("онтроля "),n("span",{staticClass:"upperLetter"},[t._v("Д")]),t._v("оступа ")])]),n("v-spacer"),t.isAuth?n("v-btn",{attrs:{flat:""},on:{click:function(e){return e.stopPropagation(),t.logout(e)}}},[n("v-icon"),t._v("\n             Выйти\n        ")],1):n("v-btn",{attrs:{flat:""},on:{click:function(e){e.stopPropagation(),t.login_dialog=!0}}},[n("v-icon",[t._v("account_box")]),t._v("\n             Авторизация\n        ")],1)],1),n("v-navigation-drawer",{attrs:{clipped:"",fixed:"",app:"",dark:""},model:{value:t.drawer,callback:function(e){t.drawer=e},expression:"drawer"}},[n("v-list",{attrs:{dense:""}},t._l(t.items,(function(e){return!e.auth||t.isAuth?n("v-list-tile",{key:e.text,attrs:{to:e.path},on:{click:function(t){}}},[n("v-list-tile-action",[n("v-icon",[t._v(t._s(e.icon))])],1),n("v-list-tile-content",[t._v("\n                    "+t._s(e.text)+"\n                ")])],1):t._e()})),1)],1),n("v-snackbar",{attrs:{color:t.snackbar_color,"multi-line":"multi-line"===t.mode,timeout:t.timeout,vertical:"vertical"===t.mode},model:{value:t.snackbar,callback:function(e){t.snackbar=e},expression:"snackbar"}},[t._v("\n        "+t._s(t.snackbar_msg)+"\n        "),n("v-btn",{attrs:{dark:"",flat:""},on:{click:function(e){t.snackbar=!1}}},[t._v("\n            Close\n        ")])],1),n("v-dialog",{attrs:{"max-width":"400px"},model:{value:t.login_dialog,callback:function(e){t.login_dialog=e},expression:"login_dialog"}},[n("v-card",[n("v-card-title",[n("span",{staticClass:"headline"},[t._v("Авторизация")])]),n("v-card-text",[n("v-container",{attrs:{"grid-list-md":""}},[n("v-layout",{attrs:{wrap:""}},[n("v-flex",{attrs:{xs12:""}},[n("v-text-field",{attrs:{label:"Email",required:""},model:{value:t.login_input,callback:function(e){t.login_input=e},expression:"login_input"}})],1),n("v-flex",{attrs:{xs12:""}},[n("v-text-field",{attrs:{label:"Password",type:"password",required:""},model:{value:t.password_input,callback:function(e){t.password_input=e},expression:"password_input"}})],1)],1)],1)],1),n("v-card-actions",[n("v-spacer"),n("v-btn",{attrs:{color:"grey",flat:""},nativeOn:{click:function(e){t.login_dialog=!1}}},[t._v("Закрыть")]),n("v-btn",{attrs:{color:"green",flat:""},nativeOn:{click:function(e){return t.doLogin(e)}}},[t._v("Войти")])],1)],1)],1)],1)},l=[],u=n("2f62"),d="authorization/LOGIN",f="authorization/LOGOUT",m="terminals/GROUPS_GET",p="terminals/TERMINALS_GET",v="terminals/TERMINAL_ADD",h="terminals/TERMINAL_SET",g="terminals/GROUP_ADD",b="terminals/GROUP_REMOVE",x="terminals/GROUP_SET",_="sqlrunner/QUERY",y="authorization/MUTATE_LOGIN_SET",j="authorization/MUTATE_LOGOUT_SET",k="authorization/MUTATE_API_ERROR",w="terminals/MUTATE_TERMINALS_GROUPS",O="terminals/MUTATE_TERMINALS",P="sqlrunner/QUERY_RESULT",E="sqlrunner/QUERY_PREPARE";function D(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function S(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?D(n,!0).forEach((function(e){C(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):D(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function C(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var A,T,L={name:"NavBar",data:function(){return{drawer:!1,items:[{icon:"trending_up",text:"Терминалы",path:"/terminals",auth:!0},{icon:"find_in_page",text:"Проверка билета",path:"/check",auth:!1},{icon:"cloud_upload",text:"Импорт",path:"/upload",auth:!0},{icon:"history",text:"Логи",path:"/logs",auth:!0},{icon:"settings",text:"Настройки",path:"/settings",auth:!0},{icon:"blur_linear",text:"Статистика",path:"/stat",auth:!1}],login_dialog:!1,login_input:"",password_input:"",snackbar:!1,snackbar_msg:"",timeout:6e3,mode:"multi-line",snackbar_color:"error"}},methods:S({doLogin:function(){this.login_dialog=!1,this.login({login:this.login_input,password:this.password_input})}},Object(u["b"])({logout:f,login:d})),computed:S({},Object(u["c"])({isAuth:function(t){return t.authorization.isAuth},exp:function(t){return t.authorization.exp},apiErr:function(t){return t.authorization.apiErr}})),watch:{apiErr:function(){this.snackbar_msg=this.apiErr.response.status+" "+this.apiErr.response.statusText,this.snackbar=!0},isAuth:function(t){this.drawer=t}},mounted:function(){}},G=L,N=(n("548c"),n("2877")),R=Object(N["a"])(G,c,l,!1,null,"1a024898",null),I=R.exports,q={title:"SKD kassy.ru",name:"app",data:function(){return{}},components:{NavBar:I}},M=q,Y=Object(N["a"])(M,s,o,!1,null,null,null),z=Y.exports,B=n("bc3a"),U=n.n(B),Q=U.a.create({withCredentials:!0}),F=n("4328"),J=n.n(F),H=200,X=401,K={checkTicket:function(t){return new Promise((function(e,n){Q.post("/check_ticket",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},login:function(t){return new Promise((function(e,n){Q.post("/login",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},loguot:function(){return new Promise((function(t,e){Q.post("/logout").then((function(e){t(e)})).catch((function(t){e(t)}))}))},getGroups:function(){return new Promise((function(t,e){Q.get("/groups").then((function(e){t(e)})).catch((function(t){e(t)}))}))},getLogs:function(){return new Promise((function(t,e){Q.get("/logs").then((function(e){t(e)})).catch((function(t){e(t)}))}))},getStats:function(t){return new Promise((function(e,n){Q.post("/stats",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},getTerminalss:function(){return new Promise((function(t,e){Q.get("/terminals").then((function(e){t(e)})).catch((function(t){e(t)}))}))},setTerminal:function(t){return new Promise((function(e,n){Q.post("/terminal/"+t.id,J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},addTerminal:function(t){return new Promise((function(e,n){Q.post("/add_terminal",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},addGroup:function(t){return new Promise((function(e,n){Q.post("/add_group",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},syncEvent:function(t){return new Promise((function(e,n){Q.get("/event/".concat(t,"/sync")).then((function(t){e(t)})).catch((function(t){n(t)}))}))},getEventInfo:function(t){return new Promise((function(e,n){Q.get("/event/".concat(t,"/info")).then((function(t){e(t)})).catch((function(t){n(t)}))}))},setGroup:function(t){return new Promise((function(e,n){Q.post("/set_group",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},removeGroup:function(t){return new Promise((function(e,n){Q.post("/remove_group",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))},getEventsByGroupId:function(t){return new Promise((function(e,n){Q.get("/events/"+t).then((function(t){e(t)})).catch((function(t){n(t)}))}))},sqlQuery:function(t){return new Promise((function(e,n){Q.post("/sql",J.a.stringify(t)).then((function(t){e(t)})).catch((function(t){n(t)}))}))}};function W(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var V={isAuth:!1,exp:null,apiErr:null,isInit:!1};function Z(t){t.isAuth=!1,t.exp=null,localStorage.setItem("authorization",JSON.stringify(t))}var $,tt,et=(A={initialiseStore:function(t){if(t.isInit=!0,localStorage.getItem("authorization")){var e=JSON.parse(localStorage.getItem("authorization"));Date.parse(e.exp)>Date.now()&&Object.assign(t,e)}}},W(A,y,(function(t,e){1e3*e.exp>Date.now()&&(t.exp=new Date(1e3*e.exp),t.isAuth=!0,localStorage.setItem("authorization",JSON.stringify(t)))})),W(A,j,(function(t,e){null!==e?e.code===H&&Z(t):Z(t)})),W(A,k,(function(t,e){t.apiErr=e,t.isAuth&&e.response.status===X&&Z(t)})),A),nt=(T={},W(T,d,(function(t,e){var n=t.commit;K.login(e).then((function(t){n(y,t.data)})).catch((function(t){n(k,t)}))})),W(T,f,(function(t){var e=t.commit;K.loguot().then((function(t){e(j,t.data)})).catch((function(t){e(k,t)}))})),T),at={state:V,mutations:et,actions:nt};function it(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var rt,st={groups:null,terminals:null},ot=($={},it($,w,(function(t,e){t.groups=e.groups})),it($,O,(function(t,e){t.terminals=e.terminals})),$),ct=(tt={},it(tt,m,(function(t){var e=t.commit;K.getGroups().then((function(t){e(w,t.data)})).catch((function(t){e(k,t)}))})),it(tt,p,(function(t){var e=t.commit;K.getTerminalss().then((function(t){e(O,t.data)})).catch((function(t){e(k,t)}))})),it(tt,h,(function(t,e){var n=t.commit,a=t.dispatch;K.setTerminal(e).then((function(){a(p)})).catch((function(t){n(k,t)}))})),it(tt,v,(function(t,e){var n=t.commit,a=t.dispatch;K.addTerminal(e).then((function(){a(p)})).catch((function(t){n(k,t)}))})),it(tt,g,(function(t,e){var n=t.commit,a=t.dispatch;K.addGroup(e).then((function(){a(m)})).catch((function(t){n(k,t)}))})),it(tt,x,(function(t,e){var n=t.commit,a=t.dispatch;K.setGroup(e).then((function(){a(m)})).catch((function(t){n(k,t)}))})),it(tt,b,(function(t,e){var n=t.commit,a=t.dispatch;K.removeGroup(e).then((function(){a(m)})).catch((function(t){n(k,t)}))})),tt),lt={state:st,mutations:ot,actions:ct};function ut(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var dt={results:[],isProcess:!1},ft=(rt={},ut(rt,P,(function(t,e){t.results.push(e),t.isProcess=!1})),ut(rt,E,(function(t){t.isProcess=!0,t.results=[]})),rt),mt=ut({},_,(function(t,e){var n=t.commit;n(E),K.sqlQuery(e).then((function(t){n(P,t.data)})).catch((function(t){console.log(t)}))})),pt={state:dt,mutations:ft,actions:mt};a["default"].use(u["a"]);var vt=new u["a"].Store({modules:{authorization:at,terminals:lt,sqlrunner:pt},state:{},mutations:{},actions:{}}),ht=n("8c4f"),gt=function(){var t=this,e=t.$createElement;t._self._c;return t._m(0)},bt=[function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"about"},[n("h1",[t._v("This is an about page")])])}],xt={},_t=Object(N["a"])(xt,gt,bt,!1,null,null,null),yt=_t.exports,jt=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",[n("v-text-field",{attrs:{"append-icon":"search",label:"Фильтр","single-line":"","hide-details":"",clearable:""},model:{value:t.search,callback:function(e){t.search=e},expression:"search"}}),n("v-data-table",{attrs:{loading:t.isLoading,items:t.logs,headers:t.headers,pagination:t.pagination,search:t.search,"select-all":"","item-key":"id"},on:{"update:pagination":function(e){t.pagination=e}},scopedSlots:t._u([{key:"headers",fn:function(e){return[n("tr",t._l(e.headers,(function(e){return n("th",{key:e.text,class:["column sortable",t.pagination.descending?"desc":"asc",e.value===t.pagination.sortBy?"active":""],on:{click:function(n){return t.changeSort(e.value)}}},[n("v-icon",{attrs:{small:""}},[t._v("arrow_upward")]),t._v("\n        "+t._s(e.text)+"\n      ")],1)})),0)]}},{key:"items",fn:function(e){return[n("tr",{class:{errorItem:t.isErrorCode(e.item.code)},attrs:{active:e.selected}},[n("td",{staticClass:"text-xs-left"},[n("small",{staticClass:"grey darken-2"},[t._v(t._s(t.frontEndDateFormat(e.item.dt)))]),n("br"),t._v(" "+t._s(t.frontEndDateFromNow(e.item.dt)))]),n("td",{staticClass:"text-xs-right"},[t._v(t._s(e.item.data))]),n("td",{staticClass:"text-xs-left"},[t._v(t._s(e.item.message))]),n("td",{staticClass:"text-xs-right"},[t._v(t._s(e.item.code))])])]}}])},[n("v-progress-linear",{attrs:{slot:"progress",color:"blue",indeterminate:""},slot:"progress"})],1)],1)},kt=[],wt=n("c1df"),Ot=n.n(wt);function Pt(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function Et(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?Pt(n,!0).forEach((function(e){Dt(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):Pt(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function Dt(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var St={name:"Logs",methods:Et({frontEndDateFormat:function(t){return Ot.a.locale("ru"),Ot.a.unix(t).format("DD/MM/YYYY HH:mm")},frontEndDateFromNow:function(t){return Ot.a.unix(t).fromNow()},changeSort:function(t){this.pagination.sortBy===t?this.pagination.descending=!this.pagination.descending:(this.pagination.sortBy=t,this.pagination.descending=!1)},isErrorCode:function(t){var e=[401,402],n=e.indexOf(t);return n>=0}},Object(u["b"])({groupsRemove:b,groupSet:x}),{getLogs:function(){var t=this;this.isLoading=!0,K.getLogs().then((function(e){t.logs=e.data,t.isLoading=!1})).catch((function(e){t.isLoading=!1,console.log(e)}))}}),data:function(){return{search:"",isLoading:!1,hidden:!1,isOpen:!1,pagination:{sortBy:"dt",descending:!0,rowsPerPage:25},headers:[{text:"Датa",value:"dt",align:"center",sort:"desc"},{text:"Данные",align:"left",value:"data",width:"10%"},{text:"Сообщение",value:"message",width:"90%",align:"right"},{text:"Код",value:"code"}],logs:[]}},mounted:function(){this.hidden=!1,this.getLogs()}},Ct=St,At=(n("2c27"),Object(N["a"])(Ct,jt,kt,!1,null,null,null)),Tt=At.exports,Lt=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",[n("v-list",{attrs:{"two-line":"",subheader:""}},[n("v-subheader",{attrs:{inset:""}},[t._v("Folders")]),t._l(t.items,(function(e){return n("v-list-tile",{key:e.title,attrs:{avatar:""},on:{click:function(t){}}},[n("v-list-tile-avatar",[n("v-icon",{class:[e.iconClass]},[t._v(t._s(e.icon))])],1),n("v-list-tile-content",[n("v-list-tile-title",[t._v(t._s(e.title))]),n("v-list-tile-sub-title",[t._v(t._s(e.subtitle))])],1),n("v-list-tile-action",[n("v-btn",{attrs:{icon:"",ripple:""}},[n("v-icon",{attrs:{color:"grey lighten-1"}},[t._v("info")])],1)],1)],1)}))],2),n("v-subheader",{staticClass:"pl-0"},[t._v("Время блокировки билета после проверки")]),n("v-slider",{attrs:{"thumb-label":"always"},model:{value:t.slider,callback:function(e){t.slider=e},expression:"slider"}}),n("v-card",{attrs:{flat:""}},[n("v-card-text",[n("v-container",{attrs:{fluid:""}},[n("v-layout",{attrs:{row:"",wrap:""}},[n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"red",color:"red",value:"red","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"red darken-3",color:"red darken-3",value:"red darken-3","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1),n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"indigo",color:"indigo",value:"indigo","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"indigo darken-3",color:"indigo darken-3",value:"indigo darken-3","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1),n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"orange",color:"orange",value:"orange","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"orange darken-3",color:"orange darken-3",value:"orange darken-3","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1)],1),n("v-layout",{staticClass:"mt-5",attrs:{row:"",wrap:""}},[n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"primary",color:"primary",value:"primary","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"secondary",color:"secondary",value:"secondary","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1),n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"success",color:"success",value:"success","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"info",color:"info",value:"info","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1),n("v-flex",{attrs:{xs12:"",sm4:"",md4:""}},[n("v-switch",{attrs:{label:"warning",color:"warning",value:"warning","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}}),n("v-switch",{attrs:{label:"error",color:"error",value:"error","hide-details":""},model:{value:t.ex11,callback:function(e){t.ex11=e},expression:"ex11"}})],1)],1)],1)],1)],1)],1)},Gt=[],Nt=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",{staticClass:"hello"})},Rt=[],It={name:"HelloWorld",props:{msg:String}},qt=It,Mt=(n("bc4f"),Object(N["a"])(qt,Nt,Rt,!1,null,"c4e39d4a",null)),Yt=Mt.exports,zt={name:"home",components:{HelloWorld:Yt},data:function(){return{items:[{icon:"folder",iconClass:"grey lighten-1 white--text",title:"Photos",subtitle:"Jan 9, 2014"},{icon:"folder",iconClass:"grey lighten-1 white--text",title:"Recipes",subtitle:"Jan 17, 2014"},{icon:"folder",iconClass:"grey lighten-1 white--text",title:"Work",subtitle:"Jan 28, 2014"}],slider:11,ex11:["red","indigo","orange","primary","secondary","success","info","warning","error","red darken-3","indigo darken-3","orange darken-3"]}}},Bt=zt,Ut=Object(N["a"])(Bt,Lt,Gt,!1,null,"08ebdf80",null),Qt=Ut.exports,Ft=function(){var t=this,e=t.$createElement,a=t._self._c||e;return a("v-card",{staticClass:"mx-auto pt-5",attrs:{"max-width":"600"}},[a("v-card-text",{staticClass:"py-0"},[a("v-layout",{attrs:{row:"",wrap:""}},[a("v-flex",{attrs:{xs12:"",sm6:"",md4:""}},[a("v-img",{staticClass:"mt-2 ml-2",attrs:{src:n("91b8"),width:"130"}})],1),a("v-flex",{attrs:{xs12:"",sm6:"",md4:""}},[a("v-menu",{attrs:{"close-on-content-click":!1,"nudge-right":40,lazy:"",transition:"scale-transition","offset-y":"","full-width":"","min-width":"290px"},scopedSlots:t._u([{key:"activator",fn:function(e){var n=e.on;return[a("v-text-field",t._g({attrs:{label:"Начало периода","prepend-icon":"event",readonly:"",value:t.fromDateFormattedMomentjs}},n))]}}]),model:{value:t.menu,callback:function(e){t.menu=e},expression:"menu"}},[a("v-date-picker",{attrs:{locale:"ru"},on:{change:t.getStats,input:function(e){t.menu=!1}},model:{value:t.fromDate,callback:function(e){t.fromDate=e},expression:"fromDate"}})],1)],1),a("v-flex",{attrs:{xs12:"",sm6:"",md4:""}},[a("v-menu",{attrs:{"close-on-content-click":!1,"nudge-right":40,lazy:"",transition:"scale-transition","offset-y":"","full-width":"","min-width":"290px"},scopedSlots:t._u([{key:"activator",fn:function(e){var n=e.on;return[a("v-text-field",t._g({attrs:{value:t.toDateFormattedMomentjs,label:"Конец периода","prepend-icon":"event",readonly:""}},n))]}}]),model:{value:t.menu2,callback:function(e){t.menu2=e},expression:"menu2"}},[a("v-date-picker",{attrs:{locale:"ru"},on:{change:t.getStats,input:function(e){t.menu2=!1}},model:{value:t.toDate,callback:function(e){t.toDate=e},expression:"toDate"}})],1)],1)],1),a("events-stats-timeline",{staticClass:"pt-3",attrs:{transition:"slide-x-transition",events:t.events}})],1),a("v-progress-linear",{attrs:{active:t.loading,indeterminate:t.loading}})],1)},Jt=[],Ht=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("div",t._l(t.events,(function(e){return n("v-card",{key:e.id,staticClass:"mb-3 card-event"},[n("v-card-title",{attrs:{"primary-title":""}},[n("div",[n("div",{staticClass:"headline pb-1"},[n("span",{staticClass:"date"},[t._v(t._s(t.frontEndDateFormat(e.dt)))]),t._v(" "+t._s(e.title))])])]),n("v-card-text",[n("v-data-table",{staticClass:"elevation-1",attrs:{headers:t.headers,items:e.info,"hide-actions":!0},scopedSlots:t._u([{key:"items",fn:function(e){return[n("td",{staticClass:"light-green--text"},[t._v(t._s(e.item.id))]),n("td",{staticClass:"text-xs"},[t._v(t._s(e.item.sell))]),n("td",{staticClass:"text-xs"},[t._v(t._s(e.item.total))]),n("td",{staticClass:"text-xs"},[t._v(t._s(e.item.entry))])]}},{key:"footer",fn:function(){return[n("td",[n("strong",[t._v("Итого")])]),n("td",{staticClass:"text-xs"},[n("b",[t._v(t._s(e.sell))])]),n("td",{staticClass:"text-xs"},[n("b",[t._v(t._s(e.total))])]),n("td",{staticClass:"text-xs"},[n("b",[t._v(t._s(e.entry))])])]},proxy:!0}],null,!0)})],1)],1)})),1)},Xt=[],Kt={last_color:"",colors:["red","pink","purple","indigo","blue","teal","green","lime","yellow","orange","brown"],random_color:function(){return this.colors[Math.floor(Math.random()*this.colors.length)]},get_color:function(){var t=this.random_color();return t===this.last_color?this.get_color():(this.last_color=t,t)}},Wt={name:"EventsStatsTimeline",props:["events"],data:function(){return{headers:[{text:"Цена",align:"left",sortable:!0,value:"id"},{text:"Продано",value:"sell",sortable:!1},{text:"Сумма",value:"total",sortable:!1},{text:"Прошло",value:"entry",sortable:!1}]}},methods:{frontEndDateFormat:function(t){return Ot.a.locale("ru"),Ot.a.unix(t).format("DD.MM.YY  HH:mm ")},get_color:function(){return Kt.get_color()},percent:function(t){return t.entry/t.sell*100}},mounted:function(){}},Vt=Wt,Zt=(n("5376"),Object(N["a"])(Vt,Ht,Xt,!1,null,"782a9bce",null)),$t=Zt.exports,te={name:"Stats",components:{EventsStatsTimeline:$t},data:function(){return{fromDate:(new Date).toISOString().substr(0,10),toDate:(new Date).toISOString().substr(0,10),value:0,query:!1,show:!0,interval:0,loading:!1,loader:null,barcode:null,selected:[2],items:[],events:[],menu:!1,modal:!1,menu2:!1}},mounted:function(){},beforeDestroy:function(){clearInterval(this.interval)},methods:{today:function(){var t=Ot()();return Ot.a.lang("ru"),t.format("DD")},todayDay:function(){var t=Ot()();return Ot.a.lang("ru"),t.format("dddd")},todayMonth:function(){var t=Ot()();return Ot.a.lang("ru"),t.format("MMMM YYYYY")},getStats:function(){var t=this;null!=this.events&&this.events.clear(),this.loading=!0,K.getStats({from:this.fromDate,to:this.toDate}).then((function(e){console.log(e.data),t.loading=!1,t.events=e.data})).catch((function(e){t.loading=!1,console.log(e)}))}},computed:{fromDateFormattedMomentjs:function(){return console.log(this.fromDate),this.fromDate?Ot()(this.fromDate).format("DD.MM.YYYY"):""},toDateFormattedMomentjs:function(){return this.toDate?Ot()(this.toDate).format("DD.MM.YYYY"):""}}},ee=te,ne=(n("2e8d"),Object(N["a"])(ee,Ft,Jt,!1,null,null,null)),ae=ne.exports,ie=function(){var t=this,e=t.$createElement,a=t._self._c||e;return a("v-container",[a("v-flex",{attrs:{xs12:"",sm6:"","offset-sm3":""}},[a("v-layout",{attrs:{row:""}},[a("v-text-field",{attrs:{label:"Штрихкод",autofocus:""},nativeOn:{keyup:function(e){return!e.type.indexOf("key")&&t._k(e.keyCode,"enter",13,e.key,"Enter")?null:t.checkTicket()}},model:{value:t.barcode,callback:function(e){t.barcode=e},expression:"barcode"}}),a("v-btn",{attrs:{loading:t.loading,disabled:t.loading,color:"secondary",fab:""},nativeOn:{click:function(e){return t.checkTicket()}}},[a("v-icon",[t._v("find_in_page")])],1)],1),a("v-list",{attrs:{dense:""}},[t._l(t.items,(function(e,i){return[e.event.dt?a("v-subheader",{key:i},[t._v("\n              "+t._s(e.event.venue_title)+"/"+t._s(e.event.title)+" "),a("br"),t._v("\n              "+t._s(t.frontEndDateFormat(e.event.dt))+"\n            ")]):a("v-subheader",{key:i},[a("small",[t._v(t._s(e.ticket.barcode)+" Билет не найден")])]),e.ticket.dt?a("v-list-tile",{key:e.ticket},[a("v-list-tile-avatar",[a("img",{attrs:{src:n("ee37")}})]),a("v-list-tile-content",[a("v-list-tile-title",[t._v(t._s(e.ticket.barcode))]),a("v-list-tile-sub-title",[t._v(" "+t._s(t.frontEndDateFormat(e.ticket.dt)))])],1)],1):t._e(),t._l(e.entry,(function(e,n){return a("v-list-tile",{key:n},["entry"===e.direction&&1===e.result_code?a("v-icon",{staticClass:"light-blue--text"},[t._v("arrow_forward")]):"exit"===e.direction&&1===e.result_code?a("v-icon",{staticClass:"green--text "},[t._v("arrow_back")]):a("v-icon",{staticClass:"red--text text--lighten-2"},[t._v("cancel")]),a("small",[t._v(t._s(t.frontEndDateFormat(e.operation_dt)))]),t._v(" "+t._s(e.term.name)+"\n            ")],1)})),a("v-divider")]}))],2)],1)],1)},re=[],se={name:"Check",methods:{onBarcodeScanned:function(t){t!==this.barcode&&(this.barcode=t,this.checkTicket())},frontEndDateFormat:function(t){return Ot.a.locale("ru"),Ot.a.unix(t).format("DD/MM/YYYY HH:mm")},checkTicket:function(){var t=this;this.loading=!0,K.checkTicket({barcode:this.barcode}).then((function(e){t.barcode="",t.items.unshift(e.data),t.loading=!1})).catch((function(e){t.barcode="",t.loading=!1,t.loading=!1,console.log(e)}))}},data:function(){return{loading:!1,loader:null,barcode:null,selected:[2],items:[]}},mounted:function(){},created:function(){this.$barcodeScanner.init(this.onBarcodeScanned)},destroyed:function(){this.$barcodeScanner.destroy()}},oe=se,ce=Object(N["a"])(oe,ie,re,!1,null,null,null),le=ce.exports,ue=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",[n("v-flex",{attrs:{xs12:"",sm6:"","offset-sm3":""}})],1)},de=[],fe={name:"Check",methods:{},data:function(){return{loading:!1,loader:null,barcode:null,selected:[2],items:[]}},mounted:function(){}},me=fe,pe=Object(N["a"])(me,ue,de,!1,null,null,null),ve=pe.exports,he=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",{attrs:{"grid-list-md":""}},[n("v-layout",{attrs:{wrap:""}},[n("v-flex",{attrs:{sm12:""}},[n("v-toolbar",{attrs:{dense:""}},[n("v-toolbar-title",[t._v("Группы")]),n("v-spacer"),n("v-toolbar-items",[n("v-btn",{attrs:{flat:"",color:"green"},nativeOn:{click:function(e){return t.showDialog(e)}}},[n("v-icon",[t._v("add")])],1)],1)],1),n("v-expansion-panel",t._l(t.groups,(function(t){return n("terminal-group",{key:t.name,staticClass:"column",attrs:{group:t,lazy:""}})})),1),n("v-dialog",{attrs:{"max-width":"500px"},model:{value:t.dialog,callback:function(e){t.dialog=e},expression:"dialog"}},[n("v-card",[n("v-card-text",[n("v-text-field",{ref:"groupNameInput",attrs:{label:"Название группы"},nativeOn:{keyup:function(e){return!e.type.indexOf("key")&&t._k(e.keyCode,"enter",13,e.key,"Enter")?null:t.addGroup(e)}},model:{value:t.groupName,callback:function(e){t.groupName=e},expression:"groupName"}}),n("small",{staticClass:"grey--text"},[t._v("* Название должно быть уникальным")])],1),n("v-card-actions",[n("v-spacer"),n("v-btn",{attrs:{disabled:0==t.groupName.length,flat:"",color:"primary"},nativeOn:{click:function(e){return t.addGroup(e)}}},[t._v("Добавить\n                    ")])],1)],1)],1)],1),n("v-flex",{attrs:{sm12:""}},[n("v-toolbar",{attrs:{dense:""}},[n("v-toolbar-title",[t._v("Терминалы")]),n("v-spacer"),n("v-toolbar-items",[n("v-btn",{attrs:{flat:""},nativeOn:{click:function(e){return t.showDialogTerm(e)}}},[n("v-icon",[t._v("add")])],1)],1)],1),n("v-expansion-panel",t._l(t.terminals,(function(t){return n("terminal",{key:t.name,staticClass:"column",attrs:{terminal:t}})})),1),n("v-dialog",{attrs:{"max-width":"500px"},model:{value:t.dialogTerm,callback:function(e){t.dialogTerm=e},expression:"dialogTerm"}},[n("v-card",[n("v-card-text",[n("v-text-field",{ref:"terminalNameInput",attrs:{label:"Название терминала"},nativeOn:{keyup:function(e){return!e.type.indexOf("key")&&t._k(e.keyCode,"enter",13,e.key,"Enter")?null:t.addTerminal(e)}},model:{value:t.terminalName,callback:function(e){t.terminalName=e},expression:"terminalName"}}),n("small",{staticClass:"grey--text"},[t._v("* Название терминала должно быть уникальным")])],1),n("v-card-actions",[n("v-spacer"),n("v-btn",{attrs:{disabled:0==t.terminalName.length,flat:"",color:"primary"},nativeOn:{click:function(e){return t.addTerminal(e)}}},[t._v("Добавить\n                    ")])],1)],1)],1)],1)],1)],1)},ge=[],be=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-expansion-panel-content",[n("div",{attrs:{slot:"header"},slot:"header"},[t._v(t._s(t.group.name))]),n("v-card",{on:{mouseleave:t.mouseLeave,mouseover:t.mouseOver}},[n("building-card",{attrs:{building:t.group},on:{"change-building":t.building_change}})],1),n("v-text-field",{attrs:{"append-icon":"search",label:"Фильтр","single-line":"","hide-details":"",clearable:""},model:{value:t.search,callback:function(e){t.search=e},expression:"search"}}),n("v-data-table",{staticClass:"elevation-1",attrs:{loading:t.isLoading,items:t.events,headers:t.headers,pagination:t.pagination,search:t.search,"select-all":"","item-key":"id"},on:{"update:pagination":function(e){t.pagination=e}},scopedSlots:t._u([{key:"headers",fn:function(e){return[n("tr",[n("th",[n("v-btn",{attrs:{flat:"",icon:"",disabled:""}},[n("v-icon",{attrs:{color:"green"}},[t._v("loop")])],1)],1),t._l(e.headers,(function(e){return n("th",{key:e.text,class:["column sortable",t.pagination.descending?"desc":"asc",e.value===t.pagination.sortBy?"active":""],on:{click:function(n){return t.changeSort(e.value)}}},[n("v-icon",{attrs:{small:""}},[t._v("arrow_upward")]),t._v("\n                    "+t._s(e.text)+"\n                ")],1)}))],2)]}},{key:"items",fn:function(e){return[n("tr",{attrs:{active:e.selected},on:{click:function(n){return t.ping(e)}}},[n("td",[n("v-btn",{attrs:{flat:"",icon:"",disabled:"sync"===e.item.last_update},nativeOn:{click:function(n){return t.test(e.item.id)}}},[n("v-icon",{attrs:{small:"",color:"green"}},[t._v("loop")])],1)],1),n("td",{staticClass:"text-xs-left"},[n("small",{staticClass:"grey darken-2"},[t._v(t._s(t.frontEndDateFormat(e.item.dt)))]),t._v(" "+t._s(t.frontEndDateFromNow(e.item.dt)))]),n("td",[t._v("\n                    "+t._s(e.item.title)+"  "),n("span",{staticClass:"extPlaceCount"})]),n("td",{staticClass:"text-xs-center"},[n("small",[t._v(t._s(e.item.hall))])]),n("td",{staticClass:"text-xs-left"},["sync"!==e.item.last_update?n("small",{staticClass:"grey darken-2"},[t._v(t._s(t.frontEndDateFromNow(e.item.last_update))+" ")]):t._e()])])]}},{key:"expand",fn:function(e){return[n("v-card",[n("v-card-text",[t._v("\n                    Продано: "),t.event_info[e.item.id]?n("span",{staticClass:"atlasPlaceCount"},[t._v("  "+t._s(t.event_info[e.item.id].Tickets.tickets))]):t._e(),t._v("\n                    Прошло: "),t.event_info[e.item.id]?n("span",{staticClass:"entriesPlaceCount"},[t._v("  "+t._s(t.event_info[e.item.id].Entries.entries))]):t._e()])],1)]}}])},[n("v-progress-linear",{attrs:{slot:"progress",color:"blue",indeterminate:""},slot:"progress"})],1)],1)},xe=[],_e=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-card",{attrs:{color:"blue-grey darken-3",dark:""}},[t.show_input?n("v-card-text",[n("v-autocomplete",{attrs:{items:t.items,loading:t.isLoading,"search-input":t.search,color:"white","item-text":"building_name","item-value":"API",label:"Учреждение",placeholder:"Введите учреждение","prepend-icon":"mdi-database-search","return-object":""},on:{"update:searchInput":function(e){t.search=e},"update:search-input":function(e){t.search=e},change:t.change_input},model:{value:t.model,callback:function(e){t.model=e},expression:"model"}})],1):t._e(),n("v-divider"),n("v-expand-transition",[t.show_input?t._e():n("v-list",{staticClass:"blue-grey darken-2",attrs:{dense:""}},[n("v-list-tile",[n("small",[t._v("Учреждение")])]),t._l(t.fields,(function(e,a){return"building_name"===e.key||"building_address"===e.key?n("v-list-tile",{key:a},[n("v-list-tile-content",["building_address"===e.key?n("v-list-tile-sub-title",{domProps:{textContent:t._s(e.value)}}):t._e(),"building_name"===e.key?n("v-list-tile-title",{domProps:{textContent:t._s(e.value)}}):t._e()],1)],1):t._e()})),n("v-btn",{attrs:{disabled:!t.model,color:"grey darken-3"},on:{click:t.clear_input}},[t._v("\n                Сбросить\n                "),n("v-icon",{attrs:{right:""}},[t._v("eject")])],1)],2)],1),n("v-spacer")],1)},ye=[],je={props:["building"],data:function(){return{descriptionLimit:60,entries:[],isLoading:!1,model:null,search:null,show_input:!1}},methods:{change_input:function(t){this.$emit("change-building",{building_id:t.building_id,building_name:t.building_name,building_address:t.building_address}),this.show_input=!1},clear_input:function(){this.model=null,this.building_id=null,this.$emit("change-building",{building_id:null,building_name:null,building_address:null}),this.show_input=!0}},computed:{fields:function(){var t=this;return this.model?Object.keys(this.model).map((function(e){return{key:e,value:t.model[e]||"n/a"}})):[]},items:function(){return this.entries.map((function(t){return t.building_id=t.id,t.building_name=t.title,t.building_address=t.address,t}))}},watch:{search:function(t){var e=this;this.items.length>0||(this.isLoading=!0,U.a.get("/buildings").then((function(t){e.entries=t.data,console.log(e.items)})).catch((function(t){console.log(t)})).finally((function(){return e.isLoading=!1})))}},mounted:function(){this.model=this.building,0===this.building.building_id&&(this.show_input=!0)}},ke=je,we=Object(N["a"])(ke,_e,ye,!1,null,"16a55914",null),Oe=we.exports;function Pe(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function Ee(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?Pe(n,!0).forEach((function(e){De(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):Pe(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function De(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var Se={name:"TerminalGroup",components:{BuildingCard:Oe},props:["group"],methods:Ee({ping:function(t){var e=this;t.expanded=!t.expanded,K.getEventInfo(t.item.id).then((function(n){console.log(n.data),e.$set(e.event_info,t.item.id,n.data)}))},test:function(t){var e=this;this.syncids.push(t),this.isLoading=!0,this.events.filter((function(e){return e.id===t})).map((function(t){return t.last_update="sync"})),K.syncEvent(t).then((function(n){e.events.filter((function(t){return t.id===n.data.id})).map((function(a){var i=e.syncids.indexOf(t);return i>-1&&e.syncids.splice(i,1),0===e.syncids.length&&(e.isLoading=!1),a.last_update=n.data.last_update,a.tickets_cached=n.data.tickets_cached,a}))})).catch((function(t){e.isLoading=!1,console.log(t)})),console.log(t)},frontEndDateFormat:function(t){return Ot.a.locale("ru"),Ot.a.unix(t).format("DD/MM/YYYY HH:mm")},frontEndDateFromNow:function(t){return Ot.a.unix(t).fromNow()},changeSort:function(t){this.pagination.sortBy===t?this.pagination.descending=!this.pagination.descending:(this.pagination.sortBy=t,this.pagination.descending=!1)}},Object(u["b"])({groupsRemove:b,groupSet:x}),{building_change:function(t){var e=this;null!==t.building_id?(this.isLoading=!0,this.group=Object.assign(this.group,t),this.groupSet(this.group),setTimeout((function(){e.getEvents()}),1300)):this.events=[]},mouseOver:function(){this.hidden=!1},mouseLeave:function(){var t=this;setTimeout((function(){return t.hidden=!0}),800)},removeAction:function(){this.groupsRemove({name:this.group.name})},getEvents:function(){var t=this;this.isLoading=!0,K.getEventsByGroupId(this.group.id).then((function(e){console.log(e.data),t.events=e.data.events.slice(),t.isLoading=!1})).catch((function(e){t.isLoading=!1,console.log(e)}))}}),data:function(){return{event_info:[],syncids:[],search:"",isLoading:!1,hidden:!1,isOpen:!1,pagination:{sortBy:"dt"},headers:[{text:"Датa",value:"dt",align:"left",width:"100px"},{text:"Название мероприятия",align:"left",value:"title",width:"90%"},{text:"Зал",value:"hall",align:"right"},{text:"Актуальность данных",value:"last_update"}],events:[]}},mounted:function(){this.hidden=!1,this.getEvents()}},Ce=Se,Ae=(n("5d20"),Object(N["a"])(Ce,be,xe,!1,null,"926b7ce8",null)),Te=Ae.exports,Le=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-expansion-panel-content",[n("div",{attrs:{slot:"header"},slot:"header"},[n("b",[t._v(t._s(t.terminal.name))])]),n("v-card",{attrs:{extended:""}},[n("img",{attrs:{src:"/terminal/"+t.terminal.id+"/auth.png"}}),n("v-autocomplete",{attrs:{items:t.groups,disabled:!t.isEditing,box:"",chips:"",color:"blue-grey lighten-2",label:"Группы","item-text":"name","item-value":"name",multiple:""},scopedSlots:t._u([{key:"selection",fn:function(e){return[n("v-chip",{staticClass:"chip--select-multi",attrs:{selected:e.selected,close:t.isEditing},on:{input:function(t){return e.parent.selectItem(e.item)}}},[t._v("\n                    "+t._s(e.item.name)+"\n                ")])]}},{key:"item",fn:function(e){return["object"!==typeof e.item?[n("v-list-tile-content",{domProps:{textContent:t._s(e.item)}})]:[n("v-list-tile-content",{attrs:{hint:"123123"}},[n("v-list-tile-title",{domProps:{innerHTML:t._s(e.item.name)}}),n("v-list-tile-sub-title",{domProps:{innerHTML:t._s(e.item.group)}})],1)]]}}]),model:{value:t.model,callback:function(e){t.model=e},expression:"model"}}),n("v-btn",{attrs:{color:"blue darken-3",fab:"",small:"",absolute:"",bottom:"",right:""},on:{click:t.save_input}},[t.isEditing?n("v-icon",[t._v("done")]):n("v-icon",[t._v("edit")])],1)],1)],1)},Ge=[];function Ne(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function Re(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?Ne(n,!0).forEach((function(e){Ie(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):Ne(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function Ie(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}function qe(t,e){for(var n=0;n<e.length;n++)if(e[n].id===t)return e[n]}function Me(t,e){for(var n=0;n<e.length;n++)if(e[n].name===t)return e[n]}Array.prototype.clear=function(){this.length>0&&this.splice(0,this.length)};var Ye={name:"Terminal",components:{BuildingCard:Oe},props:["terminal"],methods:Re({},Object(u["b"])({groupsRemove:b,terminalSet:h}),{building_change:function(t){this.group=Object.assign(this.group,t),this.groupSet(this.group)},save_input:function(){var t=this;this.isEditing=!this.isEditing,this.isEditing||(this.terminal.groups=[],this.model.map((function(e){return t.terminal.groups.push(Me(e,t.groups).id)})),this.terminalSet(this.terminal))},mouseLeave:function(){var t=this;setTimeout((function(){return t.hidden=!0}),800)},removeAction:function(){this.groupsRemove({name:this.group.name})}}),data:function(){return{isEditing:!1,hidden:!1,model:[],isOpen:!1}},computed:Re({},Object(u["c"])({groups:function(t){return t.terminals.groups}})),mounted:function(){var t=this;this.hidden=!1,this.terminal.groups&&this.terminal.groups.map((function(e){return t.model.push(qe(e,t.groups).name)}))}},ze=Ye,Be=Object(N["a"])(ze,Le,Ge,!1,null,"27839ae6",null),Ue=Be.exports;function Qe(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function Fe(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?Qe(n,!0).forEach((function(e){Je(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):Qe(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function Je(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var He={name:"terminals",components:{TerminalGroup:Te,Terminal:Ue},data:function(){return{dialog:!1,dialogTerm:!1,groupName:"",terminalName:""}},computed:Fe({},Object(u["c"])({groups:function(t){return t.terminals.groups},terminals:function(t){return t.terminals.terminals}})),methods:Fe({addGroup:function(){this.dialog=!this.dialog,this.groupAdd({name:this.groupName})},addTerminal:function(){this.dialogTerm=!this.dialogTerm,this.terminalAdd({name:this.terminalName})},showDialog:function(){this.groupName="",this.dialog=!this.dialog,this.$nextTick(this.$refs.groupNameInput.focus)},showDialogTerm:function(){this.terminalName="",this.dialogTerm=!this.dialogTerm,this.$nextTick(this.$refs.terminalNameInput.focus)}},Object(u["b"])({groupsGet:m,terminalsGet:p,terminalAdd:v,groupAdd:g})),created:function(){this.groupsGet(),this.terminalsGet()}},Xe=He,Ke=Object(N["a"])(Xe,he,ge,!1,null,"9dae002c",null),We=Ke.exports,Ve=function(){var t=this,e=t.$createElement,n=t._self._c||e;return n("v-container",{attrs:{fluid:""}},[n("v-flex",{attrs:{xs12:""}},[n("v-autocomplete",{attrs:{disabled:t.isUpdating,items:t.dbs,box:"",chips:"",color:"blue-grey lighten-2",label:"Базы","item-text":"name","item-value":"name",multiple:""},scopedSlots:t._u([{key:"selection",fn:function(e){return[n("v-chip",{staticClass:"chip--select-multi",attrs:{selected:e.selected,close:""},on:{input:function(t){return e.parent.selectItem(e.item)}}},[t._v("\n                    "+t._s(e.item.name)+"\n                ")])]}},{key:"item",fn:function(e){return["object"!==typeof e.item?[n("v-list-tile-content",{domProps:{textContent:t._s(e.item)}})]:[n("v-list-tile-content",[n("v-list-tile-title",{domProps:{innerHTML:t._s(e.item.name)}})],1)]]}}]),model:{value:t.friends,callback:function(e){t.friends=e},expression:"friends"}})],1),n("v-flex",{attrs:{xs12:""}},[n("v-textarea",{attrs:{"auto-grow":"",name:"input-7-1",label:"SQL запрос",hint:"Внимательно проверьте запрос перед выполнением"},model:{value:t.sql_query,callback:function(e){t.sql_query=e},expression:"sql_query"}})],1),n("v-btn",{attrs:{color:"success"},on:{click:t.doQuery}},[t._v("Выполнить")]),n("v-data-table",{staticClass:"elevation-1",attrs:{items:t.JsonResult,headers:t.headers_data,"hide-actions":"",loading:t.isProcess},scopedSlots:t._u([{key:"items",fn:function(e){return t._l(e.item,(function(e){return n("td",{key:e.name},[t._v("\n                "+t._s(e)+"\n            ")])}))}}])},[n("v-progress-linear",{attrs:{slot:"progress",color:"blue",indeterminate:""},slot:"progress"}),n("template",{slot:"no-data"},[n("div",{staticClass:"text-xs-center"},[t._v("Нет данных")])])],2)],1)},Ze=[];function $e(t){return nn(t)||en(t)||tn()}function tn(){throw new TypeError("Invalid attempt to spread non-iterable instance")}function en(t){if(Symbol.iterator in Object(t)||"[object Arguments]"===Object.prototype.toString.call(t))return Array.from(t)}function nn(t){if(Array.isArray(t)){for(var e=0,n=new Array(t.length);e<t.length;e++)n[e]=t[e];return n}}function an(t,e){var n=Object.keys(t);if(Object.getOwnPropertySymbols){var a=Object.getOwnPropertySymbols(t);e&&(a=a.filter((function(e){return Object.getOwnPropertyDescriptor(t,e).enumerable}))),n.push.apply(n,a)}return n}function rn(t){for(var e=1;e<arguments.length;e++){var n=null!=arguments[e]?arguments[e]:{};e%2?an(n,!0).forEach((function(e){sn(t,e,n[e])})):Object.getOwnPropertyDescriptors?Object.defineProperties(t,Object.getOwnPropertyDescriptors(n)):an(n).forEach((function(e){Object.defineProperty(t,e,Object.getOwnPropertyDescriptor(n,e))}))}return t}function sn(t,e,n){return e in t?Object.defineProperty(t,e,{value:n,enumerable:!0,configurable:!0,writable:!0}):t[e]=n,t}var on={name:"SqlRunner",data:function(){return{isLoading:!1,headers:[],isUpdating:!1,sql_query:'SELECT "OrgName","OrgId","INN" FROM "Org"',friends:["Sandbox","Test"],dbs:[]}},computed:rn({},Object(u["c"])({results:function(t){return t.sqlrunner.results},isProcess:function(t){return t.sqlrunner.isProcess}}),{JsonResult:function(){var t,e=this;try{this.results.map((function(n,a){var i=JSON.parse(n);t="undefined"===typeof t?i:[].concat($e(t),$e(i)),t.push({server_alias:e.friends[a]})}))}catch(n){}return t},headers_data:function(){if(null!=this.JsonResult){var t=[],e=Object.keys(this.JsonResult[0]);return e.map((function(e){return t.push({text:e,vaule:e})})),t}return[{text:"",vaule:""}]}}),methods:rn({test:function(){console.log(this.results),console.log(this.headers_data)},doQuery:function(){var t=this;this.isLoading=!0;var e=[];this.friends.map((function(n){return e.push(t.dbs.find((function(t){return t.name===n})).connString)})),e.map((function(e){return t.sqlQuery({constring:e,query:t.sql_query})}))}},Object(u["b"])({sqlQuery:_})),watch:{isUpdating:function(t){var e=this;t&&setTimeout((function(){return e.isUpdating=!1}),3e3)}}},cn=on,ln=Object(N["a"])(cn,Ve,Ze,!1,null,"2bb5acc2",null),un=ln.exports;a["default"].use(ht["a"]);var dn=new ht["a"]({mode:"history",routes:[{path:"/",name:"home",title:"СКД",component:ae,meta:{title:"SKD"}},{path:"/about",name:"about",component:yt},{path:"/logs",name:"logs",component:Tt,meta:{requiresAuth:!0,title:"Logs"}},{path:"/upload",name:"upload",component:ve,meta:{requiresAuth:!0,title:"Logs"}},{path:"/terminals",name:"terminals",component:We,meta:{requiresAuth:!0,title:"Terminal"}},{path:"/check",name:"check",component:le,meta:{title:"Check Ticket"}},{path:"/settings",name:"settings",component:Qt,meta:{requiresAuth:!0,title:"Settings"}},{path:"/stat",name:"stat",component:ae,meta:{title:"Статистика"}},{path:"/sqlrunner",name:"sqlrunner",component:un,meta:{requiresAuth:!0}}]}),fn=n("2c0a"),mn=n.n(fn);a["default"].use(mn.a),a["default"].config.productionTip=!1,dn.beforeEach((function(t,e,n){document.title=t.meta.title,n()})),new a["default"]({store:vt,beforeCreate:function(){this.$store.commit("initialiseStore")},router:dn,render:function(t){return t(z)}}).$mount("#app")},"5d20":function(t,e,n){"use strict";var a=n("6256"),i=n.n(a);i.a},6256:function(t,e,n){},6688:function(t,e,n){},"91b8":function(t,e,n){t.exports=n.p+"img/oceanarium.c8d52b4b.svg"},ac06:function(t,e,n){},bc4f:function(t,e,n){"use strict";var a=n("eb9a"),i=n.n(a);i.a},c8f8:function(t,e,n){},eb9a:function(t,e,n){},ee37:function(t,e){t.exports="data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAADAAAAAwCAYAAABXAvmHAAAIOElEQVR42s0aC1BU1/Xc994usOBadJEQQAFlgQWEpH4yxemMtbQlBBvyswwhMQ5q1cR/YrTNOEljo9GJEm1aojVEhjGNtSEhaqa2tcmEptoYEWT5BKsBRCIqH5ddlt33bs97sviIvN19u6vlzNy5537fPZ937jnnPQIBgC2fRzH6CCYd0dlYTFjisRiw6IamWLFcxXIBixnLSUu3ULfxgcuCv88mPh+6Gg9tYOfiDoVAaS4QMkkaoJQiTrzAuxA7AhQqrH3CiQ0zO3wiRjUBr395LxccRhbiyvXYNPrLwSFoRkJ2OOxQti7jkvOOEfBmU0wOcm8Xcs+ogtPu8ZFtJISuXpl86VhACdh5LlrPcmQ3okX05iKCNSVD6/3BFcbKqQDPrTK19/lNQElDTApWH0Dg1MVbaMaSvyqlvcFnAkoaYrMo0CqUbvgwtxCwTVy1vM8X3MO8bgIkb1VKW7VqAnbh4XHwGBIQhpvIxI0PwLarlvf5gnsxz4JqlbNagYhRCdhpjknBAXHB9wKlD35CDxKRtcZ0uzrdRsD2mhg9pyWnkAdJEheQDYjLxH2zLe/3B1expgnLrLWpbX1uCdhpnvwuTi+CYRHjMpm4b7Xl/f7gqtaUrzG1Pq1IwBv1sTkg3o5jG3JRCsP3xDABr3wew4WGM/UgmksUHbhELMflbaU5anH1a5odNpr64ow25wgCdtRNKcbW3uEtb43JcXlbaY5aXP0aCovXp3+zb5iAF49GMxNjOTPc/ctKgpmGpTA9/Bfw2bfb4Ou+T7xZ0mztFUyb57QJEgGv18bNw+q4Sm75LQGxMSdyHcyOWCFdXjx1wEety2lL39+8WZ/9wvSLf5c6tp2N24/VQnUWwz8rRAhD5kZtgu8bFkn67bp9ncIgrfzml+S/N/7pYS8o25BxcRFZfSiauceovYybRKix2f7Zfob+JPplkjmxcGjPka4ET+3kQMsj0GVrVNwLsa7+63wUee1MfAY2zvipxl4DAxw8GLsVUsPzFefwPA/vtTwN7fYv3G9G4T7y2zMJSxAtBfUWQ7Xes0RD8qa8AcnjcxSdOYfDQf/asp3U2vaLgvK071Ky5aupJYit9NZ31zA6ep+hgHRYa2i75bTXfj/LaGl+3G6SOH6eojc66BiEY81b6LmBCsIwnvfFuoS8enrqh9jI80r8jBYWJLwNceOycBMBTnTsgJNX9g29U8qgYXXwaPxbEI/rlEA8fFXTZmgaPCz5ut4Azqoiv/lyWjXy4geebkCC2js/bjvqbp4sHgBivn4UPm7dRB2CddS1WjaMLJhaCrFhMxXjgQG7jVY2bSItgx+LD/L6Vka+/Yu88p9pZ3CvTE/m78cxG+GByGdGDWiu2JroofMrSLe9dcTaYFZPCxL/SKJDMxSDFttAPxxufIFecBxHyzr6s5Vw3KaGvHwqEQmADHeiuickDYpNh92Kc4Dvg8oL66Gl91OpreMmQqFxP0SGJCuusQ5Y4P2GtdDGfzripvEWkIyzZPMXxmqGRRVyQ60GxpFHY34P0yJniMkDxZBSoDxUd+6lHf215EfR68AQnKAYUlpsveS9hlXQwf9b4qUvl6LAowq9VJ30IcvR+eDJFDqD4IeGtTAnvpBqNBq/YuLe/uv0oPlZ0klPux7ikyvCO8lH5FefJe3itLDSG5EJAkBi0M9gvvEl0IdOUC9zhB7LVahoWA5dtM73tOAQ8A54k2z8R9ISjY6Uehs9iZwcTxPIIwlb6ZSINLTXjNcSuHajk1SYl8E1aBxhhXz1qzAuWEqe/yQpI0hPaqQQRcVNzDp0NDtqA5k1JR84jnNLgJh46+ptp+UNy0gPnIfvPMs3FUJDOmiBTPLUnnjm3rTgy6wGItSKEN9ZSAvNh7zkDRAaMk5xXmf3RcDDQx9p9VNpRqhPV2+HI0qi6PnjKfs1IWShGgm4alGYBpJEHzduJTGGRGlcLoFL11rIgcbl0E86AheRIY7qU7Y9u2GR1LHmqGmeNhSOo4vuczyg4fXkodhfw/2Tf0pRpSS1ae1qpAcaVxArc8XLPb3DqUDpYD9k73zQfDOgKXprKjNpWrCZC/YvpKROAjPCCyA7YRl03jiPLvE6GGCuBUxtXOAcgOa+K07T3iebhGEurKxKKw4Kw6CecSs6eXvUOVRAFXJoicA4gNF4dIfBw9jtOJpy5P7ikofO3QrqRVj0TjKnj9TUcyGQGHCWBZL7Nvja1sunlhaYR6ZVRHi2Mj0H74QjDDccc8NYyswJqKIOK83d83Dd7YktF6yonP6uNgyKZImnsZEXQsuPdr/8dw/XKqcWRSiuSNMHhbGnNKE3k7tjhQBHPzQ57XRW6RO17pO7Iiw9lJHCaqGa05ExkV53WmmP4ICsPzx21nN63QVL/pyZxWjgmCYYwv6f74BjACx4+Jy3H6vx/gOHCxa/j0RwpAotU/iIVOzdUCEqWZxugad5ex+vUf+JyQXFf8pMwRv6Ay6EGAnraXZgQPSxnDbajDdu/r4FNb5/5HPBU2UZek0wu5vRQhEbJEX4d0QCovLwdjSXg1DOO4Xnyp6s8f8zqxyeOXh/Dvppu9ggMOL7IftY7ec7gI4T6jnh7dCM6Op3Cr4K7IduOTyxJ53TTdBKvxqwGiISAlI2Qe1OVHI7AA+OrjGVfjWwW5xlB5ecvXO/Gsjh59tSmfHRIXMRLUQCchkWJhEWdYtFX4iRZXBkFxEemIj6TXlEeehCv+YIjlbc+NZ+4i9r6+7Ozx6jQe6rJmbCZF06atRsPKcJ63j4zu82qBpXsf8i4vWIn+xpt9VVbaz3+3eb/wGxx1j/I/aT+QAAAABJRU5ErkJggg=="}});
 //# sourceMappingURL=app.a25a555d.js.map
\ No newline at end of file